@@ -0,0 +1,102 @@
+package raven
+
+import (
+	"sync"
+	"time"
+)
+
+// The failure ratio over a window above which an AdaptiveSampler backs off.
+const adaptiveFailureThreshold = 0.5
+
+// AdaptiveSampler lowers the sample rate of a client while its transport
+// keeps failing, e.g. during a Sentry outage or while the project is rate
+// limited, and restores it once sends succeed again. It protects both the
+// application from piling up doomed sends and the Sentry project from a
+// flood of events once it recovers.
+//
+// Every window, the rate is halved, down to the minimum rate, if more than
+// half of the sends failed, and doubled, up to 1, if some were made and none
+// failed.
+//
+// Example:
+//
+//	client.SetAdaptiveSampler(raven.NewAdaptiveSampler(0.01, time.Minute))
+type AdaptiveSampler struct {
+	minRate float32
+	window  time.Duration
+	now     func() time.Time
+
+	mu          sync.Mutex
+	rate        float32
+	windowStart time.Time
+	sent        int
+	failed      int
+}
+
+// NewAdaptiveSampler returns a sampler adjusting the sample rate every window,
+// never below minRate.
+func NewAdaptiveSampler(minRate float32, window time.Duration) *AdaptiveSampler {
+	return &AdaptiveSampler{minRate: minRate, window: window, now: time.Now, rate: 1}
+}
+
+// Rate returns the fraction of events the sampler currently lets through,
+// applied on top of the client's sample rate.
+func (s *AdaptiveSampler) Rate() float32 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(s.now())
+	return s.rate
+}
+
+// record counts the outcome of a send.
+func (s *AdaptiveSampler) record(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.advance(s.now())
+	s.sent++
+	if err != nil {
+		s.failed++
+	}
+}
+
+// advance adjusts the rate if the current window is over. s.mu must be held.
+func (s *AdaptiveSampler) advance(now time.Time) {
+	if s.windowStart.IsZero() {
+		s.windowStart = now
+		return
+	}
+	if now.Sub(s.windowStart) < s.window {
+		return
+	}
+
+	switch {
+	case s.sent == 0:
+		// Nothing was sent, so there is no telling whether sends would
+		// succeed.
+	case s.failed == 0:
+		s.rate *= 2
+		if s.rate > 1 {
+			s.rate = 1
+		}
+	case float64(s.failed)/float64(s.sent) > adaptiveFailureThreshold:
+		s.rate /= 2
+		if s.rate < s.minRate {
+			s.rate = s.minRate
+		}
+	}
+	if s.rate != 1 {
+		debugf("raven: adaptive sample rate is now %g after %d of %d sends failed", s.rate, s.failed, s.sent)
+	}
+	s.windowStart, s.sent, s.failed = now, 0, 0
+}
+
+// SetAdaptiveSampler makes the client lower its sample rate with s while
+// sends fail. A nil s disables it.
+func (client *Client) SetAdaptiveSampler(s *AdaptiveSampler) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.adaptiveSampler = s
+}
+
+// SetAdaptiveSampler sets the adaptive sampler of the default *Client
+func SetAdaptiveSampler(s *AdaptiveSampler) { DefaultClientInstance().SetAdaptiveSampler(s) }
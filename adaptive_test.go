@@ -0,0 +1,64 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveSampler(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewAdaptiveSampler(0.2, time.Minute)
+	s.now = func() time.Time { return now }
+	failure := errors.New("connection refused")
+
+	// Each step sends events within a window, then checks the rate once it
+	// is over.
+	tests := []struct {
+		sent, failed int
+		expected     float32
+	}{
+		{10, 6, 0.5},
+		{10, 10, 0.25},
+		{10, 10, 0.2},
+		{10, 5, 0.2},
+		{10, 0, 0.4},
+		{0, 0, 0.4},
+		{10, 0, 0.8},
+		{10, 0, 1},
+		{10, 0, 1},
+	}
+	s.Rate()
+	for i, test := range tests {
+		for j := 0; j < test.sent; j++ {
+			if j < test.failed {
+				s.record(failure)
+			} else {
+				s.record(nil)
+			}
+		}
+		now = now.Add(time.Minute)
+		if rate := s.Rate(); rate != test.expected {
+			t.Errorf("incorrect rate after step %d: got %g, want %g", i, rate, test.expected)
+		}
+	}
+}
+
+func TestClientAdaptiveSampler(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := NewAdaptiveSampler(0, time.Minute)
+	s.now = func() time.Time { return now }
+
+	client, _ := newTestClient()
+	client.SetDSN("https://u@example.com/1")
+	client.Transport = &failingTransport{errors.New("connection refused")}
+	client.SetAdaptiveSampler(s)
+	for i := 0; i < 10; i++ {
+		client.CaptureMessageAndWait("failing", nil)
+	}
+
+	now = now.Add(time.Minute)
+	if rate := s.Rate(); rate != 0.5 {
+		t.Errorf("incorrect rate: got %g, want 0.5", rate)
+	}
+}
@@ -0,0 +1,124 @@
+package raven
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// Methods of *Client without a package-level wrapper on DefaultClient, as a
+// type of the same name is already declared in the package.
+var clientOnlyMethods = map[string]bool{
+	"DynamicSamplingContext": true,
+	"Severity":               true,
+}
+
+// TestPackageAPIParity fails when an exported method of *Client has no
+// package-level function of the same name using the default client, so the
+// two APIs cannot drift apart.
+func TestPackageAPIParity(t *testing.T) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	methods := map[string]bool{}
+	wrappers := map[string]bool{}
+	for _, file := range pkgs["raven"].Files {
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || !fn.Name.IsExported() {
+				continue
+			}
+			if fn.Recv != nil {
+				if star, ok := fn.Recv.List[0].Type.(*ast.StarExpr); ok {
+					if ident, ok := star.X.(*ast.Ident); ok && ident.Name == "Client" {
+						methods[fn.Name.Name] = true
+					}
+				}
+				continue
+			}
+			if usesDefaultClient(fn) {
+				wrappers[fn.Name.Name] = true
+			}
+		}
+	}
+
+	var missing []string
+	for name := range methods {
+		if !wrappers[name] && !clientOnlyMethods[name] {
+			missing = append(missing, name)
+		}
+	}
+	sort.Strings(missing)
+	if len(missing) > 0 {
+		t.Errorf("missing package-level wrappers: got %v, want none", missing)
+	}
+}
+
+// usesDefaultClient reports whether the body of fn gets the default client.
+// Some wrappers go through an unexported method, like FlushOnExit, which has
+// to call recover itself.
+func usesDefaultClient(fn *ast.FuncDecl) bool {
+	if fn.Body == nil {
+		return false
+	}
+	found := false
+	ast.Inspect(fn.Body, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Ident); ok && ident.Name == "DefaultClientInstance" {
+			found = true
+		}
+		return !found
+	})
+	return found
+}
+
+func TestAddTags(t *testing.T) {
+	client, transport := newTestClient()
+	client.AddTags(map[string]string{"region": "eu"})
+	client.AddTags(map[string]string{"shard": "7"})
+	client.ClearContext()
+
+	client.Capture(NewPacket("boom"), nil)
+	client.Wait()
+
+	packet := transport.packets[0]
+	for _, want := range []Tag{{"region", "eu"}, {"shard", "7"}} {
+		found := false
+		for _, tag := range packet.Tags {
+			if tag == want {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("incorrect tags: got %v, want %v", packet.Tags, want)
+		}
+	}
+}
+
+func TestSetDropHandler(t *testing.T) {
+	client, _ := newTestClient()
+	// Without a worker nor room in the queue every packet is dropped.
+	client.start.Do(func() {})
+	client.queue = make(chan *outgoingPacket)
+
+	dropped := make(chan *Packet, 1)
+	client.SetDropHandler(func(packet *Packet) { dropped <- packet })
+	client.Capture(NewPacket("boom"), nil)
+
+	select {
+	case packet := <-dropped:
+		if packet.Message != "boom" {
+			t.Errorf("incorrect message: got %s, want boom", packet.Message)
+		}
+	default:
+		t.Error("drop handler was not called")
+	}
+}
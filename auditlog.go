@@ -0,0 +1,198 @@
+package raven
+
+import (
+	"encoding/json"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A CaptureLog is an append-only local log of every event captured by a
+// client and of what became of it, one JSON document per line, for auditing
+// what the SDK sent and dropped. The log file is rotated once it grows past a
+// size limit.
+type CaptureLog struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+}
+
+// A captureRecord is a line of a CaptureLog.
+type captureRecord struct {
+	Time    time.Time `json:"time"`
+	EventID string    `json:"event_id,omitempty"`
+	Level   Severity  `json:"level,omitempty"`
+	Logger  string    `json:"logger,omitempty"`
+	Message string    `json:"message"`
+	Outcome string    `json:"outcome"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// OpenCaptureLog opens the capture log at path, appending to it if it exists.
+// Once the file grows past maxSize bytes, it is renamed to path.1, previous
+// backups being shifted up to path.maxBackups, and a new file is started. A
+// maxSize of 0 disables rotation.
+func OpenCaptureLog(path string, maxSize int64, maxBackups int) (*CaptureLog, error) {
+	l := &CaptureLog{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *CaptureLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	l.f, l.size = f, info.Size()
+	return nil
+}
+
+// Close closes the log file.
+func (l *CaptureLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return nil
+	}
+	err := l.f.Close()
+	l.f = nil
+	return err
+}
+
+// record appends the outcome of packet, as reported on its capture channel.
+func (l *CaptureLog) record(packet *Packet, err error) error {
+	outcome := captureLogOutcome(err)
+	if outcome == "failed" {
+		return l.write(packet, outcome, err.Error())
+	}
+	return l.write(packet, outcome, "")
+}
+
+// write appends a record of packet with the given outcome.
+func (l *CaptureLog) write(packet *Packet, outcome, errMessage string) error {
+	r := captureRecord{
+		Time:    time.Now().UTC(),
+		EventID: packet.EventID,
+		Level:   packet.Level,
+		Logger:  packet.Logger,
+		Message: packet.Message,
+		Outcome: outcome,
+		Error:   errMessage,
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.f == nil {
+		return os.ErrClosed
+	}
+	if l.maxSize > 0 && l.size > 0 && l.size+int64(len(line)) > l.maxSize {
+		if err := l.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := l.f.Write(line)
+	l.size += int64(n)
+	return err
+}
+
+// rotate shifts the backups and starts a new log file. l.mu must be held.
+func (l *CaptureLog) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	l.f = nil
+	if l.maxBackups > 0 {
+		for i := l.maxBackups - 1; i > 0; i-- {
+			os.Rename(l.backup(i), l.backup(i+1))
+		}
+		if err := os.Rename(l.path, l.backup(1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(l.path); err != nil {
+		return err
+	}
+	return l.open()
+}
+
+func (l *CaptureLog) backup(i int) string {
+	return l.path + "." + strconv.Itoa(i)
+}
+
+func captureLogOutcome(err error) string {
+	switch err {
+	case nil:
+		return "sent"
+	case ErrSampledOut:
+		return "sampled_out"
+	case ErrFiltered:
+		return "filtered"
+	case ErrPacketDropped:
+		return "dropped"
+	case ErrPacketPersisted:
+		return "persisted"
+	case ErrEventExpired:
+		return "expired"
+	case ErrTenantQuotaExceeded:
+		return "quota_exceeded"
+	}
+	return "failed"
+}
+
+// SetCaptureLog makes the client record every event it captures to l along
+// with its outcome: sent, failed, sampled out, filtered, dropped because the
+// queue was full, expired, over its tenant's quota, or persisted on Close.
+// Events handed over for delivery are first recorded as enqueued, so that
+// those in flight when the process dies still show up in the log. A nil l
+// disables the log. The client doesn't close l.
+func (client *Client) SetCaptureLog(l *CaptureLog) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.captureLog = l
+}
+
+// SetCaptureLog sets the capture log of the default *Client
+func SetCaptureLog(l *CaptureLog) { DefaultClientInstance().SetCaptureLog(l) }
+
+// logEnqueued records packet to the capture log before it is delivered.
+func (client *Client) logEnqueued(packet *Packet) {
+	client.mu.RLock()
+	l := client.captureLog
+	client.mu.RUnlock()
+
+	if l != nil {
+		if err := l.write(packet, "enqueued", ""); err != nil {
+			debugf("raven: failed to write the capture log: %v", err)
+		}
+	}
+}
+
+// resolve reports err as the outcome of packet on ch and to the capture log.
+func (client *Client) resolve(packet *Packet, ch chan error, err error) {
+	client.mu.RLock()
+	l := client.captureLog
+	client.mu.RUnlock()
+
+	if l != nil && packet != nil {
+		if lerr := l.record(packet, err); lerr != nil {
+			debugf("raven: failed to write the capture log: %v", lerr)
+		}
+	}
+	ch <- err
+}
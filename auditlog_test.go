@@ -0,0 +1,150 @@
+package raven
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readCaptureLog(t *testing.T, path string) []captureRecord {
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var records []captureRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r captureRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, r)
+	}
+	return records
+}
+
+func TestCaptureLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "captures.jsonl")
+
+	l, err := OpenCaptureLog(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	client, _ := newTestClient()
+	client.SetCaptureLog(l)
+	client.SetIgnoreErrors([]string{"ignored"})
+
+	client.CaptureMessageAndWait("sent", nil)
+	client.Capture(NewPacket("ignored"), nil)
+	client.Transport = &failingTransport{errors.New("connection refused")}
+	client.CaptureMessageAndWait("failed", nil)
+	client.SetSampleRate(0)
+	client.Capture(NewPacket("sampled"), nil)
+
+	expected := []captureRecord{
+		{Message: "sent", Outcome: "enqueued"},
+		{Message: "sent", Outcome: "sent"},
+		{Message: "ignored", Outcome: "filtered"},
+		{Message: "failed", Outcome: "enqueued"},
+		{Message: "failed", Outcome: "failed", Error: "connection refused"},
+		{Message: "sampled", Outcome: "sampled_out"},
+	}
+	records := readCaptureLog(t, path)
+	if len(records) != len(expected) {
+		t.Fatalf("incorrect record count: got %d, want %d", len(records), len(expected))
+	}
+	for i, r := range records {
+		if r.Message != expected[i].Message || r.Outcome != expected[i].Outcome || r.Error != expected[i].Error {
+			t.Errorf("incorrect record %d: got %+v, want %+v", i, r, expected[i])
+		}
+		if r.Time.IsZero() {
+			t.Errorf("record %d has no time", i)
+		}
+	}
+	if records[0].EventID == "" || records[0].EventID != records[1].EventID {
+		t.Errorf("expected the event ID of the sent event in both its records, got %q and %q", records[0].EventID, records[1].EventID)
+	}
+}
+
+func TestCaptureLogRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "captures.jsonl")
+
+	l, err := OpenCaptureLog(path, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	for _, message := range []string{"first", "second", "third", "fourth"} {
+		if err := l.record(&Packet{Message: message}, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[string]string{
+		path:        "fourth",
+		path + ".1": "third",
+		path + ".2": "second",
+	}
+	for file, message := range expected {
+		records := readCaptureLog(t, file)
+		if len(records) != 1 || records[0].Message != message {
+			t.Errorf("incorrect records in %s: got %+v, want %s", filepath.Base(file), records, message)
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected no third backup: %v", err)
+	}
+}
+
+func TestCaptureLogInFlight(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "captures.jsonl")
+
+	l, err := OpenCaptureLog(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	blocking := &blockingTransport{make(chan struct{}), make(chan struct{})}
+	client, _ := newTestClient()
+	client.Transport = blocking
+	client.SetCaptureLog(l)
+
+	client.CaptureMessage("in flight", nil)
+	<-blocking.started
+	records := readCaptureLog(t, path)
+	if len(records) != 1 || records[0].Message != "in flight" || records[0].Outcome != "enqueued" {
+		t.Errorf("incorrect records while the event is in flight: %+v", records)
+	}
+
+	close(blocking.release)
+	client.Wait()
+	records = readCaptureLog(t, path)
+	if len(records) != 2 || records[1].Outcome != "sent" {
+		t.Errorf("incorrect records once the event is sent: %+v", records)
+	}
+}
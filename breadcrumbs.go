@@ -0,0 +1,91 @@
+package raven
+
+import "time"
+
+// The maximum number of breadcrumbs a client keeps. Older ones are discarded
+// first.
+var MaxBreadcrumbs = 100
+
+// A Breadcrumb records something that happened before an event, e.g. an HTTP
+// request, a query or a user action.
+// https://develop.sentry.dev/sdk/event-payloads/breadcrumbs/
+type Breadcrumb struct {
+	Timestamp Timestamp              `json:"timestamp"`
+	Type      string                 `json:"type,omitempty"`
+	Category  string                 `json:"category,omitempty"`
+	Message   string                 `json:"message,omitempty"`
+	Level     Severity               `json:"level,omitempty"`
+	Data      map[string]interface{} `json:"data,omitempty"`
+}
+
+// Breadcrumbs is the interface holding the breadcrumbs sent with an event.
+type Breadcrumbs struct {
+	Values []*Breadcrumb `json:"values"`
+}
+
+func (b *Breadcrumbs) Class() string { return "breadcrumbs" }
+
+func (c *context) addBreadcrumb(b *Breadcrumb) {
+	if MaxBreadcrumbs <= 0 {
+		return
+	}
+	if len(c.breadcrumbs) >= MaxBreadcrumbs {
+		c.breadcrumbs = c.breadcrumbs[len(c.breadcrumbs)-MaxBreadcrumbs+1:]
+	}
+	c.breadcrumbs = append(c.breadcrumbs, b)
+}
+
+// AddBreadcrumb records b, to be sent with every subsequent event until the
+// context is cleared. The timestamp defaults to now.
+func (client *Client) AddBreadcrumb(b *Breadcrumb) {
+	if b == nil {
+		return
+	}
+	if time.Time(b.Timestamp).IsZero() {
+		b.Timestamp = Timestamp(time.Now())
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.context.addBreadcrumb(b)
+}
+
+// AddBreadcrumb records b on the default *Client
+func AddBreadcrumb(b *Breadcrumb) { DefaultClientInstance().AddBreadcrumb(b) }
+
+// addBreadcrumbs attaches the client's breadcrumbs to packet, unless it
+// already has some. client.mu must be held.
+func (client *Client) addBreadcrumbs(packet *Packet) {
+	if len(client.context.breadcrumbs) == 0 {
+		return
+	}
+	for _, inter := range packet.Interfaces {
+		if _, ok := inter.(*Breadcrumbs); ok {
+			return
+		}
+	}
+	values := append([]*Breadcrumb(nil), client.context.breadcrumbs...)
+	packet.Interfaces = append(packet.Interfaces, &Breadcrumbs{Values: values})
+}
+
+// withBreadcrumb returns a copy of packet with b appended to its breadcrumbs,
+// leaving packet untouched.
+func withBreadcrumb(packet *Packet, b *Breadcrumb) *Packet {
+	p := *packet
+	p.Interfaces = make([]Interface, 0, len(packet.Interfaces)+1)
+	found := false
+	for _, inter := range packet.Interfaces {
+		if crumbs, ok := inter.(*Breadcrumbs); ok && !found {
+			values := append(append([]*Breadcrumb(nil), crumbs.Values...), b)
+			if MaxBreadcrumbs > 0 && len(values) > MaxBreadcrumbs {
+				values = values[len(values)-MaxBreadcrumbs:]
+			}
+			inter, found = &Breadcrumbs{Values: values}, true
+		}
+		p.Interfaces = append(p.Interfaces, inter)
+	}
+	if !found {
+		p.Interfaces = append(p.Interfaces, &Breadcrumbs{Values: []*Breadcrumb{b}})
+	}
+	return &p
+}
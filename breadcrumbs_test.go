@@ -0,0 +1,37 @@
+package raven
+
+import "testing"
+
+func TestAddBreadcrumb(t *testing.T) {
+	defer func(max int) { MaxBreadcrumbs = max }(MaxBreadcrumbs)
+	MaxBreadcrumbs = 2
+
+	client, transport := newTestClient()
+	for _, message := range []string{"a", "b", "c"} {
+		client.AddBreadcrumb(&Breadcrumb{Message: message})
+	}
+	client.CaptureMessage("failed", nil)
+	client.Wait()
+
+	var breadcrumbs *Breadcrumbs
+	for _, inter := range transport.packets[0].Interfaces {
+		if b, ok := inter.(*Breadcrumbs); ok {
+			breadcrumbs = b
+		}
+	}
+	if breadcrumbs == nil || len(breadcrumbs.Values) != 2 {
+		t.Fatalf("incorrect breadcrumbs: %+v", breadcrumbs)
+	}
+	if breadcrumbs.Values[0].Message != "b" || breadcrumbs.Values[1].Message != "c" {
+		t.Errorf("incorrect breadcrumbs: got %s, %s, want b, c", breadcrumbs.Values[0].Message, breadcrumbs.Values[1].Message)
+	}
+
+	client.ClearContext()
+	client.CaptureMessage("failed", nil)
+	client.Wait()
+	for _, inter := range transport.packets[1].Interfaces {
+		if _, ok := inter.(*Breadcrumbs); ok {
+			t.Error("expected breadcrumbs to be cleared")
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package raven
+
+// CaptureErrorSkip is like CaptureError, but leaves skip more frames out of
+// the stack trace. Helpers wrapping it pass the number of their own frames so
+// that their caller, not the helper, is reported as the culprit.
+func (client *Client) CaptureErrorSkip(skip int, err error, tags map[string]string, interfaces ...Interface) string {
+	return client.captureError(skip+1, err, tags, interfaces)
+}
+
+// CaptureErrorSkip is like CaptureError with the default *Client, but leaves
+// skip more frames out of the stack trace.
+func CaptureErrorSkip(skip int, err error, tags map[string]string, interfaces ...Interface) string {
+	return DefaultClientInstance().captureError(skip+1, err, tags, interfaces)
+}
+
+// NewErrorPacket builds the packet CaptureError would send for err, but leaves
+// skip more frames out of the stack trace. Fields CaptureError takes no
+// parameter for, e.g. the level or fingerprint, can then be set before
+// passing the packet to Capture.
+func (client *Client) NewErrorPacket(skip int, err error, interfaces ...Interface) *Packet {
+	return client.newErrorPacket(skip+1, err, interfaces)
+}
+
+// NewErrorPacket builds the packet CaptureError would send for err with the
+// default *Client, leaving skip more frames out of the stack trace.
+func NewErrorPacket(skip int, err error, interfaces ...Interface) *Packet {
+	return DefaultClientInstance().newErrorPacket(skip+1, err, interfaces)
+}
+
+// WithCaller makes the function containing pc, e.g. as returned by
+// runtime.Caller, the culprit of the event it is passed to, overriding the one
+// derived from its stack trace. It is passed along with the interfaces of an
+// event but never sent as one.
+func WithCaller(pc uintptr) Interface { return callerOverride(pc) }
+
+type callerOverride uintptr
+
+func (c callerOverride) Class() string { return "caller" }
+
+func (c callerOverride) Culprit() string {
+	module, function := functionName(uintptr(c))
+	if function == "" {
+		return ""
+	}
+	if module == "" {
+		return function
+	}
+	return module + "." + function
+}
+
+// applyCaller removes any WithCaller option from the packet's interfaces and
+// sets the culprit it overrides.
+func (packet *Packet) applyCaller() {
+	var interfaces []Interface
+	for i, inter := range packet.Interfaces {
+		c, ok := inter.(callerOverride)
+		if !ok {
+			if interfaces != nil {
+				interfaces = append(interfaces, inter)
+			}
+			continue
+		}
+		if interfaces == nil {
+			interfaces = append(make([]Interface, 0, len(packet.Interfaces)), packet.Interfaces[:i]...)
+		}
+		if culprit := c.Culprit(); culprit != "" {
+			packet.Culprit = culprit
+		}
+	}
+	if interfaces != nil {
+		packet.Interfaces = interfaces
+	}
+}
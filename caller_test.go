@@ -0,0 +1,127 @@
+package raven
+
+import (
+	gocontext "context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func reportError(client *Client, skip int, err error) {
+	client.CaptureErrorSkip(skip, err, nil)
+}
+
+func reportFromCaller(client *Client, err error) {
+	pc, _, _, _ := runtime.Caller(1)
+	client.CaptureError(err, nil, WithCaller(pc))
+}
+
+func TestCaptureErrorSkip(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetIncludePaths([]string{"github.com/getsentry/raven-go"})
+
+	reportError(client, 0, errors.New("failed"))
+	reportError(client, 1, errors.New("failed"))
+	client.Wait()
+
+	expected := []string{
+		"github.com/getsentry/raven-go.reportError",
+		"github.com/getsentry/raven-go.TestCaptureErrorSkip",
+	}
+	if len(transport.packets) != len(expected) {
+		t.Fatalf("incorrect packet count: got %d, want %d", len(transport.packets), len(expected))
+	}
+	for i, packet := range transport.packets {
+		if packet.Culprit != expected[i] {
+			t.Errorf("incorrect culprit: got %s, want %s", packet.Culprit, expected[i])
+		}
+	}
+}
+
+func TestWithCaller(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetIncludePaths([]string{"github.com/getsentry/raven-go"})
+
+	reportFromCaller(client, errors.New("failed"))
+	client.Wait()
+
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+	packet := transport.packets[0]
+	if expected := "github.com/getsentry/raven-go.TestWithCaller"; packet.Culprit != expected {
+		t.Errorf("incorrect culprit: got %s, want %s", packet.Culprit, expected)
+	}
+	for _, inter := range packet.Interfaces {
+		if inter.Class() == "caller" {
+			t.Errorf("caller option sent as an interface: %+v", packet.Interfaces)
+		}
+	}
+}
+
+func TestNewErrorPacket(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetIncludePaths([]string{"github.com/getsentry/raven-go"})
+
+	packet := client.NewErrorPacket(0, errors.New("failed"))
+	packet.Level = WARNING
+	packet.Fingerprint = []string{"failed"}
+	client.Capture(packet, nil)
+	client.Wait()
+
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+	packet = transport.packets[0]
+	if expected := "github.com/getsentry/raven-go.TestNewErrorPacket"; packet.Culprit != expected {
+		t.Errorf("incorrect culprit: got %s, want %s", packet.Culprit, expected)
+	}
+	if packet.Level != WARNING {
+		t.Errorf("incorrect level: got %s, want %s", packet.Level, WARNING)
+	}
+	if packet.err == nil {
+		t.Error("packet lost the error it was built from")
+	}
+}
+
+func TestErrorCapturesHonourWithCaller(t *testing.T) {
+	captures := map[string]func(client *Client, err error, caller Interface){
+		"CaptureErrorAndWait": func(client *Client, err error, caller Interface) {
+			client.CaptureErrorAndWait(err, nil, caller)
+		},
+		"CaptureErrorAndWaitCtx": func(client *Client, err error, caller Interface) {
+			client.CaptureErrorAndWaitCtx(gocontext.Background(), err, nil, caller)
+		},
+		"CaptureErrorThrottled": func(client *Client, err error, caller Interface) {
+			client.CaptureErrorThrottled(err, "", time.Minute, nil, caller)
+		},
+		"CaptureHTTPError": func(client *Client, err error, caller Interface) {
+			client.CaptureHTTPError(nil, err, nil, caller)
+		},
+	}
+	for name, capture := range captures {
+		client, transport := newTestClient()
+		client.SetUserContext(&User{ID: "42"})
+		pc, _, _, _ := runtime.Caller(0)
+		capture(client, errors.New("failed"), WithCaller(pc))
+		client.Wait()
+
+		if len(transport.packets) != 1 {
+			t.Fatalf("%s: incorrect packet count: got %d, want 1", name, len(transport.packets))
+		}
+		packet := transport.packets[0]
+		if expected := "github.com/getsentry/raven-go.TestErrorCapturesHonourWithCaller"; packet.Culprit != expected {
+			t.Errorf("%s: incorrect culprit: got %s, want %s", name, packet.Culprit, expected)
+		}
+		var user *User
+		for _, inter := range packet.Interfaces {
+			if u, ok := inter.(*User); ok {
+				user = u
+			}
+		}
+		if user == nil {
+			t.Errorf("%s: user context not sent", name)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package raven
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	interfaceTypesMu sync.RWMutex
+	interfaceTypes   = map[string]func() Interface{
+		"breadcrumbs": func() Interface { return &Breadcrumbs{} },
+		"logentry":    func() Interface { return &Message{} },
+		"query":       func() Interface { return &Query{} },
+		"request":     func() Interface { return &Http{} },
+		"stacktrace":  func() Interface { return &Stacktrace{} },
+		"template":    func() Interface { return &Template{} },
+		"threads":     func() Interface { return &Threads{} },
+		"user":        func() Interface { return &User{} },
+	}
+)
+
+// RegisterInterface makes UnmarshalCanonical decode the interfaces of class
+// into the values returned by new, e.g. for custom interfaces. Interfaces of
+// unknown classes are kept as raw JSON.
+func RegisterInterface(class string, new func() Interface) {
+	interfaceTypesMu.Lock()
+	defer interfaceTypesMu.Unlock()
+	interfaceTypes[class] = new
+}
+
+// rawInterface is an interface of an unknown class read back from a
+// serialized packet, kept as is so that it is sent unchanged.
+type rawInterface struct {
+	class string
+	data  json.RawMessage
+}
+
+func (i *rawInterface) Class() string                { return i.class }
+func (i *rawInterface) MarshalJSON() ([]byte, error) { return i.data, nil }
+
+// packetFields holds the JSON keys of the Packet struct fields; any other key
+// in a serialized packet is an interface.
+var packetFields = func() map[string]bool {
+	fields := make(map[string]bool)
+	t := reflect.TypeOf(Packet{})
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			fields[name] = true
+		}
+	}
+	return fields
+}()
+
+// MarshalCanonical serializes the packet as sent to Sentry, with its
+// interfaces keyed by class and all keys sorted, so that the same packet
+// always gives the same bytes. It is meant for archiving events, which
+// UnmarshalCanonical reads back.
+func (packet *Packet) MarshalCanonical() ([]byte, error) {
+	packetJSON, err := packet.JSON()
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(packetJSON, &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalCanonical reads back a packet serialized by MarshalCanonical or
+// Packet.JSON, decoding its interfaces into their types.
+func (packet *Packet) UnmarshalCanonical(data []byte) error {
+	var p Packet
+	if err := json.Unmarshal(data, &p); err != nil {
+		return err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	classes := make([]string, 0, len(fields))
+	for key := range fields {
+		if !packetFields[key] {
+			classes = append(classes, key)
+		}
+	}
+	sort.Strings(classes)
+
+	for _, class := range classes {
+		inter, err := unmarshalInterface(class, fields[class])
+		if err != nil {
+			return err
+		}
+		p.Interfaces = append(p.Interfaces, inter)
+	}
+
+	*packet = p
+	return nil
+}
+
+func unmarshalInterface(class string, data json.RawMessage) (Interface, error) {
+	var inter Interface
+	if class == "exception" {
+		// A single exception or a chain of them
+		var probe struct {
+			Values json.RawMessage `json:"values"`
+		}
+		if json.Unmarshal(data, &probe) == nil && probe.Values != nil {
+			inter = &Exceptions{}
+		} else {
+			inter = &Exception{}
+		}
+	} else {
+		interfaceTypesMu.RLock()
+		new, ok := interfaceTypes[class]
+		interfaceTypesMu.RUnlock()
+		if !ok {
+			return &rawInterface{class, data}, nil
+		}
+		inter = new()
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(inter); err != nil {
+		return nil, err
+	}
+	return inter, nil
+}
@@ -0,0 +1,77 @@
+package raven
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type testCustomInterface struct {
+	Name string `json:"name"`
+}
+
+func (i *testCustomInterface) Class() string { return "custom" }
+
+func TestCanonicalRoundTrip(t *testing.T) {
+	packet := NewPacket("failed",
+		&Message{"%s failed", []interface{}{"job"}},
+		NewException(errors.New("failed"), NewStacktrace(0, 0, nil)),
+		&Http{URL: "https://example.com/", Method: "GET"},
+		&User{ID: "42"},
+		&testCustomInterface{"unregistered"},
+	)
+	packet.Init("1")
+	packet.AddTags(map[string]string{"region": "eu"})
+
+	data, err := packet.MarshalCanonical()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual Packet
+	if err := actual.UnmarshalCanonical(data); err != nil {
+		t.Fatal(err)
+	}
+
+	again, _ := actual.MarshalCanonical()
+	if string(again) != string(data) {
+		t.Errorf("incorrect round trip: got %s, want %s", again, data)
+	}
+
+	classes := make(map[string]reflect.Type)
+	for _, inter := range actual.Interfaces {
+		classes[inter.Class()] = reflect.TypeOf(inter)
+	}
+	expected := map[string]reflect.Type{
+		"logentry":  reflect.TypeOf(&Message{}),
+		"exception": reflect.TypeOf(&Exception{}),
+		"request":   reflect.TypeOf(&Http{}),
+		"user":      reflect.TypeOf(&User{}),
+		"custom":    reflect.TypeOf(&rawInterface{}),
+	}
+	if !reflect.DeepEqual(classes, expected) {
+		t.Errorf("incorrect interfaces: got %v, want %v", classes, expected)
+	}
+
+	RegisterInterface("custom", func() Interface { return &testCustomInterface{} })
+	defer func() {
+		interfaceTypesMu.Lock()
+		delete(interfaceTypes, "custom")
+		interfaceTypesMu.Unlock()
+	}()
+	actual.UnmarshalCanonical(data)
+	for _, inter := range actual.Interfaces {
+		if c, ok := inter.(*testCustomInterface); inter.Class() == "custom" && (!ok || c.Name != "unregistered") {
+			t.Errorf("incorrect custom interface: %+v", inter)
+		}
+	}
+}
+
+func TestUnmarshalCanonicalExceptions(t *testing.T) {
+	var packet Packet
+	if err := packet.UnmarshalCanonical([]byte(`{"message":"failed","exception":{"values":[{"value":"a"},{"value":"b"}]}}`)); err != nil {
+		t.Fatal(err)
+	}
+	if es, ok := packet.Interfaces[0].(*Exceptions); !ok || len(es.Values) != 2 {
+		t.Errorf("incorrect exceptions: %+v", packet.Interfaces)
+	}
+}
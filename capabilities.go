@@ -0,0 +1,214 @@
+package raven
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ServerCapabilities lists the optional event fields a Sentry server accepts.
+// Old self-hosted servers answer 400 to events carrying fields they predate,
+// so events are stripped of the fields their server lacks support for.
+type ServerCapabilities struct {
+	Environment bool
+	SDK         bool
+	Contexts    bool
+	Fingerprint bool
+	Breadcrumbs bool
+	Threads     bool
+}
+
+// FullCapabilities is what current Sentry servers accept, assumed unless set
+// otherwise with SetServerCapabilities.
+var FullCapabilities = ServerCapabilities{
+	Environment: true,
+	SDK:         true,
+	Contexts:    true,
+	Fingerprint: true,
+	Breadcrumbs: true,
+	Threads:     true,
+}
+
+// LegacyCapabilities leaves out every optional field, for servers predating
+// all of them.
+var LegacyCapabilities = ServerCapabilities{}
+
+// The keys events serialize the optional fields as.
+var capabilityKeys = []string{"environment", "sdk", "contexts", "fingerprint", "breadcrumbs", "threads"}
+
+// without returns caps lacking support for the field serialized as key.
+func (caps ServerCapabilities) without(key string) ServerCapabilities {
+	switch key {
+	case "environment":
+		caps.Environment = false
+	case "sdk":
+		caps.SDK = false
+	case "contexts":
+		caps.Contexts = false
+	case "fingerprint":
+		caps.Fingerprint = false
+	case "breadcrumbs":
+		caps.Breadcrumbs = false
+	case "threads":
+		caps.Threads = false
+	}
+	return caps
+}
+
+// strip returns packet without the fields caps lacks, along with their keys.
+// packet itself is returned when it holds none of them.
+func (caps ServerCapabilities) strip(packet *Packet) (*Packet, []string) {
+	stripped := *packet
+	var keys []string
+	if !caps.Environment && packet.Environment != "" {
+		stripped.Environment = ""
+		keys = append(keys, "environment")
+	}
+	if !caps.SDK && packet.SDK != nil {
+		stripped.SDK = nil
+		keys = append(keys, "sdk")
+	}
+	if !caps.Contexts && len(packet.Contexts) > 0 {
+		stripped.Contexts = nil
+		keys = append(keys, "contexts")
+	}
+	if !caps.Fingerprint && len(packet.Fingerprint) > 0 {
+		stripped.Fingerprint = nil
+		keys = append(keys, "fingerprint")
+	}
+	if !caps.Breadcrumbs || !caps.Threads {
+		stripped.Interfaces = nil
+		for _, inter := range packet.Interfaces {
+			switch inter.(type) {
+			case *Breadcrumbs:
+				if !caps.Breadcrumbs {
+					keys = append(keys, "breadcrumbs")
+					continue
+				}
+			case *Threads:
+				if !caps.Threads {
+					keys = append(keys, "threads")
+					continue
+				}
+			}
+			stripped.Interfaces = append(stripped.Interfaces, inter)
+		}
+	}
+	if len(keys) == 0 {
+		return packet, nil
+	}
+	return &stripped, keys
+}
+
+// learnedCapabilities are the capabilities a server was found to have. Those
+// guessed from rejections naming no field expire, so that the server is probed
+// again, e.g. once it's upgraded.
+type learnedCapabilities struct {
+	caps    ServerCapabilities
+	expires time.Time
+}
+
+// How many 400s naming no field a server must answer in a row before events
+// are sent to it without any optional field, and for how long.
+var (
+	capabilityRejectionLimit = 3
+	capabilityProbeInterval  = time.Hour
+)
+
+// SetServerCapabilities sets the optional event fields the Sentry servers
+// events are sent to accept, e.g. LegacyCapabilities for an old self-hosted
+// server. Events are sent without the others.
+func (client *Client) SetServerCapabilities(caps ServerCapabilities) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.serverCapabilities = &caps
+	client.learnedCapabilities = nil
+	client.capabilityRejections = nil
+}
+
+// SetServerCapabilities sets the server capabilities of the default *Client
+func SetServerCapabilities(caps ServerCapabilities) {
+	DefaultClientInstance().SetServerCapabilities(caps)
+}
+
+// SetDetectServerCapabilities makes the client find out what a server accepts
+// from the events it rejects with a 400: an event is sent again without the
+// fields named in the X-Sentry-Error header, and later events to the same
+// server are sent without them too. A 400 naming no field may be about the
+// event itself, so only after several in a row are events sent without any
+// optional field, for an hour before the server is probed again.
+func (client *Client) SetDetectServerCapabilities(detect bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.detectCapabilities = detect
+}
+
+// SetDetectServerCapabilities controls whether the default *Client detects server capabilities
+func SetDetectServerCapabilities(detect bool) {
+	DefaultClientInstance().SetDetectServerCapabilities(detect)
+}
+
+// capabilitiesFor returns the capabilities of the server at url.
+func (client *Client) capabilitiesFor(url string) ServerCapabilities {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	learned, ok := client.learnedCapabilities[url]
+	if ok && (learned.expires.IsZero() || time.Now().Before(learned.expires)) {
+		return learned.caps
+	}
+	if client.serverCapabilities != nil {
+		return *client.serverCapabilities
+	}
+	return FullCapabilities
+}
+
+// learnCapabilities narrows caps down for the server at url when err is the
+// 400 it rejected an event with, reporting whether it did.
+func (client *Client) learnCapabilities(url string, caps ServerCapabilities, err error) (ServerCapabilities, bool) {
+	if err == nil {
+		client.mu.RLock()
+		rejected := client.capabilityRejections[url] > 0
+		client.mu.RUnlock()
+		if rejected {
+			client.mu.Lock()
+			delete(client.capabilityRejections, url)
+			client.mu.Unlock()
+		}
+		return caps, false
+	}
+	e, ok := err.(statusCoder)
+	if !ok || e.StatusCode() != http.StatusBadRequest {
+		return caps, false
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if !client.detectCapabilities {
+		return caps, false
+	}
+
+	narrowed := caps
+	message := strings.ToLower(err.Error())
+	for _, key := range capabilityKeys {
+		if strings.Contains(message, key) {
+			narrowed = narrowed.without(key)
+		}
+	}
+	learned := learnedCapabilities{caps: narrowed}
+	if narrowed == caps {
+		if client.capabilityRejections == nil {
+			client.capabilityRejections = make(map[string]int)
+		}
+		client.capabilityRejections[url]++
+		if client.capabilityRejections[url] < capabilityRejectionLimit || caps == LegacyCapabilities {
+			return caps, false
+		}
+		learned = learnedCapabilities{LegacyCapabilities, time.Now().Add(capabilityProbeInterval)}
+	}
+	delete(client.capabilityRejections, url)
+	if client.learnedCapabilities == nil {
+		client.learnedCapabilities = make(map[string]learnedCapabilities)
+	}
+	client.learnedCapabilities[url] = learned
+	return learned.caps, true
+}
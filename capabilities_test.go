@@ -0,0 +1,166 @@
+package raven
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServerCapabilitiesStrip(t *testing.T) {
+	packet := NewPacket("test", &Breadcrumbs{}, &Threads{}, &Message{Message: "test"})
+	packet.Environment = "production"
+	packet.SDK = &SDKInfo{Name: "raven-go"}
+	packet.Contexts = map[string]interface{}{"os": "linux"}
+	packet.Fingerprint = []string{"test"}
+
+	tests := []struct {
+		caps       ServerCapabilities
+		stripped   []string
+		interfaces int
+	}{
+		{FullCapabilities, nil, 3},
+		{FullCapabilities.without("environment"), []string{"environment"}, 3},
+		{FullCapabilities.without("threads"), []string{"threads"}, 2},
+		{LegacyCapabilities, []string{"environment", "sdk", "contexts", "fingerprint", "breadcrumbs", "threads"}, 1},
+	}
+	for i, test := range tests {
+		got, stripped := test.caps.strip(packet)
+		if !reflect.DeepEqual(stripped, test.stripped) {
+			t.Errorf("%d: incorrect stripped fields: got %v, want %v", i, stripped, test.stripped)
+		}
+		if len(got.Interfaces) != test.interfaces {
+			t.Errorf("%d: incorrect interface count: got %d, want %d", i, len(got.Interfaces), test.interfaces)
+		}
+		if test.stripped == nil && got != packet {
+			t.Errorf("%d: packet copied without stripping anything", i)
+		}
+	}
+	if packet.Environment != "production" || len(packet.Interfaces) != 3 {
+		t.Error("original packet was modified")
+	}
+}
+
+func TestSetServerCapabilities(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetDSN("https://u@example.com/1")
+	client.SetEnvironment("production")
+	client.SetServerCapabilities(LegacyCapabilities)
+
+	client.Capture(NewPacket("test"), nil)
+	client.Wait()
+
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+	if packet := transport.packets[0]; packet.Environment != "" || packet.SDK != nil {
+		t.Errorf("optional fields sent: environment %q, sdk %v", packet.Environment, packet.SDK)
+	}
+}
+
+// oldServerTransport rejects events carrying an environment, as servers
+// predating it do.
+type oldServerTransport struct {
+	mu       sync.Mutex
+	requests int
+	accepted []*Packet
+}
+
+func (t *oldServerTransport) Send(url, authHeader string, packet *Packet) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests++
+	if packet.Environment != "" {
+		return &statusError{"raven: got http status 400 - x-sentry-error: Invalid value for 'environment'", http.StatusBadRequest}
+	}
+	t.accepted = append(t.accepted, packet)
+	return nil
+}
+
+func TestDetectServerCapabilities(t *testing.T) {
+	tests := []struct {
+		detect   bool
+		requests int
+		accepted int
+	}{
+		{false, 2, 0},
+		{true, 3, 2},
+	}
+	for _, test := range tests {
+		transport := &oldServerTransport{}
+		client := newClient(nil)
+		client.SetTransport(transport)
+		client.SetDSN("https://u@example.com/1")
+		client.SetEnvironment("production")
+		client.SetDetectServerCapabilities(test.detect)
+
+		for i := 0; i < 2; i++ {
+			_, ch := client.Capture(NewPacket("test"), nil)
+			<-ch
+		}
+
+		if transport.requests != test.requests {
+			t.Errorf("detect %v: incorrect request count: got %d, want %d", test.detect, transport.requests, test.requests)
+		}
+		if len(transport.accepted) != test.accepted {
+			t.Errorf("detect %v: incorrect accepted count: got %d, want %d", test.detect, len(transport.accepted), test.accepted)
+		}
+		for _, packet := range transport.accepted {
+			if packet.SDK == nil {
+				t.Errorf("detect %v: sdk stripped along with the environment", test.detect)
+			}
+		}
+	}
+}
+
+// vagueServerTransport rejects events carrying an sdk without saying why.
+type vagueServerTransport struct {
+	requests int
+	accepted int
+}
+
+func (t *vagueServerTransport) Send(url, authHeader string, packet *Packet) error {
+	t.requests++
+	if packet.SDK != nil {
+		return &statusError{"raven: got http status 400 - x-sentry-error: Bad data", http.StatusBadRequest}
+	}
+	t.accepted++
+	return nil
+}
+
+func TestDetectServerCapabilitiesWithoutField(t *testing.T) {
+	transport := &vagueServerTransport{}
+	client := newClient(nil)
+	client.SetTransport(transport)
+	client.SetDSN("https://u@example.com/1")
+	client.SetDetectServerCapabilities(true)
+
+	capture := func() error {
+		_, ch := client.Capture(NewPacket("test"), nil)
+		return <-ch
+	}
+	for i := 1; i < capabilityRejectionLimit; i++ {
+		if err := capture(); err == nil {
+			t.Fatalf("%d: expected the event to be rejected", i)
+		}
+	}
+	if transport.requests != capabilityRejectionLimit-1 {
+		t.Errorf("incorrect request count: got %d, want %d", transport.requests, capabilityRejectionLimit-1)
+	}
+	if err := capture(); err != nil {
+		t.Fatalf("expected the event to be sent without optional fields, got %v", err)
+	}
+	if err := capture(); err != nil || transport.accepted != 2 {
+		t.Errorf("incorrect accepted count: got %d (%v), want 2", transport.accepted, err)
+	}
+
+	client.mu.Lock()
+	learned := client.learnedCapabilities[client.url]
+	learned.expires = time.Now().Add(-time.Second)
+	client.learnedCapabilities[client.url] = learned
+	client.mu.Unlock()
+	if err := capture(); err == nil {
+		t.Error("expected the server to be probed again once the capabilities expired")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !raven_nocertifi && !raven_minimal && !js
+// +build !raven_nocertifi,!raven_minimal,!js
+
+package raven
+
+import (
+	"crypto/x509"
+
+	"github.com/certifi/gocertifi"
+)
+
+// defaultRootCAs returns the certifi root certificates, so that the client
+// trusts Sentry even on hosts without a CA bundle. Build with the
+// raven_nocertifi tag to drop the dependency and use the system roots. It is
+// left out of js builds, where the browser verifies certificates.
+func defaultRootCAs() (*x509.CertPool, error) {
+	return gocertifi.CACerts()
+}
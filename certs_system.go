@@ -0,0 +1,12 @@
+//go:build raven_nocertifi || raven_minimal || js
+// +build raven_nocertifi raven_minimal js
+
+package raven
+
+import "crypto/x509"
+
+// defaultRootCAs returns nil, making transports use the system root
+// certificates, or those of the browser on js.
+func defaultRootCAs() (*x509.CertPool, error) {
+	return nil, nil
+}
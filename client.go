@@ -2,36 +2,31 @@
 package raven
 
 import (
-	"bytes"
-	"compress/zlib"
+	gocontext "context"
+	"crypto/cipher"
 	"crypto/rand"
-	"crypto/tls"
-	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"log"
 	mrand "math/rand"
 	"net/http"
-	"net/url"
 	"os"
-	"regexp"
-	"runtime"
+	"reflect"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 
-	"github.com/certifi/gocertifi"
 	pkgErrors "github.com/pkg/errors"
 )
 
-const (
-	userAgent       = "raven-go/1.0"
-	timestampFormat = `"2006-01-02T15:04:05.00"`
-)
+const timestampFormat = `"2006-01-02T15:04:05.00"`
+
+var userAgent = "raven-go/" + sdkVersion
 
 var (
 	ErrPacketDropped         = errors.New("raven: packet dropped")
@@ -39,6 +34,14 @@ var (
 	ErrMissingUser           = errors.New("raven: dsn missing public key and/or password")
 	ErrMissingProjectID      = errors.New("raven: dsn missing project id")
 	ErrInvalidSampleRate     = errors.New("raven: sample rate should be between 0 and 1")
+	ErrNotConfigured         = errors.New("raven: no dsn configured")
+	ErrSampledOut            = errors.New("raven: event sampled out")
+	ErrFiltered              = errors.New("raven: event filtered")
+	ErrClientClosed          = errors.New("raven: client closed")
+	ErrEventExpired          = errors.New("raven: event older than its time to live")
+	ErrTenantQuotaExceeded   = errors.New("raven: tenant quota exceeded")
+	ErrEnvelopesUnsupported  = errors.New("raven: transport can't send envelopes")
+	ErrInvalidInterval       = errors.New("raven: interval should be positive")
 )
 
 type Severity string
@@ -88,11 +91,35 @@ type Transport interface {
 	Send(url, authHeader string, packet *Packet) error
 }
 
+// A ContextTransport is a Transport that can give up sending a packet when a
+// context is done, which synchronous clients rely on to enforce their
+// timeout.
+type ContextTransport interface {
+	Transport
+	SendContext(ctx gocontext.Context, url, authHeader string, packet *Packet) error
+}
+
+// An EnvelopeTransport is a Transport that can also send envelopes holding
+// items other than events, such as check-ins, to the envelope endpoint at url.
+type EnvelopeTransport interface {
+	Transport
+	SendEnvelope(ctx gocontext.Context, url, authHeader string, envelope []byte) error
+}
+
 type Extra map[string]interface{}
 
 type outgoingPacket struct {
 	packet *Packet
 	ch     chan error
+
+	// Where to send the packet, as configured when it was captured, and the
+	// public key of the DSN authHeader was built for.
+	url        string
+	authHeader string
+	publicKey  string
+
+	// The file to remove once the packet is delivered, see SetDurableDir.
+	journal string
 }
 
 type Tag struct {
@@ -115,10 +142,94 @@ func (t *Tag) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
+// MarshalJSON writes the tags as an array of [key, value] pairs in a single
+// pass, since events can carry many tags.
+func (t Tags) MarshalJSON() ([]byte, error) {
+	if t == nil {
+		return []byte("null"), nil
+	}
+	size := 2
+	for _, tag := range t {
+		size += len(tag.Key) + len(tag.Value) + 8
+	}
+	buf := make([]byte, 0, size)
+	buf = append(buf, '[')
+	for i, tag := range t {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, '[')
+		buf = appendJSONString(buf, tag.Key)
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, tag.Value)
+		buf = append(buf, ']')
+	}
+	return append(buf, ']'), nil
+}
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString appends s as a JSON string, escaped like encoding/json does.
+func appendJSONString(buf []byte, s string) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if c := s[i]; c < utf8.RuneSelf {
+			if c >= 0x20 && c != '"' && c != '\\' && c != '<' && c != '>' && c != '&' {
+				i++
+				continue
+			}
+			buf = append(buf, s[start:i]...)
+			switch c {
+			case '"', '\\':
+				buf = append(buf, '\\', c)
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hexDigits[c>>4], hexDigits[c&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, string(utf8.RuneError)...)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			buf = append(buf, s[start:i]...)
+			buf = append(buf, '\\', 'u', '2', '0', '2', hexDigits[r&0xf])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	buf = append(buf, s[start:]...)
+	return append(buf, '"')
+}
+
 func (t *Tags) UnmarshalJSON(data []byte) error {
 	var tags []Tag
 
+	if len(data) == 0 {
+		return ErrUnableToUnmarshalJSON
+	}
 	switch data[0] {
+	case 'n':
+		// Leave the tags as they are on null, as encoding/json does
+		if string(data) != "null" {
+			return ErrUnableToUnmarshalJSON
+		}
+		return nil
 	case '[':
 		// Unmarshal into []Tag
 		if err := json.Unmarshal(data, &tags); err != nil {
@@ -161,47 +272,54 @@ type Packet struct {
 	ServerName  string            `json:"server_name,omitempty"`
 	Release     string            `json:"release,omitempty"`
 	Environment string            `json:"environment,omitempty"`
+	Transaction string            `json:"transaction,omitempty"`
+	SDK         *SDKInfo          `json:"sdk,omitempty"`
 	Tags        Tags              `json:"tags,omitempty"`
 	Modules     map[string]string `json:"modules,omitempty"`
 	Fingerprint []string          `json:"fingerprint,omitempty"`
 	Extra       Extra             `json:"extra,omitempty"`
 
+	// Named contexts, e.g. "os", "runtime" or application specific ones.
+	// https://develop.sentry.dev/sdk/event-payloads/contexts/
+	Contexts map[string]interface{} `json:"contexts,omitempty"`
+
 	Interfaces []Interface `json:"-"`
+
+	// HTTP headers sent along with the event, e.g. routing hints for Relay.
+	// They are not part of the event, nor saved in queue files.
+	Headers http.Header `json:"-"`
+
+	// The error the packet was built from, if any.
+	err error
+
+	// The tenant the packet was captured for, if any, see ForTenant.
+	tenant *Tenant
 }
 
 // NewPacket constructs a packet with the specified message and interfaces.
 func NewPacket(message string, interfaces ...Interface) *Packet {
-	extra := Extra{}
-	setExtraDefaults(extra)
 	return &Packet{
 		Message:    message,
 		Interfaces: interfaces,
-		Extra:      extra,
+		Extra:      Extra{},
 	}
 }
 
 // NewPacketWithExtra constructs a packet with the specified message, extra information, and interfaces.
+// The packet gets a copy of extra.
 func NewPacketWithExtra(message string, extra Extra, interfaces ...Interface) *Packet {
-	if extra == nil {
-		extra = Extra{}
+	copied := make(Extra, len(extra))
+	for k, v := range extra {
+		copied[k] = v
 	}
-	setExtraDefaults(extra)
 
 	return &Packet{
 		Message:    message,
 		Interfaces: interfaces,
-		Extra:      extra,
+		Extra:      copied,
 	}
 }
 
-func setExtraDefaults(extra Extra) Extra {
-	extra["runtime.Version"] = runtime.Version()
-	extra["runtime.NumCPU"] = runtime.NumCPU()
-	extra["runtime.GOMAXPROCS"] = runtime.GOMAXPROCS(0) // 0 just returns the current value
-	extra["runtime.NumGoroutine"] = runtime.NumGoroutine()
-	return extra
-}
-
 // Init initializes required fields in a packet. It is typically called by
 // Client.Send/Report automatically.
 func (packet *Packet) Init(project string) error {
@@ -231,6 +349,16 @@ func (packet *Packet) Init(project string) error {
 		packet.Platform = "go"
 	}
 
+	packet.applyCaller()
+	packet.applyHeaders()
+	packet.deriveCulprit()
+
+	return nil
+}
+
+// deriveCulprit sets the culprit and transaction of the packet from its
+// interfaces, unless they are already set.
+func (packet *Packet) deriveCulprit() {
 	if packet.Culprit == "" {
 		for _, inter := range packet.Interfaces {
 			if c, ok := inter.(Culpriter); ok {
@@ -241,8 +369,22 @@ func (packet *Packet) Init(project string) error {
 			}
 		}
 	}
+	if packet.Transaction == "" {
+		packet.Transaction = packet.Culprit
+	}
+}
 
-	return nil
+// AddContexts sets the named contexts that aren't already set on the packet.
+func (packet *Packet) AddContexts(contexts map[string]interface{}) {
+	for k, v := range contexts {
+		if _, ok := packet.Contexts[k]; ok {
+			continue
+		}
+		if packet.Contexts == nil {
+			packet.Contexts = make(map[string]interface{})
+		}
+		packet.Contexts[k] = v
+	}
 }
 
 func (packet *Packet) AddTags(tags map[string]string) {
@@ -251,6 +393,17 @@ func (packet *Packet) AddTags(tags map[string]string) {
 	}
 }
 
+// setTag sets the tag key of packet to value, replacing any value it had.
+func (packet *Packet) setTag(key, value string) {
+	for i, tag := range packet.Tags {
+		if tag.Key == key {
+			packet.Tags[i].Value = value
+			return
+		}
+	}
+	packet.Tags = append(packet.Tags, Tag{key, value})
+}
+
 func uuid() (string, error) {
 	id := make([]byte, 16)
 	_, err := io.ReadFull(rand.Reader, id)
@@ -290,9 +443,11 @@ func (packet *Packet) JSON() ([]byte, error) {
 }
 
 type context struct {
-	user *User
-	http *Http
-	tags map[string]string
+	user        *User
+	http        *Http
+	tags        map[string]string
+	contexts    map[string]interface{}
+	breadcrumbs []*Breadcrumb
 }
 
 func (c *context) setUser(u *User) { c.user = u }
@@ -305,10 +460,29 @@ func (c *context) setTags(t map[string]string) {
 		c.tags[k] = v
 	}
 }
+func (c *context) setContext(name string, value interface{}) {
+	if c.contexts == nil {
+		c.contexts = make(map[string]interface{})
+	}
+	if value == nil {
+		delete(c.contexts, name)
+		return
+	}
+	c.contexts[name] = value
+}
 func (c *context) clear() {
 	c.user = nil
 	c.http = nil
 	c.tags = nil
+	c.contexts = nil
+	c.breadcrumbs = nil
+}
+
+// contextInterfaces returns the interfaces of the client's context.
+func (client *Client) contextInterfaces() []Interface {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	return client.context.interfaces()
 }
 
 // Return a list of interfaces to be used in appending with the rest
@@ -336,33 +510,29 @@ func (c *context) interfaces() []Interface {
 // Packets will be dropped if the buffer is full. Used by NewClient.
 var MaxQueueBuffer = 100
 
-func newTransport() Transport {
-	t := &HTTPTransport{}
-	rootCAs, err := gocertifi.CACerts()
-	if err != nil {
-		log.Println("raven: failed to load root TLS certificates:", err)
-	} else {
-		t.Client = &http.Client{
-			Transport: &http.Transport{
-				Proxy:           http.ProxyFromEnvironment,
-				TLSClientConfig: &tls.Config{RootCAs: rootCAs},
-			},
-		}
-	}
-	return t
-}
-
 func newClient(tags map[string]string) *Client {
+	// Copy the tags, so that the caller modifying its map doesn't race
+	// with captures.
+	if tags != nil {
+		tags = copyTags(tags)
+	}
 	client := &Client{
 		Transport:  newTransport(),
 		Tags:       tags,
 		context:    &context{},
 		sampleRate: 1.0,
 		queue:      make(chan *outgoingPacket, MaxQueueBuffer),
+		sdk:        defaultSDK(),
 	}
 	client.SetDSN(os.Getenv("SENTRY_DSN"))
 	client.SetRelease(os.Getenv("SENTRY_RELEASE"))
 	client.SetEnvironment(os.Getenv("SENTRY_ENVIRONMENT"))
+	if names := os.Getenv("SENTRY_ENV_TAGS"); names != "" {
+		client.SetEnvTags(strings.Split(names, ","))
+	}
+	if dev, _ := strconv.ParseBool(os.Getenv("SENTRY_DEV")); dev {
+		client.Transport = NewDevTransport()
+	}
 	return client
 }
 
@@ -389,7 +559,8 @@ func NewClient(dsn string, tags map[string]string) (*Client, error) {
 
 // Client encapsulates a connection to a Sentry server. It must be initialized
 // by calling NewClient. Modification of fields concurrently with Send or after
-// calling Report for the first time is not thread-safe.
+// calling Report for the first time is not thread-safe: use AddTags,
+// SetTransport, SetDropHandler and SetHeartbeatMissedHandler instead.
 type Client struct {
 	Tags map[string]string
 
@@ -398,45 +569,130 @@ type Client struct {
 	// DropHandler is called when a packet is dropped because the buffer is full.
 	DropHandler func(*Packet)
 
+	// HeartbeatMissedHandler is called when a heartbeat started by
+	// StartHeartbeat could not be delivered, or was late because the process
+	// stalled, so that Sentry may report the monitor as missed.
+	HeartbeatMissedHandler func(monitorSlug string, err error)
+
 	// Context that will get appending to all packets
 	context *context
 
 	mu          sync.RWMutex
 	url         string
 	projectID   string
+	publicKey   string
 	authHeader  string
+	dsn         *DSN
+	strictDSN   bool
 	release     string
 	environment string
+	serverName  string
 	sampleRate  float32
 
+	noHostContext    bool
+	noRuntimeContext bool
+	splitMultiErrors bool
+	lazyStacktraces  bool
+	severityMapper   SeverityMapper
+	dsnRoutes        []*dsnRoute
+	routeResolver    RouteResolver
+
+	// Where events go when no DSN is set
+	fallback io.Writer
+
+	captureLog *CaptureLog
+
+	sdk SDKInfo
+
+	protocolVersion int
+	sendSecretKey   bool
+
+	serverCapabilities   *ServerCapabilities
+	detectCapabilities   bool
+	learnedCapabilities  map[string]learnedCapabilities
+	capabilityRejections map[string]int
+
+	tracesSampleRate float32
+	tracesSampler    TracesSampler
+
 	// default logger name (leave empty for 'root')
 	defaultLoggerName string
 
-	includePaths       []string
-	ignoreErrorsRegexp *regexp.Regexp
-	queue              chan *outgoingPacket
+	processors []EventProcessor
+
+	includePaths      []string
+	ignoreErrors      errorMatcher
+	ignoreErrorTypes  []reflect.Type
+	queue             chan *outgoingPacket
+	queueFile         string
+	closed            bool
+	syncTimeout       time.Duration
+	durableDir        string
+	eventTTL          time.Duration
+	adaptiveSampler   *AdaptiveSampler
+	queueFileCipher   cipher.AEAD
+	queueCodec        PacketCodec
+	services          []servicePrefix
+	tenants           map[string]*Tenant
+	tenantRoutes      map[string]*dsnRoute
+	tenantQuota       int
+	tenantQuotaWindow time.Duration
+	idGenerator       IDGenerator
+	envTags           map[string]string
 
 	// A WaitGroup to keep track of all currently in-progress captures
 	// This is intended to be used with Client.Wait() to assure that
 	// all messages have been transported before exiting the process.
-	wg sync.WaitGroup
+	wg inFlight
 
 	// A Once to track only starting up the background worker once
 	start sync.Once
+
+	stats    clientStats
+	throttle throttle
 }
 
-// Initialize a default *Client instance
+// Initialize a default *Client instance. The package-level functions use it
+// until another client is installed with SetDefaultClient; assigning to it
+// is not safe once events may be captured concurrently.
 var DefaultClient = newClient(nil)
 
+// defaultClient holds the client installed with SetDefaultClient, wrapped so
+// that a nil client can be stored to go back to DefaultClient.
+var defaultClient atomic.Value
+
+type defaultClientHolder struct{ client *Client }
+
+// SetDefaultClient atomically replaces the client used by the package-level
+// functions, so that a fully configured client can be installed at startup
+// while other goroutines are already capturing. A nil client reverts to
+// DefaultClient.
+func SetDefaultClient(client *Client) {
+	defaultClient.Store(defaultClientHolder{client})
+}
+
+// DefaultClientInstance returns the client used by the package-level
+// functions: the one installed with SetDefaultClient, or DefaultClient.
+func DefaultClientInstance() *Client {
+	if holder, ok := defaultClient.Load().(defaultClientHolder); ok && holder.client != nil {
+		return holder.client
+	}
+	return DefaultClient
+}
+
+// An errorMatcher matches the messages of errors to ignore.
+type errorMatcher interface {
+	MatchString(s string) bool
+}
+
 func (c *Client) SetIgnoreErrors(errs []string) error {
-	joinedRegexp := strings.Join(errs, "|")
-	r, err := regexp.Compile(joinedRegexp)
+	m, err := compileIgnoreErrors(errs)
 	if err != nil {
-		return fmt.Errorf("failed to compile regexp %q for %q: %v", joinedRegexp, errs, err)
+		return err
 	}
 
 	c.mu.Lock()
-	c.ignoreErrorsRegexp = r
+	c.ignoreErrors = m
 	c.mu.Unlock()
 	return nil
 }
@@ -444,15 +700,47 @@ func (c *Client) SetIgnoreErrors(errs []string) error {
 func (c *Client) shouldExcludeErr(errStr string) bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
-	return c.ignoreErrorsRegexp != nil && c.ignoreErrorsRegexp.MatchString(errStr)
+	return c.ignoreErrors != nil && c.ignoreErrors.MatchString(errStr)
 }
 
 func SetIgnoreErrors(errs ...string) error {
-	return DefaultClient.SetIgnoreErrors(errs)
+	return DefaultClientInstance().SetIgnoreErrors(errs)
+}
+
+// SetIgnoreErrorTypes makes the client ignore errors whose chain contains a
+// value of one of types, whatever their message. Interface types match any
+// error implementing them. Other types must implement error.
+//
+// Example:
+//
+//	client.SetIgnoreErrorTypes([]reflect.Type{reflect.TypeOf((*ValidationError)(nil))})
+func (c *Client) SetIgnoreErrorTypes(types []reflect.Type) error {
+	for _, t := range types {
+		if t == nil || t.Kind() != reflect.Interface && !t.Implements(errorType) {
+			return fmt.Errorf("raven: %v is not an error type", t)
+		}
+	}
+
+	c.mu.Lock()
+	c.ignoreErrorTypes = append([]reflect.Type(nil), types...)
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Client) shouldExcludeErrType(err error) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return hasErrorType(err, c.ignoreErrorTypes)
+}
+
+// SetIgnoreErrorTypes sets the error types ignored by the default *Client
+func SetIgnoreErrorTypes(types ...reflect.Type) error {
+	return DefaultClientInstance().SetIgnoreErrorTypes(types)
 }
 
 // SetDSN updates a client with a new DSN. It safe to call after and
-// concurrently with calls to Report and Send.
+// concurrently with calls to Report and Send. Events captured before the call
+// are still sent to the previous DSN, even if they are still queued.
 func (client *Client) SetDSN(dsn string) error {
 	if dsn == "" {
 		return nil
@@ -461,39 +749,22 @@ func (client *Client) SetDSN(dsn string) error {
 	client.mu.Lock()
 	defer client.mu.Unlock()
 
-	uri, err := url.Parse(dsn)
+	d, err := ParseDSN(dsn, client.strictDSN)
 	if err != nil {
 		return err
 	}
 
-	if uri.User == nil {
-		return ErrMissingUser
-	}
-	publicKey := uri.User.Username()
-	secretKey, hasSecretKey := uri.User.Password()
-	uri.User = nil
-
-	if idx := strings.LastIndex(uri.Path, "/"); idx != -1 {
-		client.projectID = uri.Path[idx+1:]
-		uri.Path = uri.Path[:idx+1] + "api/" + client.projectID + "/store/"
-	}
-	if client.projectID == "" {
-		return ErrMissingProjectID
-	}
-
-	client.url = uri.String()
-
-	if hasSecretKey {
-		client.authHeader = fmt.Sprintf("Sentry sentry_version=4, sentry_key=%s, sentry_secret=%s", publicKey, secretKey)
-	} else {
-		client.authHeader = fmt.Sprintf("Sentry sentry_version=4, sentry_key=%s", publicKey)
-	}
+	client.url = d.StoreURL()
+	client.projectID = d.ProjectID
+	client.publicKey = d.PublicKey
+	client.dsn = d
+	client.updateAuthHeader()
 
 	return nil
 }
 
 // Sets the DSN for the default *Client instance
-func SetDSN(dsn string) error { return DefaultClient.SetDSN(dsn) }
+func SetDSN(dsn string) error { return DefaultClientInstance().SetDSN(dsn) }
 
 // SetRelease sets the "release" tag.
 func (client *Client) SetRelease(release string) {
@@ -509,6 +780,61 @@ func (client *Client) SetEnvironment(environment string) {
 	client.environment = environment
 }
 
+// AddTags adds tags to the Tags of the client, sent with every event. Unlike
+// the tags set with SetTagsContext, they are kept by ClearContext.
+func (client *Client) AddTags(tags map[string]string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	// Captures read the map without holding the lock for long: replace it
+	// rather than modifying it.
+	merged := make(map[string]string, len(client.Tags)+len(tags))
+	for k, v := range client.Tags {
+		merged[k] = v
+	}
+	for k, v := range tags {
+		merged[k] = v
+	}
+	client.Tags = merged
+}
+
+// AddTags adds tags to the Tags of the default *Client
+func AddTags(tags map[string]string) { DefaultClientInstance().AddTags(tags) }
+
+// SetTransport sets the transport events are sent with.
+func (client *Client) SetTransport(t Transport) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.Transport = t
+}
+
+// SetTransport sets the transport of the default *Client
+func SetTransport(t Transport) { DefaultClientInstance().SetTransport(t) }
+
+// SetDropHandler sets the DropHandler of the client, called when a packet is
+// dropped because the buffer is full.
+func (client *Client) SetDropHandler(h func(*Packet)) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.DropHandler = h
+}
+
+// SetDropHandler sets the DropHandler of the default *Client
+func SetDropHandler(h func(*Packet)) { DefaultClientInstance().SetDropHandler(h) }
+
+// SetHeartbeatMissedHandler sets the HeartbeatMissedHandler of the client,
+// called when a heartbeat could not be delivered or was late.
+func (client *Client) SetHeartbeatMissedHandler(h func(monitorSlug string, err error)) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.HeartbeatMissedHandler = h
+}
+
+// SetHeartbeatMissedHandler sets the HeartbeatMissedHandler of the default *Client
+func SetHeartbeatMissedHandler(h func(monitorSlug string, err error)) {
+	DefaultClientInstance().SetHeartbeatMissedHandler(h)
+}
+
 // SetDefaultLoggerName sets the default logger name.
 func (client *Client) SetDefaultLoggerName(name string) {
 	client.mu.Lock()
@@ -529,34 +855,92 @@ func (client *Client) SetSampleRate(rate float32) error {
 }
 
 // SetRelease sets the "release" tag on the default *Client
-func SetRelease(release string) { DefaultClient.SetRelease(release) }
+func SetRelease(release string) { DefaultClientInstance().SetRelease(release) }
 
 // SetEnvironment sets the "environment" tag on the default *Client
-func SetEnvironment(environment string) { DefaultClient.SetEnvironment(environment) }
+func SetEnvironment(environment string) { DefaultClientInstance().SetEnvironment(environment) }
 
 // SetDefaultLoggerName sets the "defaultLoggerName" on the default *Client
 func SetDefaultLoggerName(name string) {
-	DefaultClient.SetDefaultLoggerName(name)
+	DefaultClientInstance().SetDefaultLoggerName(name)
 }
 
 // SetSampleRate sets the "sample rate" on the degault *Client
-func SetSampleRate(rate float32) error { return DefaultClient.SetSampleRate(rate) }
+func SetSampleRate(rate float32) error { return DefaultClientInstance().SetSampleRate(rate) }
 
 func (client *Client) worker() {
 	for outgoingPacket := range client.queue {
+		client.deliver(gocontext.Background(), outgoingPacket)
+	}
+}
 
-		client.mu.RLock()
-		url, authHeader := client.url, client.authHeader
-		client.mu.RUnlock()
+// deliver sends a packet taken off the queue, or captured by a synchronous
+// client, and resolves its channel.
+func (client *Client) deliver(ctx gocontext.Context, outgoingPacket *outgoingPacket) {
+	url, authHeader := outgoingPacket.url, outgoingPacket.authHeader
+	client.mu.RLock()
+	transport := client.Transport
+	fallback := client.fallback
+	ttl := client.eventTTL
+	adaptive := client.adaptiveSampler
+	client.mu.RUnlock()
 
-		outgoingPacket.ch <- client.Transport.Send(url, authHeader, outgoingPacket.packet)
+	packet := outgoingPacket.packet
+	if ttl > 0 && time.Since(time.Time(packet.Timestamp)) > ttl {
+		ack(outgoingPacket.journal)
+		client.stats.recordExpired(packet)
+		client.resolve(packet, outgoingPacket.ch, ErrEventExpired)
 		client.wg.Done()
+		return
+	}
+	packet.resolveStacktraces()
+	crumb, loss := client.stats.lossBreadcrumb()
+	if crumb != nil {
+		packet = withBreadcrumb(packet, crumb)
+	}
+
+	send := func(packet *Packet) error {
+		if url == "" && fallback != nil {
+			return writeFallback(fallback, packet)
+		} else if t, ok := transport.(ContextTransport); ok {
+			return t.SendContext(ctx, url, authHeader, packet)
+		}
+		return transport.Send(url, authHeader, packet)
+	}
+
+	var err error
+	if url == "" {
+		err = send(packet)
+	} else {
+		caps := client.capabilitiesFor(url)
+		packet, _ = caps.strip(packet)
+		err = send(packet)
+		if learned, ok := client.learnCapabilities(url, caps, err); ok {
+			if retry, stripped := learned.strip(packet); len(stripped) > 0 {
+				debugf("raven: event %s was rejected, retrying without %s", packet.EventID, strings.Join(stripped, ", "))
+				err = send(retry)
+			}
+		}
+	}
+	// Without a DSN, the packet went nowhere
+	if err == nil && (url != "" || fallback != nil) {
+		ack(outgoingPacket.journal)
+	}
+	if adaptive != nil && url != "" {
+		adaptive.record(err)
 	}
+	if err == nil && crumb != nil {
+		client.stats.clearLoss(loss)
+	}
+	client.stats.recordSend(outgoingPacket.packet, err)
+	client.resolve(outgoingPacket.packet, outgoingPacket.ch, err)
+	client.wg.Done()
 }
 
 // Capture asynchronously delivers a packet to the Sentry server. It is a no-op
 // when client is nil. A channel is provided if it is important to check for a
-// send's success.
+// send's success; it always receives an outcome, ErrSampledOut or ErrFiltered
+// if the event was dropped before being sent.
 func (client *Client) Capture(packet *Packet, captureTags map[string]string) (eventID string, ch chan error) {
 	ch = make(chan error, 1)
 
@@ -566,17 +950,36 @@ func (client *Client) Capture(packet *Packet, captureTags map[string]string) (ev
 		return
 	}
 
-	if client.sampleRate < 1.0 && mrand.Float32() > client.sampleRate {
+	if !client.sample() {
+		client.resolve(packet, ch, ErrSampledOut)
 		return
 	}
 
+	return client.capture(packet, captureTags, ch)
+}
+
+// sample makes the sampling decision for an event.
+func (client *Client) sample() bool {
+	client.mu.RLock()
+	rate := client.sampleRate
+	adaptive := client.adaptiveSampler
+	client.mu.RUnlock()
+	if adaptive != nil {
+		rate *= adaptive.Rate()
+	}
+	return rate >= 1.0 || mrand.Float32() <= rate
+}
+
+// capture is Capture past the sampling decision.
+func (client *Client) capture(packet *Packet, captureTags map[string]string, ch chan error) (string, chan error) {
 	if packet == nil {
 		close(ch)
-		return
+		return "", ch
 	}
 
-	if client.shouldExcludeErr(packet.Message) {
-		return
+	if client.shouldExcludeErr(packet.Message) || client.shouldExcludeErrType(packet.err) {
+		client.resolve(packet, ch, ErrFiltered)
+		return "", ch
 	}
 
 	// Keep track of all running Captures so that we can wait for them all to finish
@@ -584,29 +987,57 @@ func (client *Client) Capture(packet *Packet, captureTags map[string]string) (ev
 	// finished being acted upon, whether success or failure
 	client.wg.Add(1)
 
-	// Merge capture tags and client tags
+	// Merge capture tags, client tags and tags of the error
+	packet.snapshot()
 	packet.AddTags(captureTags)
-	packet.AddTags(client.Tags)
+	client.mu.RLock()
+	clientTags := client.Tags
+	client.mu.RUnlock()
+	packet.AddTags(clientTags)
+	packet.AddTags(extractTags(packet.err))
 
 	// Initialize any required packet fields
 	client.mu.RLock()
 	packet.AddTags(client.context.tags)
+	packet.AddTags(client.envTags)
+	packet.AddContexts(client.context.contexts)
+	client.addBreadcrumbs(packet)
 	projectID := client.projectID
+	url, authHeader := client.url, client.authHeader
+	var publicKey string
+	if client.dsn != nil {
+		publicKey = client.dsn.PublicKey
+	}
 	release := client.release
 	environment := client.environment
 	defaultLoggerName := client.defaultLoggerName
+	serverName := client.serverName
+	idGenerator := client.idGenerator
+	services := client.services
+	hostContext := !client.noHostContext
+	runtimeContext := !client.noRuntimeContext
+	sdk := client.sdk
 	client.mu.RUnlock()
 
+	if len(services) > 0 {
+		assignService(packet, services)
+	}
+
 	// set the global logger name on the packet if we must
 	if packet.Logger == "" && defaultLoggerName != "" {
 		packet.Logger = defaultLoggerName
 	}
 
+	if packet.ServerName == "" {
+		packet.ServerName = serverName
+	}
+	generateEventID(packet, idGenerator)
+
 	err := packet.Init(projectID)
 	if err != nil {
-		ch <- err
+		client.resolve(packet, ch, err)
 		client.wg.Done()
-		return
+		return "", ch
 	}
 
 	if packet.Release == "" {
@@ -617,7 +1048,57 @@ func (client *Client) Capture(packet *Packet, captureTags map[string]string) (ev
 		packet.Environment = environment
 	}
 
-	outgoingPacket := &outgoingPacket{packet, ch}
+	if packet.SDK == nil {
+		packet.SDK = &sdk
+	}
+
+	if hostContext {
+		addHostContexts(packet)
+	}
+	if runtimeContext {
+		addRuntimeContext(packet)
+	}
+
+	if !client.processPacket(packet) {
+		client.resolve(packet, ch, ErrFiltered)
+		client.wg.Done()
+		return "", ch
+	}
+
+	if packet.tenant != nil && !packet.tenant.allow(time.Now()) {
+		client.resolve(packet, ch, ErrTenantQuotaExceeded)
+		client.wg.Done()
+		return "", ch
+	}
+
+	if route := client.matchDSNRoute(packet); route != nil {
+		if packet.Project == projectID {
+			packet.Project = route.dsn.ProjectID
+		}
+		url, authHeader, publicKey = route.url, route.authHeader, route.dsn.PublicKey
+	}
+
+	client.enqueue(packet, url, authHeader, publicKey, ch)
+	return packet.EventID, ch
+}
+
+// enqueue hands packet over to the background worker, which sends it to url
+// and resolves ch. client.wg must already account for packet.
+func (client *Client) enqueue(packet *Packet, url, authHeader, publicKey string, ch chan error) {
+	outgoingPacket := &outgoingPacket{packet: packet, ch: ch, url: url, authHeader: authHeader, publicKey: publicKey}
+	outgoingPacket.journal = client.journal(outgoingPacket)
+	client.logEnqueued(packet)
+
+	client.mu.RLock()
+	syncTimeout, closed := client.syncTimeout, client.closed
+	client.mu.RUnlock()
+	if syncTimeout > 0 && !closed {
+		client.stats.recordQueued(packet)
+		ctx, cancel := gocontext.WithTimeout(gocontext.Background(), syncTimeout)
+		defer cancel()
+		client.deliver(ctx, outgoingPacket)
+		return
+	}
 
 	// Lazily start background worker until we
 	// do our first write into the queue.
@@ -625,25 +1106,42 @@ func (client *Client) Capture(packet *Packet, captureTags map[string]string) (ev
 		go client.worker()
 	})
 
-	select {
-	case client.queue <- outgoingPacket:
+	// Hold the lock while sending so that Close can't close the queue
+	// meanwhile.
+	client.mu.RLock()
+	closed, queued := client.closed, false
+	dropHandler := client.DropHandler
+	if !closed {
+		select {
+		case client.queue <- outgoingPacket:
+			queued = true
+		default:
+		}
+	}
+	client.mu.RUnlock()
+
+	switch {
+	case closed:
+		client.resolve(packet, ch, ErrClientClosed)
+		client.wg.Done()
+	case queued:
+		client.stats.recordQueued(packet)
 	default:
 		// Send would block, drop the packet
-		if client.DropHandler != nil {
-			client.DropHandler(packet)
+		client.stats.recordDropped(packet)
+		if dropHandler != nil {
+			dropHandler(packet)
 		}
-		ch <- ErrPacketDropped
+		client.resolve(packet, ch, ErrPacketDropped)
 		client.wg.Done()
 	}
-
-	return packet.EventID, ch
 }
 
 // Capture asynchronously delivers a packet to the Sentry server with the default *Client.
 // It is a no-op when client is nil. A channel is provided if it is important to check for a
 // send's success.
 func Capture(packet *Packet, captureTags map[string]string) (eventID string, ch chan error) {
-	return DefaultClient.Capture(packet, captureTags)
+	return DefaultClientInstance().Capture(packet, captureTags)
 }
 
 // CaptureMessage formats and delivers a string message to the Sentry server.
@@ -656,7 +1154,7 @@ func (client *Client) CaptureMessage(message string, tags map[string]string, int
 		return ""
 	}
 
-	packet := NewPacket(message, append(append(interfaces, client.context.interfaces()...), &Message{message, nil})...)
+	packet := NewPacket(message, append(append(interfaces, client.contextInterfaces()...), &Message{message, nil})...)
 	eventID, _ := client.Capture(packet, tags)
 
 	return eventID
@@ -664,7 +1162,65 @@ func (client *Client) CaptureMessage(message string, tags map[string]string, int
 
 // CaptureMessage formats and delivers a string message to the Sentry server with the default *Client
 func CaptureMessage(message string, tags map[string]string, interfaces ...Interface) string {
-	return DefaultClient.CaptureMessage(message, tags, interfaces...)
+	return DefaultClientInstance().CaptureMessage(message, tags, interfaces...)
+}
+
+// CaptureMessagef formats a message according to format and delivers it to
+// the Sentry server. Events are grouped by format rather than by the formatted
+// message.
+func (client *Client) CaptureMessagef(tags map[string]string, format string, args ...interface{}) string {
+	if client == nil {
+		return ""
+	}
+
+	message := fmt.Sprintf(format, args...)
+	if client.shouldExcludeErr(message) {
+		return ""
+	}
+
+	packet := NewPacket(message, append(client.contextInterfaces(), &Message{format, args})...)
+	eventID, _ := client.Capture(packet, tags)
+
+	return eventID
+}
+
+// CaptureMessagef formats and delivers a message to the Sentry server with the default *Client
+func CaptureMessagef(tags map[string]string, format string, args ...interface{}) string {
+	return DefaultClientInstance().CaptureMessagef(tags, format, args...)
+}
+
+// CaptureLazy delivers the packet returned by build, which is only called
+// once the event has passed sampling, so that building expensive messages or
+// extras isn't wasted on events that are sampled out. Only sampling is
+// decided beforehand: the ignore lists, event processors and tenant quotas
+// need the packet, so events they drop are still built. build is called on
+// the calling goroutine. The error returned by build, if any, is the one the
+// packet describes, as passed to event processors. level is used when the
+// packet has none.
+func (client *Client) CaptureLazy(level Severity, build func() (*Packet, error)) string {
+	if client == nil || !client.sample() {
+		return ""
+	}
+
+	packet, err := build()
+	if packet == nil {
+		return ""
+	}
+	if packet.Level == "" {
+		packet.Level = level
+	}
+	if packet.err == nil {
+		packet.err = err
+	}
+	packet.Interfaces = append(packet.Interfaces, client.contextInterfaces()...)
+
+	eventID, _ := client.capture(packet, nil, make(chan error, 1))
+	return eventID
+}
+
+// CaptureLazy delivers the packet returned by build with the default *Client
+func CaptureLazy(level Severity, build func() (*Packet, error)) string {
+	return DefaultClientInstance().CaptureLazy(level, build)
 }
 
 // CaptureMessageAndWait is identical to CaptureMessage except it blocks and waits for the message to be sent.
@@ -677,7 +1233,7 @@ func (client *Client) CaptureMessageAndWait(message string, tags map[string]stri
 		return ""
 	}
 
-	packet := NewPacket(message, append(append(interfaces, client.context.interfaces()...), &Message{message, nil})...)
+	packet := NewPacket(message, append(append(interfaces, client.contextInterfaces()...), &Message{message, nil})...)
 	eventID, ch := client.Capture(packet, tags)
 	if eventID != "" {
 		<-ch
@@ -688,12 +1244,18 @@ func (client *Client) CaptureMessageAndWait(message string, tags map[string]stri
 
 // CaptureMessageAndWait is identical to CaptureMessage except it blocks and waits for the message to be sent.
 func CaptureMessageAndWait(message string, tags map[string]string, interfaces ...Interface) string {
-	return DefaultClient.CaptureMessageAndWait(message, tags, interfaces...)
+	return DefaultClientInstance().CaptureMessageAndWait(message, tags, interfaces...)
 }
 
 // CaptureErrors formats and delivers an error to the Sentry server.
 // Adds a stacktrace to the packet, excluding the call to this method.
 func (client *Client) CaptureError(err error, tags map[string]string, interfaces ...Interface) string {
+	return client.captureError(1, err, tags, interfaces)
+}
+
+// captureError captures err with a stack trace leaving out its caller's skip
+// frames.
+func (client *Client) captureError(skip int, err error, tags map[string]string, interfaces []Interface) string {
 	if client == nil {
 		return ""
 	}
@@ -706,19 +1268,63 @@ func (client *Client) CaptureError(err error, tags map[string]string, interfaces
 		return ""
 	}
 
-	extra := extractExtra(err)
-	cause := pkgErrors.Cause(err)
+	client.mu.RLock()
+	split := client.splitMultiErrors
+	client.mu.RUnlock()
+	if split {
+		if errs := splitErrors(err); len(errs) > 0 {
+			return client.captureSplitErrors(skip+1, errs, tags, interfaces)
+		}
+	}
 
-	packet := NewPacketWithExtra(err.Error(), extra, append(append(interfaces, client.context.interfaces()...), NewException(cause, GetOrNewStacktrace(err, cause, 1, 3, client.includePaths)))...)
+	packet := client.newErrorPacket(skip+1, err, interfaces)
 	eventID, _ := client.Capture(packet, tags)
 
 	return eventID
 }
 
+// newErrorPacket builds the packet reporting err, with a stack trace leaving
+// out its caller's skip frames.
+func (client *Client) newErrorPacket(skip int, err error, interfaces []Interface) *Packet {
+	return client.errorPacket(skip+1, err, err, interfaces)
+}
+
+// newPanicPacket builds the packet reporting the recovered panic value rval,
+// with the stack trace recorded by SnapshotPanic if any, or else one leaving
+// out its caller's skip frames.
+func (client *Client) newPanicPacket(skip int, rval interface{}, interfaces []Interface) *Packet {
+	err, ok := rval.(error)
+	if !ok {
+		err = errors.New(fmt.Sprint(rval))
+	}
+	return client.errorPacket(skip+1, err, rval, interfaces)
+}
+
+// errorPacket builds the packet reporting err, looking up the stack trace
+// recorded for the panic value rval first.
+func (client *Client) errorPacket(skip int, err error, rval interface{}, interfaces []Interface) *Packet {
+	extra := extractExtra(err)
+	cause := pkgErrors.Cause(err)
+	client.mu.RLock()
+	lazy := client.lazyStacktraces
+	includePaths := client.includePaths
+	client.mu.RUnlock()
+	stacktrace := recordedStacktrace(rval, 3, includePaths)
+	if stacktrace == nil && lazy {
+		stacktrace = lazyStacktrace(err, cause, skip+1, 3, includePaths)
+	} else if stacktrace == nil {
+		stacktrace = GetOrNewStacktrace(err, cause, skip+1, 3, includePaths)
+	}
+
+	packet := NewPacketWithExtra(err.Error(), extra, append(append(interfaces, client.contextInterfaces()...), errorException(err, stacktrace, includePaths))...)
+	packet.err = err
+	return packet
+}
+
 // CaptureErrors formats and delivers an error to the Sentry server using the default *Client.
 // Adds a stacktrace to the packet, excluding the call to this method.
 func CaptureError(err error, tags map[string]string, interfaces ...Interface) string {
-	return DefaultClient.CaptureError(err, tags, interfaces...)
+	return DefaultClientInstance().CaptureError(err, tags, interfaces...)
 }
 
 // CaptureErrorAndWait is identical to CaptureError, except it blocks and assures that the event was sent
@@ -731,10 +1337,7 @@ func (client *Client) CaptureErrorAndWait(err error, tags map[string]string, int
 		return ""
 	}
 
-	extra := extractExtra(err)
-	cause := pkgErrors.Cause(err)
-
-	packet := NewPacketWithExtra(err.Error(), extra, append(append(interfaces, client.context.interfaces()...), NewException(cause, GetOrNewStacktrace(err, cause, 1, 3, client.includePaths)))...)
+	packet := client.newErrorPacket(1, err, interfaces)
 	eventID, ch := client.Capture(packet, tags)
 	if eventID != "" {
 		<-ch
@@ -745,7 +1348,7 @@ func (client *Client) CaptureErrorAndWait(err error, tags map[string]string, int
 
 // CaptureErrorAndWait is identical to CaptureError, except it blocks and assures that the event was sent
 func CaptureErrorAndWait(err error, tags map[string]string, interfaces ...Interface) string {
-	return DefaultClient.CaptureErrorAndWait(err, tags, interfaces...)
+	return DefaultClientInstance().CaptureErrorAndWait(err, tags, interfaces...)
 }
 
 // CapturePanic calls f and then recovers and reports a panic to the Sentry server if it occurs.
@@ -756,23 +1359,11 @@ func (client *Client) CapturePanic(f func(), tags map[string]string, interfaces
 	// *Packet just to be thrown away, this should not be the normal case. Could be refactored to
 	// be completely noop though if we cared.
 	defer func() {
-		var packet *Packet
 		err = recover()
-		switch rval := err.(type) {
-		case nil:
+		if err == nil || client.shouldExcludeErr(fmt.Sprint(err)) {
 			return
-		case error:
-			if client.shouldExcludeErr(rval.Error()) {
-				return
-			}
-			packet = NewPacket(rval.Error(), append(append(interfaces, client.context.interfaces()...), NewException(rval, NewStacktrace(2, 3, client.includePaths)))...)
-		default:
-			rvalStr := fmt.Sprint(rval)
-			if client.shouldExcludeErr(rvalStr) {
-				return
-			}
-			packet = NewPacket(rvalStr, append(append(interfaces, client.context.interfaces()...), NewException(errors.New(rvalStr), NewStacktrace(2, 3, client.includePaths)))...)
 		}
+		packet := client.newPanicPacket(2, err, interfaces)
 
 		errorID, _ = client.Capture(packet, tags)
 	}()
@@ -784,7 +1375,7 @@ func (client *Client) CapturePanic(f func(), tags map[string]string, interfaces
 // CapturePanic calls f and then recovers and reports a panic to the Sentry server if it occurs.
 // If an error is captured, both the error and the reported Sentry error ID are returned.
 func CapturePanic(f func(), tags map[string]string, interfaces ...Interface) (interface{}, string) {
-	return DefaultClient.CapturePanic(f, tags, interfaces...)
+	return DefaultClientInstance().CapturePanic(f, tags, interfaces...)
 }
 
 // CapturePanicAndWait is identical to CaptureError, except it blocks and assures that the event was sent
@@ -794,23 +1385,11 @@ func (client *Client) CapturePanicAndWait(f func(), tags map[string]string, inte
 	// *Packet just to be thrown away, this should not be the normal case. Could be refactored to
 	// be completely noop though if we cared.
 	defer func() {
-		var packet *Packet
 		err = recover()
-		switch rval := err.(type) {
-		case nil:
+		if err == nil || client.shouldExcludeErr(fmt.Sprint(err)) {
 			return
-		case error:
-			if client.shouldExcludeErr(rval.Error()) {
-				return
-			}
-			packet = NewPacket(rval.Error(), append(append(interfaces, client.context.interfaces()...), NewException(rval, NewStacktrace(2, 3, client.includePaths)))...)
-		default:
-			rvalStr := fmt.Sprint(rval)
-			if client.shouldExcludeErr(rvalStr) {
-				return
-			}
-			packet = NewPacket(rvalStr, append(append(interfaces, client.context.interfaces()...), NewException(errors.New(rvalStr), NewStacktrace(2, 3, client.includePaths)))...)
 		}
+		packet := client.newPanicPacket(2, err, interfaces)
 
 		var ch chan error
 		errorID, ch = client.Capture(packet, tags)
@@ -825,14 +1404,31 @@ func (client *Client) CapturePanicAndWait(f func(), tags map[string]string, inte
 
 // CapturePanicAndWait is identical to CaptureError, except it blocks and assures that the event was sent
 func CapturePanicAndWait(f func(), tags map[string]string, interfaces ...Interface) (interface{}, string) {
-	return DefaultClient.CapturePanicAndWait(f, tags, interfaces...)
+	return DefaultClientInstance().CapturePanicAndWait(f, tags, interfaces...)
 }
 
+// Close stops the background worker. Packets still queued are delivered first,
+// unless a queue file is set, in which case they are saved to it instead.
+// Events captured afterwards are dropped with ErrClientClosed.
 func (client *Client) Close() {
+	client.mu.Lock()
+	if client.closed {
+		client.mu.Unlock()
+		return
+	}
+	client.closed = true
+	queueFile := client.queueFile
+	client.mu.Unlock()
+
+	if queueFile != "" {
+		if err := client.persistQueue(queueFile); err != nil {
+			debugf("raven: can't save the queue to %s: %v", queueFile, err)
+		}
+	}
 	close(client.queue)
 }
 
-func Close() { DefaultClient.Close() }
+func Close() { DefaultClientInstance().Close() }
 
 // Wait blocks and waits for all events to finish being sent to Sentry server
 func (client *Client) Wait() {
@@ -840,7 +1436,21 @@ func (client *Client) Wait() {
 }
 
 // Wait blocks and waits for all events to finish being sent to Sentry server
-func Wait() { DefaultClient.Wait() }
+func Wait() { DefaultClientInstance().Wait() }
+
+// Flush waits for all events to finish being sent to Sentry server, giving up
+// after timeout. It reports whether every event was sent in time.
+func (client *Client) Flush(timeout time.Duration) bool {
+	select {
+	case <-client.wg.Idle():
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Flush waits up to timeout for the default *Client to send all events
+func Flush(timeout time.Duration) bool { return DefaultClientInstance().Flush(timeout) }
 
 func (client *Client) URL() string {
 	client.mu.RLock()
@@ -849,7 +1459,7 @@ func (client *Client) URL() string {
 	return client.url
 }
 
-func URL() string { return DefaultClient.URL() }
+func URL() string { return DefaultClientInstance().URL() }
 
 func (client *Client) ProjectID() string {
 	client.mu.RLock()
@@ -858,7 +1468,7 @@ func (client *Client) ProjectID() string {
 	return client.projectID
 }
 
-func ProjectID() string { return DefaultClient.ProjectID() }
+func ProjectID() string { return DefaultClientInstance().ProjectID() }
 
 func (client *Client) Release() string {
 	client.mu.RLock()
@@ -867,9 +1477,9 @@ func (client *Client) Release() string {
 	return client.release
 }
 
-func Release() string { return DefaultClient.Release() }
+func Release() string { return DefaultClientInstance().Release() }
 
-func IncludePaths() []string { return DefaultClient.IncludePaths() }
+func IncludePaths() []string { return DefaultClientInstance().IncludePaths() }
 
 func (client *Client) IncludePaths() []string {
 	client.mu.RLock()
@@ -878,7 +1488,7 @@ func (client *Client) IncludePaths() []string {
 	return client.includePaths
 }
 
-func SetIncludePaths(p []string) { DefaultClient.SetIncludePaths(p) }
+func SetIncludePaths(p []string) { DefaultClientInstance().SetIncludePaths(p) }
 
 func (client *Client) SetIncludePaths(p []string) {
 	client.mu.Lock()
@@ -905,73 +1515,28 @@ func (c *Client) SetTagsContext(t map[string]string) {
 	c.context.setTags(t)
 }
 
-func (c *Client) ClearContext() {
+// SetContext sets a named context sent with every event, e.g. "tenant" or
+// "feature_flags". A nil value removes it.
+func (c *Client) SetContext(name string, value interface{}) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.context.clear()
-}
-
-func SetUserContext(u *User)             { DefaultClient.SetUserContext(u) }
-func SetHttpContext(h *Http)             { DefaultClient.SetHttpContext(h) }
-func SetTagsContext(t map[string]string) { DefaultClient.SetTagsContext(t) }
-func ClearContext()                      { DefaultClient.ClearContext() }
-
-// HTTPTransport is the default transport, delivering packets to Sentry via the
-// HTTP API.
-type HTTPTransport struct {
-	*http.Client
+	c.context.setContext(name, value)
 }
 
-func (t *HTTPTransport) Send(url, authHeader string, packet *Packet) error {
-	if url == "" {
-		return nil
-	}
-
-	body, contentType, err := serializedPacket(packet)
-	if err != nil {
-		return fmt.Errorf("error serializing packet: %v", err)
-	}
-	req, err := http.NewRequest("POST", url, body)
-	if err != nil {
-		return fmt.Errorf("can't create new request: %v", err)
-	}
-	req.Header.Set("X-Sentry-Auth", authHeader)
-	req.Header.Set("User-Agent", userAgent)
-	req.Header.Set("Content-Type", contentType)
-	res, err := t.Do(req)
-	if err != nil {
-		return err
-	}
-	io.Copy(ioutil.Discard, res.Body)
-	res.Body.Close()
-	if res.StatusCode != 200 {
-		return fmt.Errorf("raven: got http status %d - x-sentry-error: %s", res.StatusCode, res.Header.Get("X-Sentry-Error"))
-	}
-	return nil
+func (c *Client) ClearContext() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.context.clear()
 }
 
-func serializedPacket(packet *Packet) (io.Reader, string, error) {
-	packetJSON, err := packet.JSON()
-	if err != nil {
-		return nil, "", fmt.Errorf("error marshaling packet %+v to JSON: %v", packet, err)
-	}
-
-	// Only deflate/base64 the packet if it is bigger than 1KB, as there is
-	// overhead.
-	if len(packetJSON) > 1000 {
-		buf := &bytes.Buffer{}
-		b64 := base64.NewEncoder(base64.StdEncoding, buf)
-		deflate, _ := zlib.NewWriterLevel(b64, zlib.BestCompression)
-		deflate.Write(packetJSON)
-		deflate.Close()
-		b64.Close()
-		return buf, "application/octet-stream", nil
-	}
-	return bytes.NewReader(packetJSON), "application/json", nil
-}
+func SetUserContext(u *User)                    { DefaultClientInstance().SetUserContext(u) }
+func SetHttpContext(h *Http)                    { DefaultClientInstance().SetHttpContext(h) }
+func SetTagsContext(t map[string]string)        { DefaultClientInstance().SetTagsContext(t) }
+func SetContext(name string, value interface{}) { DefaultClientInstance().SetContext(name, value) }
+func ClearContext()                             { DefaultClientInstance().ClearContext() }
 
 var hostname string
 
 func init() {
-	hostname, _ = os.Hostname()
+	hostname = detectHostname()
 }
@@ -2,7 +2,10 @@ package raven
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"reflect"
+	"sync"
 	"testing"
 	"time"
 )
@@ -12,33 +15,6 @@ type testInterface struct{}
 func (t *testInterface) Class() string   { return "sentry.interfaces.Test" }
 func (t *testInterface) Culprit() string { return "codez" }
 
-func TestShouldExcludeErr(t *testing.T) {
-	regexpStrs := []string{"ERR_TIMEOUT", "should.exclude", "(?i)^big$"}
-
-	client := &Client{
-		Transport: newTransport(),
-		Tags:      nil,
-		context:   &context{},
-		queue:     make(chan *outgoingPacket, MaxQueueBuffer),
-	}
-
-	if err := client.SetIgnoreErrors(regexpStrs); err != nil {
-		t.Fatalf("invalid regexps %v: %v", regexpStrs, err)
-	}
-
-	testCases := []string{
-		"there was a ERR_TIMEOUT in handlers.go",
-		"do not log should.exclude at all",
-		"BIG",
-	}
-
-	for _, tc := range testCases {
-		if !client.shouldExcludeErr(tc) {
-			t.Fatalf("failed to exclude err %q with regexps %v", tc, regexpStrs)
-		}
-	}
-}
-
 func TestPacketJSON(t *testing.T) {
 	packet := &Packet{
 		Project:     "1",
@@ -114,6 +90,9 @@ func TestPacketInit(t *testing.T) {
 	if packet.Culprit != "codez" {
 		t.Error("incorrect Culprit:", packet.Culprit)
 	}
+	if packet.Transaction != "codez" {
+		t.Error("incorrect Transaction:", packet.Transaction)
+	}
 	if packet.ServerName == "" {
 		t.Errorf("ServerName should not be empty")
 	}
@@ -131,6 +110,40 @@ func TestPacketInit(t *testing.T) {
 	}
 }
 
+func TestPacketInitDerivedCulprit(t *testing.T) {
+	stacktrace := func(frames ...*StacktraceFrame) *Stacktrace { return &Stacktrace{Frames: frames} }
+	app := &StacktraceFrame{Module: "example.com/app", Function: "handle", InApp: true}
+	lib := &StacktraceFrame{Module: "example.com/lib", Function: "Do"}
+
+	tests := []struct {
+		packet      *Packet
+		culprit     string
+		transaction string
+	}{
+		{&Packet{Interfaces: []Interface{&Exceptions{Values: []*Exception{
+			{Stacktrace: stacktrace(app, lib)},
+			{Stacktrace: stacktrace(lib)},
+		}}}}, "example.com/app.handle", "example.com/app.handle"},
+		{&Packet{Interfaces: []Interface{&Threads{Values: []*Thread{
+			{ID: "1", Stacktrace: stacktrace(lib)},
+			{ID: "2", Current: true, Stacktrace: stacktrace(app)},
+		}}}}, "example.com/app.handle", "example.com/app.handle"},
+		{&Packet{Transaction: "GET /users", Interfaces: []Interface{NewException(errors.New("e"), stacktrace(app))}}, "example.com/app.handle", "GET /users"},
+		{&Packet{Culprit: "explicit", Interfaces: []Interface{NewException(errors.New("e"), stacktrace(app))}}, "explicit", "explicit"},
+		{&Packet{Interfaces: []Interface{NewException(errors.New("e"), stacktrace(lib))}}, "", ""},
+	}
+
+	for i, test := range tests {
+		test.packet.Init("foo")
+		if test.packet.Culprit != test.culprit {
+			t.Errorf("%d: incorrect Culprit: got %s, want %s", i, test.packet.Culprit, test.culprit)
+		}
+		if test.packet.Transaction != test.transaction {
+			t.Errorf("%d: incorrect Transaction: got %s, want %s", i, test.packet.Transaction, test.transaction)
+		}
+	}
+}
+
 func TestSetDSN(t *testing.T) {
 	client := &Client{}
 	client.SetDSN("https://u:p@example.com/sentry/1")
@@ -141,11 +154,62 @@ func TestSetDSN(t *testing.T) {
 	if client.projectID != "1" {
 		t.Error("incorrect projectID:", client.projectID)
 	}
-	if client.authHeader != "Sentry sentry_version=4, sentry_key=u, sentry_secret=p" {
+	if client.authHeader != "Sentry sentry_version=7, sentry_key=u" {
 		t.Error("incorrect authHeader:", client.authHeader)
 	}
 }
 
+type urlTransport struct {
+	blockingTransport
+	urls []string
+}
+
+func (t *urlTransport) Send(url, authHeader string, packet *Packet) error {
+	t.blockingTransport.Send(url, authHeader, packet)
+	t.urls = append(t.urls, url+" "+authHeader+" "+packet.Project)
+	return nil
+}
+
+func TestSetDSNInFlight(t *testing.T) {
+	client := newClient(nil)
+	transport := &urlTransport{blockingTransport: blockingTransport{started: make(chan struct{}, 2), release: make(chan struct{})}}
+	client.Transport = transport
+
+	client.SetDSN("https://old@example.com/1")
+	client.CaptureMessage("first", nil)
+	client.CaptureMessage("queued", nil)
+	<-transport.started
+	client.SetDSN("https://new@example.com/2")
+	client.CaptureMessage("after", nil)
+	close(transport.release)
+	client.Wait()
+
+	expected := []string{
+		"https://example.com/api/1/store/ Sentry sentry_version=7, sentry_client=" + client.sdk.UserAgent() + ", sentry_key=old 1",
+		"https://example.com/api/1/store/ Sentry sentry_version=7, sentry_client=" + client.sdk.UserAgent() + ", sentry_key=old 1",
+		"https://example.com/api/2/store/ Sentry sentry_version=7, sentry_client=" + client.sdk.UserAgent() + ", sentry_key=new 2",
+	}
+	if !reflect.DeepEqual(transport.urls, expected) {
+		t.Errorf("incorrect destinations:\ngot  %q\nwant %q", transport.urls, expected)
+	}
+}
+
+func TestAuthHeader(t *testing.T) {
+	client := newClient(nil)
+	client.SetDSN("https://u:p@example.com/sentry/1")
+	client.SetSDK("raven-go", "1.2.0")
+
+	if expected := "Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=u"; client.authHeader != expected {
+		t.Errorf("incorrect authHeader: got %s, want %s", client.authHeader, expected)
+	}
+
+	client.SetProtocolVersion(4)
+	client.SetSendSecretKey(true)
+	if expected := "Sentry sentry_version=4, sentry_client=raven-go/1.2.0, sentry_key=u, sentry_secret=p"; client.authHeader != expected {
+		t.Errorf("incorrect authHeader: got %s, want %s", client.authHeader, expected)
+	}
+}
+
 func TestNewClient(t *testing.T) {
 	client := newClient(nil)
 	if client.sampleRate != 1.0 {
@@ -200,6 +264,10 @@ func TestUnmarshalTags(t *testing.T) {
 			`[["foo","bar"],["bar","baz"]]`,
 			Tags{Tag{Key: "foo", Value: "bar"}, Tag{Key: "bar", Value: "baz"}},
 		},
+		{
+			`null`,
+			nil,
+		},
 	}
 
 	for _, test := range tests {
@@ -214,6 +282,63 @@ func TestUnmarshalTags(t *testing.T) {
 	}
 }
 
+func TestMarshalTags(t *testing.T) {
+	tests := []Tags{
+		nil,
+		{},
+		{{"foo", "bar"}, {"bar", "baz"}},
+		{{"quote\"back\\slash", "new\nline\ttab\rreturn"}},
+		{{"html", "<a href=\"x\">&</a>"}, {"control", "\x00\x1f\x7f"}},
+		{{"unicode", "h\u00e9llo \u2028\u2029 \U0001F600"}, {"invalid", "a\xffb"}},
+	}
+
+	for _, tags := range tests {
+		actual, err := json.Marshal(tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pairs := [][2]string{}
+		for _, tag := range tags {
+			pairs = append(pairs, [2]string{tag.Key, tag.Value})
+		}
+		expected, _ := json.Marshal(pairs)
+		if tags == nil {
+			expected = []byte("null")
+		}
+		if string(actual) != string(expected) {
+			t.Errorf("incorrect JSON: got %s, want %s", actual, expected)
+		}
+	}
+}
+
+func largeTags() Tags {
+	tags := make(Tags, 64)
+	for i := range tags {
+		tags[i] = Tag{fmt.Sprintf("tag.key.%d", i), fmt.Sprintf("some tag value %d", i)}
+	}
+	return tags
+}
+
+func BenchmarkMarshalTags(b *testing.B) {
+	tags := largeTags()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(tags)
+	}
+}
+
+func BenchmarkMarshalTagsPerTag(b *testing.B) {
+	tags := largeTags()
+	pairs := make([]*Tag, len(tags))
+	for i := range tags {
+		pairs[i] = &tags[i]
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		json.Marshal(pairs)
+	}
+}
+
 func TestMarshalTimestamp(t *testing.T) {
 	timestamp := Timestamp(time.Date(2000, 01, 02, 03, 04, 05, 0, time.UTC))
 	expected := `"2000-01-02T03:04:05.00"`
@@ -256,6 +381,33 @@ func TestNilClient(t *testing.T) {
 	}
 }
 
+func TestCaptureDropOutcome(t *testing.T) {
+	client, _ := newTestClient()
+	client.SetIgnoreErrors([]string{"ignored"})
+	client.AddEventProcessor(func(packet *Packet, err error) bool { return packet.Message != "processed" })
+
+	tests := []struct {
+		message  string
+		expected error
+	}{
+		{"ignored", ErrFiltered},
+		{"processed", ErrFiltered},
+		{"sent", nil},
+	}
+	for _, test := range tests {
+		_, ch := client.Capture(NewPacket(test.message), nil)
+		if err := <-ch; err != test.expected {
+			t.Errorf("incorrect outcome for %q: got %v, want %v", test.message, err, test.expected)
+		}
+	}
+
+	client.SetSampleRate(0)
+	_, ch := client.Capture(NewPacket("sampled"), nil)
+	if err := <-ch; err != ErrSampledOut {
+		t.Errorf("incorrect outcome: got %v, want %v", err, ErrSampledOut)
+	}
+}
+
 func TestCaptureNil(t *testing.T) {
 	var client *Client = DefaultClient
 	eventID, ch := client.Capture(nil, nil)
@@ -277,43 +429,179 @@ func TestCaptureNilError(t *testing.T) {
 	}
 }
 
-func TestNewPacketWithExtraSetsDefault(t *testing.T) {
-	testCases := []struct {
-		Extra    Extra
-		Expected Extra
-	}{
-		// Defaults should be set when nil is passed
-		{
-			Extra:    nil,
-			Expected: setExtraDefaults(Extra{}),
-		},
-		// Defaults should be set when empty is passed
-		{
-			Extra:    Extra{},
-			Expected: setExtraDefaults(Extra{}),
-		},
-		// Packet should always override default keys
-		{
-			Extra: Extra{
-				"runtime.Version": "notagoversion",
-			},
-			Expected: setExtraDefaults(Extra{}),
-		},
-		// Packet should include our extra info
-		{
-			Extra: Extra{
-				"extra.extra": "extra",
-			},
-			Expected: setExtraDefaults(Extra{
-				"extra.extra": "extra",
-			}),
-		},
+func TestNewPacketWithExtraCopiesExtra(t *testing.T) {
+	testCases := []Extra{
+		nil,
+		{},
+		{"extra.extra": "extra"},
+	}
+
+	for i, extra := range testCases {
+		packet := NewPacketWithExtra("packet", extra)
+		expected := Extra{}
+		for k, v := range extra {
+			expected[k] = v
+		}
+		if !reflect.DeepEqual(packet.Extra, expected) {
+			t.Errorf("Case [%d]: Expected packet: %+v, got: %+v", i, expected, packet.Extra)
+		}
+
+		packet.Extra["added"] = true
+		if _, ok := extra["added"]; ok {
+			t.Errorf("Case [%d]: caller's extra was modified", i)
+		}
+	}
+}
+
+func TestPacketJSONContexts(t *testing.T) {
+	packet := &Packet{
+		Project:   "1",
+		EventID:   "2",
+		Message:   "test",
+		Timestamp: Timestamp(time.Date(2000, 01, 01, 0, 0, 0, 0, time.UTC)),
+		Level:     ERROR,
+		Logger:    "root",
+		Contexts:  map[string]interface{}{"tenant": map[string]string{"id": "42"}},
+	}
+
+	expected := `{"message":"test","event_id":"2","project":"1","timestamp":"2000-01-01T00:00:00.00","level":"error","logger":"root","contexts":{"tenant":{"id":"42"}}}`
+	j, err := packet.JSON()
+	if err != nil {
+		t.Fatalf("JSON marshalling should not fail: %v", err)
 	}
+	if string(j) != expected {
+		t.Errorf("incorrect json; got %s, want %s", j, expected)
+	}
+}
 
-	for i, test := range testCases {
-		packet := NewPacketWithExtra("packet", test.Extra)
-		if !reflect.DeepEqual(packet.Extra, test.Expected) {
-			t.Errorf("Case [%d]: Expected packet: %+v, got: %+v", i, test.Expected, packet.Extra)
+func TestSetContext(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetHostContext(false)
+	client.SetRuntimeContext(false)
+	client.SetContext("tenant", map[string]string{"id": "42"})
+	client.SetContext("gpu", map[string]string{"name": "none"})
+	client.SetContext("gpu", nil)
+
+	packet := NewPacket("test")
+	packet.Contexts = map[string]interface{}{"tenant": "explicit"}
+	client.Capture(packet, nil)
+	client.Capture(NewPacket("test"), nil)
+	client.Wait()
+
+	expected := []map[string]interface{}{
+		{"tenant": "explicit"},
+		{"tenant": map[string]string{"id": "42"}},
+	}
+	for i, packet := range transport.packets {
+		if !reflect.DeepEqual(packet.Contexts, expected[i]) {
+			t.Errorf("%d: incorrect Contexts: got %+v, want %+v", i, packet.Contexts, expected[i])
 		}
 	}
+
+	client.ClearContext()
+	if client.context.contexts != nil {
+		t.Error("expected contexts to be cleared")
+	}
+}
+
+func TestFlush(t *testing.T) {
+	blocking := &blockingTransport{make(chan struct{}, 1), make(chan struct{})}
+	client := newClient(nil)
+	client.Transport = blocking
+
+	client.CaptureMessage("slow", nil)
+	if client.Flush(10 * time.Millisecond) {
+		t.Error("expected Flush to time out")
+	}
+	close(blocking.release)
+	if !client.Flush(time.Second) {
+		t.Error("expected Flush to succeed")
+	}
+}
+
+func TestCaptureMessagef(t *testing.T) {
+	client, transport := newTestClient()
+	client.CaptureMessagef(nil, "job %s failed after %d attempts", "sync", 3)
+	client.Wait()
+
+	packet := transport.packets[0]
+	if packet.Message != "job sync failed after 3 attempts" {
+		t.Errorf("incorrect Message: got %s", packet.Message)
+	}
+	expected := &Message{"job %s failed after %d attempts", []interface{}{"sync", 3}}
+	if !reflect.DeepEqual(packet.Interfaces[len(packet.Interfaces)-1], expected) {
+		t.Errorf("incorrect Message interface: got %+v, want %+v", packet.Interfaces, expected)
+	}
+}
+
+func TestCaptureLazy(t *testing.T) {
+	client, transport := newTestClient()
+	built := 0
+	build := func() (*Packet, error) {
+		built++
+		return NewPacket("expensive"), errors.New("failed")
+	}
+
+	client.SetSampleRate(0)
+	if eventID := client.CaptureLazy(WARNING, build); eventID != "" || built != 0 {
+		t.Errorf("expected the packet not to be built, got %d builds", built)
+	}
+
+	var processed error
+	client.AddEventProcessor(func(packet *Packet, err error) bool {
+		processed = err
+		return true
+	})
+	client.SetSampleRate(1)
+	if eventID := client.CaptureLazy(WARNING, build); eventID == "" || built != 1 {
+		t.Errorf("expected the packet to be built once, got %d builds", built)
+	}
+	client.Wait()
+
+	if packet := transport.packets[0]; packet.Level != WARNING || packet.Message != "expensive" {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+	if processed == nil || processed.Error() != "failed" {
+		t.Errorf("incorrect error passed to processors: %v", processed)
+	}
+}
+
+func TestSetDefaultClient(t *testing.T) {
+	defer SetDefaultClient(nil)
+
+	client, transport := newTestClient()
+	SetDefaultClient(client)
+	if got := DefaultClientInstance(); got != client {
+		t.Errorf("incorrect default client: got %p, want %p", got, client)
+	}
+
+	CaptureMessage("installed", nil)
+	Wait()
+	if len(transport.packets) != 1 {
+		t.Errorf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+
+	SetDefaultClient(nil)
+	if got := DefaultClientInstance(); got != DefaultClient {
+		t.Errorf("incorrect default client: got %p, want %p", got, DefaultClient)
+	}
+}
+
+func TestSetDefaultClientConcurrently(t *testing.T) {
+	defer SetDefaultClient(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			client, _ := newTestClient()
+			SetDefaultClient(client)
+		}()
+		go func() {
+			defer wg.Done()
+			SetTagsContext(map[string]string{"k": "v"})
+		}()
+	}
+	wg.Wait()
 }
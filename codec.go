@@ -0,0 +1,174 @@
+package raven
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// A PacketCodec serializes the packets a client stores locally, such as those
+// saved to its queue file, so that a more compact format like msgpack can be
+// used instead of JSON. Packets are still sent to Sentry as JSON.
+type PacketCodec interface {
+	Marshal(packet *Packet) ([]byte, error)
+	Unmarshal(data []byte, packet *Packet) error
+}
+
+type canonicalCodec struct{}
+
+func (canonicalCodec) Marshal(packet *Packet) ([]byte, error) { return packet.MarshalCanonical() }
+
+func (canonicalCodec) Unmarshal(data []byte, packet *Packet) error {
+	return packet.UnmarshalCanonical(data)
+}
+
+// CanonicalCodec serializes packets with MarshalCanonical. It is the default
+// codec, storing one JSON document per line.
+var CanonicalCodec PacketCodec = canonicalCodec{}
+
+var errTruncatedRecord = errors.New("raven: truncated record")
+
+// SetQueueCodec sets the codec used to save packets to the queue file. Packets
+// are read back with the codec set when SetQueueFile is called, so it must be
+// called first and stay the same across restarts. A nil codec restores
+// CanonicalCodec.
+func (client *Client) SetQueueCodec(codec PacketCodec) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.queueCodec = codec
+}
+
+// SetQueueCodec sets the queue file codec of the default *Client
+func SetQueueCodec(codec PacketCodec) { DefaultClientInstance().SetQueueCodec(codec) }
+
+// A recordTarget is where and how a saved packet was to be sent, stored along
+// with it so that it is delivered there after a restart even if the DSN
+// changed. The auth header is rebuilt from the public key when the packet is
+// read back, as it may hold the secret key of the DSN. The HTTP headers of the
+// packet aren't part of its JSON.
+type recordTarget struct {
+	URL       string      `json:"url,omitempty"`
+	PublicKey string      `json:"public_key,omitempty"`
+	Headers   http.Header `json:"headers,omitempty"`
+}
+
+// A canonicalRecord is a line of a file written with CanonicalCodec.
+type canonicalRecord struct {
+	recordTarget
+	Event json.RawMessage `json:"event"`
+}
+
+// encodeRecords serializes the packets and targets of pending with codec,
+// skipping the packets that can't be. It returns the error each packet was
+// serialized with, nil for those that were. Records are separated by newlines
+// with CanonicalCodec. Otherwise they are made of the target and the packet,
+// each prefixed by its length.
+func encodeRecords(codec PacketCodec, pending []*outgoingPacket) ([]byte, []error) {
+	var buf []byte
+	errs := make([]error, len(pending))
+	for i, p := range pending {
+		var record []byte
+		if record, errs[i] = encodeRecord(codec, p); errs[i] == nil {
+			buf = append(buf, record...)
+		}
+	}
+	return buf, errs
+}
+
+func encodeRecord(codec PacketCodec, p *outgoingPacket) ([]byte, error) {
+	data, err := codec.Marshal(p.packet)
+	if err != nil {
+		return nil, err
+	}
+	target := recordTarget{p.url, p.publicKey, p.packet.Headers}
+	if codec == CanonicalCodec {
+		line, err := json.Marshal(canonicalRecord{target, data})
+		if err != nil {
+			return nil, err
+		}
+		return append(line, '\n'), nil
+	}
+	meta, err := json.Marshal(target)
+	if err != nil {
+		return nil, err
+	}
+	return appendRecord(appendRecord(nil, meta), data), nil
+}
+
+func appendRecord(buf, data []byte) []byte {
+	var n [binary.MaxVarintLen64]byte
+	buf = append(buf, n[:binary.PutUvarint(n[:], uint64(len(data)))]...)
+	return append(buf, data...)
+}
+
+// nextRecord splits buf into its first length-prefixed record and the rest.
+func nextRecord(buf []byte) ([]byte, []byte, error) {
+	size, n := binary.Uvarint(buf)
+	if n <= 0 || uint64(len(buf)-n) < size {
+		return nil, nil, errTruncatedRecord
+	}
+	return buf[n : n+int(size)], buf[n+int(size):], nil
+}
+
+// decodeRecords reads back packets serialized by encodeRecords, along with
+// their targets, leaving their auth header to be rebuilt. Records that can't
+// be decoded are skipped, and the first error met is returned along with the
+// others. Nothing past a truncated record is read.
+func decodeRecords(codec PacketCodec, buf []byte) ([]*outgoingPacket, error) {
+	var pending []*outgoingPacket
+	var firstErr error
+	skip := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	decode := func(target recordTarget, data []byte) {
+		p := &outgoingPacket{packet: &Packet{}, url: target.URL, publicKey: target.PublicKey}
+		if err := codec.Unmarshal(data, p.packet); err != nil {
+			skip(err)
+			return
+		}
+		p.packet.Headers = target.Headers
+		pending = append(pending, p)
+	}
+
+	if codec == CanonicalCodec {
+		scanner := bufio.NewScanner(bytes.NewReader(buf))
+		scanner.Buffer(nil, 10*1024*1024)
+		for scanner.Scan() {
+			var record canonicalRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				skip(err)
+				continue
+			}
+			decode(record.recordTarget, record.Event)
+		}
+		if err := scanner.Err(); err != nil {
+			skip(err)
+		}
+		return pending, firstErr
+	}
+
+	for len(buf) > 0 {
+		var target recordTarget
+		var meta, data []byte
+		var err error
+		if meta, buf, err = nextRecord(buf); err != nil {
+			skip(err)
+			break
+		}
+		if data, buf, err = nextRecord(buf); err != nil {
+			skip(err)
+			break
+		}
+		if err := json.Unmarshal(meta, &target); err != nil {
+			skip(err)
+			continue
+		}
+		decode(target, data)
+	}
+	return pending, firstErr
+}
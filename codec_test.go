@@ -0,0 +1,102 @@
+package raven
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+// gzipCodec compresses canonical packets, standing in for a binary format.
+type gzipCodec struct{}
+
+func (gzipCodec) Marshal(packet *Packet) ([]byte, error) {
+	data, err := packet.MarshalCanonical()
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Write(data)
+	w.Close()
+	return buf.Bytes(), nil
+}
+
+func (gzipCodec) Unmarshal(data []byte, packet *Packet) error {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	data, err = ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	return packet.UnmarshalCanonical(data)
+}
+
+func TestPacketCodecs(t *testing.T) {
+	for _, codec := range []PacketCodec{CanonicalCodec, gzipCodec{}} {
+		pending := []*outgoingPacket{
+			{packet: NewPacket("first", &Message{"%s failed", []interface{}{"job"}}), url: "https://example.com/api/1/store/", authHeader: "Sentry sentry_key=u, sentry_secret=s", publicKey: "u"},
+			{packet: NewPacket("second")},
+		}
+		for _, p := range pending {
+			p.packet.Init("1")
+		}
+		pending[0].packet.SetHeader("X-Tenant", "acme")
+
+		buf, _ := encodeRecords(codec, pending)
+		if bytes.Contains(buf, []byte("sentry_secret")) {
+			t.Errorf("%T: auth header saved: %q", codec, buf)
+		}
+		decoded, err := decodeRecords(codec, buf)
+		if err != nil {
+			t.Fatalf("%T: %v", codec, err)
+		}
+		if len(decoded) != len(pending) {
+			t.Fatalf("%T: incorrect packet count: got %d, want %d", codec, len(decoded), len(pending))
+		}
+		for i, p := range decoded {
+			if p.packet.EventID != pending[i].packet.EventID || p.packet.Message != pending[i].packet.Message {
+				t.Errorf("%T: incorrect packet %d: got %+v", codec, i, p.packet)
+			}
+			if p.url != pending[i].url || p.publicKey != pending[i].publicKey || p.authHeader != "" {
+				t.Errorf("%T: incorrect target %d: got (%s, %s, %s), want (%s, %s)", codec, i, p.url, p.publicKey, p.authHeader, pending[i].url, pending[i].publicKey)
+			}
+			if !reflect.DeepEqual(p.packet.Headers, pending[i].packet.Headers) {
+				t.Errorf("%T: incorrect headers %d: got %v, want %v", codec, i, p.packet.Headers, pending[i].packet.Headers)
+			}
+		}
+	}
+}
+
+func TestDecodeTruncatedPackets(t *testing.T) {
+	buf, _ := encodeRecords(gzipCodec{}, []*outgoingPacket{{packet: NewPacket("message")}})
+	if _, err := decodeRecords(gzipCodec{}, buf[:len(buf)-1]); err != errTruncatedRecord {
+		t.Errorf("incorrect error: got %v, want %v", err, errTruncatedRecord)
+	}
+}
+
+func TestDecodeSkipsBadRecords(t *testing.T) {
+	for _, codec := range []PacketCodec{CanonicalCodec, gzipCodec{}} {
+		first, second := NewPacket("first"), NewPacket("second")
+		first.Init("1")
+		second.Init("1")
+		good, _ := encodeRecords(codec, []*outgoingPacket{{packet: first}})
+		more, _ := encodeRecords(codec, []*outgoingPacket{{packet: second}})
+		bad := []byte("garbage\n")
+		if codec != CanonicalCodec {
+			bad = appendRecord(appendRecord(nil, []byte("{}")), []byte("garbage"))
+		}
+
+		buf := append(append(good, bad...), more...)
+		decoded, err := decodeRecords(codec, buf)
+		if err == nil {
+			t.Errorf("%T: expected an error for the bad record", codec)
+		}
+		if len(decoded) != 2 || decoded[0].packet.EventID != first.EventID || decoded[1].packet.EventID != second.EventID {
+			t.Errorf("%T: incorrect records: got %+v", codec, decoded)
+		}
+	}
+}
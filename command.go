@@ -0,0 +1,102 @@
+package raven
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"runtime/debug"
+	"strings"
+)
+
+// A Command is a CLI command, such as a *cobra.Command.
+type Command interface {
+	Execute() error
+}
+
+// The maximum length of a tag value accepted by Sentry.
+const maxTagValueLength = 200
+
+// ExecuteCommand executes cmd and returns the exit code the process should
+// exit with: 0 on success, 1 when the command fails and 2 when it panics.
+// Failures are reported with the command path and the command line, with the
+// values of flags that look like credentials masked, and delivered before
+// ExecuteCommand returns, within ShutdownTimeout.
+//
+// Example:
+//
+//	func main() {
+//		os.Exit(raven.ExecuteCommand(rootCmd))
+//	}
+func (client *Client) ExecuteCommand(cmd Command) (code int) {
+	path := commandName(cmd)
+	args := strings.Join(sanitizeArgs(os.Args[1:]), " ")
+	if len(args) > maxTagValueLength {
+		args = args[:maxTagValueLength]
+	}
+	tags := map[string]string{"cli.args": args}
+
+	defer func() {
+		if rval := recover(); rval != nil {
+			fmt.Fprintf(os.Stderr, "panic: %v\n\n%s", rval, debug.Stack())
+			packet := client.newPanicPacket(2, rval, nil)
+			packet.Level = FATAL
+			tags["cli.command"] = path
+			client.Capture(packet, tags)
+			client.Flush(ShutdownTimeout)
+			code = 2
+		}
+	}()
+
+	var err error
+	path, err = executeCommand(cmd, path)
+	if err == nil {
+		return 0
+	}
+
+	tags["cli.command"] = path
+	client.CaptureError(err, tags)
+	client.Flush(ShutdownTimeout)
+	return 1
+}
+
+// ExecuteCommand executes cmd, reporting failures to the default *Client
+func ExecuteCommand(cmd Command) int { return DefaultClientInstance().ExecuteCommand(cmd) }
+
+// commandName returns the name of cmd, e.g. the CommandPath of a
+// *cobra.Command, or its type otherwise.
+func commandName(cmd Command) string {
+	if path, ok := callString(reflect.ValueOf(cmd), "CommandPath"); ok {
+		return path
+	}
+	return reflect.TypeOf(cmd).String()
+}
+
+// executeCommand runs cmd, using cobra's ExecuteC when available to learn
+// which subcommand ran.
+func executeCommand(cmd Command, path string) (string, error) {
+	method := reflect.ValueOf(cmd).MethodByName("ExecuteC")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 2 ||
+		method.Type().Out(1) != reflect.TypeOf((*error)(nil)).Elem() {
+		return path, cmd.Execute()
+	}
+
+	out := method.Call(nil)
+	if subPath, ok := callString(out[0], "CommandPath"); ok {
+		path = subPath
+	}
+	err, _ := out[1].Interface().(error)
+	return path, err
+}
+
+// callString calls the method name of v, if it takes no argument and returns
+// a string.
+func callString(v reflect.Value, name string) (string, bool) {
+	if !v.IsValid() || (v.Kind() == reflect.Ptr && v.IsNil()) {
+		return "", false
+	}
+	method := v.MethodByName(name)
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 || method.Type().Out(0).Kind() != reflect.String {
+		return "", false
+	}
+	return method.Call(nil)[0].String(), true
+}
@@ -0,0 +1,69 @@
+package raven
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// Mimics *cobra.Command.
+type testCommand struct {
+	path string
+	sub  *testCommand
+	run  func() error
+}
+
+func (c *testCommand) CommandPath() string { return c.path }
+func (c *testCommand) Execute() error      { _, err := c.ExecuteC(); return err }
+func (c *testCommand) ExecuteC() (*testCommand, error) {
+	if c.sub != nil {
+		return c.sub, c.sub.run()
+	}
+	return c, c.run()
+}
+
+type plainCommand func() error
+
+func (c plainCommand) Execute() error { return c() }
+
+func TestExecuteCommand(t *testing.T) {
+	defer func(args []string) { os.Args = args }(os.Args)
+	os.Args = []string{"tool", "sync", "--token", "abc", "--dry-run"}
+
+	tests := []struct {
+		cmd     Command
+		code    int
+		level   Severity
+		command string
+	}{
+		{&testCommand{path: "tool", run: func() error { return nil }}, 0, "", ""},
+		{&testCommand{path: "tool", sub: &testCommand{path: "tool sync", run: func() error { return errors.New("failed") }}}, 1, ERROR, "tool sync"},
+		{&testCommand{path: "tool", run: func() error { panic("boom") }}, 2, FATAL, "tool"},
+		{plainCommand(func() error { return errors.New("failed") }), 1, ERROR, "raven.plainCommand"},
+	}
+
+	for i, test := range tests {
+		client, transport := newTestClient()
+		if code := client.ExecuteCommand(test.cmd); code != test.code {
+			t.Errorf("%d: incorrect exit code: got %d, want %d", i, code, test.code)
+		}
+		if test.level == "" {
+			if len(transport.packets) != 0 {
+				t.Errorf("%d: expected no event, got %d", i, len(transport.packets))
+			}
+			continue
+		}
+
+		if len(transport.packets) != 1 {
+			t.Fatalf("%d: expected 1 event, got %d", i, len(transport.packets))
+		}
+		packet := transport.packets[0]
+		tags := make(map[string]string)
+		for _, tag := range packet.Tags {
+			tags[tag.Key] = tag.Value
+		}
+		if packet.Level != test.level || tags["cli.command"] != test.command || tags["cli.args"] != "sync --token ******** --dry-run" {
+			t.Errorf("%d: incorrect packet: level %s, tags %+v", i, packet.Level, packet.Tags)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !raven_minimal
+// +build !raven_minimal
+
+package raven
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/base64"
+	"io"
+)
+
+// encodeBody returns the body of the request sending packetJSON and its
+// content type.
+func encodeBody(packetJSON []byte) (io.Reader, string) {
+	// Only deflate/base64 the packet if it is bigger than 1KB, as there is
+	// overhead.
+	if len(packetJSON) > 1000 {
+		buf := &bytes.Buffer{}
+		b64 := base64.NewEncoder(base64.StdEncoding, buf)
+		deflate, _ := zlib.NewWriterLevel(b64, zlib.BestCompression)
+		deflate.Write(packetJSON)
+		deflate.Close()
+		b64.Close()
+		return buf, "application/octet-stream"
+	}
+	return bytes.NewReader(packetJSON), "application/json"
+}
@@ -0,0 +1,15 @@
+//go:build raven_minimal
+// +build raven_minimal
+
+package raven
+
+import (
+	"bytes"
+	"io"
+)
+
+// encodeBody returns the body of the request sending packetJSON and its
+// content type. Minimal builds send plain JSON, leaving out zlib.
+func encodeBody(packetJSON []byte) (io.Reader, string) {
+	return bytes.NewReader(packetJSON), "application/json"
+}
@@ -0,0 +1,99 @@
+package raven
+
+import (
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// TestConcurrentClient exercises the client from many goroutines at once. It
+// is meant to be run with -race.
+func TestConcurrentClient(t *testing.T) {
+	client, _ := newTestClient()
+	client.SetDSN("https://u@example.com/1")
+
+	const goroutines = 8
+	const iterations = 50
+	var wg sync.WaitGroup
+	run := func(f func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < iterations; i++ {
+				f(i)
+			}
+		}()
+	}
+
+	for g := 0; g < goroutines; g++ {
+		run(func(i int) { client.CaptureMessage(fmt.Sprint("message ", i), map[string]string{"i": fmt.Sprint(i)}) })
+		run(func(i int) { client.CaptureError(errors.New("failed"), nil) })
+		run(func(i int) { client.Capture(NewPacket("packet"), nil) })
+	}
+	run(func(i int) { client.SetDSN(fmt.Sprintf("https://u@example.com/%d", i+1)) })
+	run(func(i int) { client.SetTagsContext(map[string]string{"iteration": fmt.Sprint(i)}) })
+	run(func(i int) { client.SetUserContext(&User{ID: fmt.Sprint(i)}) })
+	run(func(i int) { client.SetHttpContext(NewHttp(httptest.NewRequest("GET", "http://example.com/", nil))) })
+	run(func(i int) { client.SetContext("iteration", i) })
+	run(func(i int) { client.AddBreadcrumb(&Breadcrumb{Message: fmt.Sprint(i)}) })
+	run(func(i int) { client.ClearContext() })
+	run(func(i int) { client.SetIncludePaths([]string{fmt.Sprint("example.com/", i)}) })
+	run(func(i int) { client.SetSampleRate(1) })
+	run(func(i int) { client.SetRelease(fmt.Sprint(i)) })
+	run(func(i int) { client.SetEnvironment(fmt.Sprint(i)) })
+	run(func(i int) { client.SetIgnoreErrors([]string{fmt.Sprint("ignored ", i)}) })
+	run(func(i int) { client.Flush(0) })
+	wg.Wait()
+	client.Wait()
+}
+
+func TestNewWithTagsCopiesTags(t *testing.T) {
+	tags := map[string]string{"foo": "bar"}
+	client, err := NewWithTags("", tags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Transport = &testTransport{}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			tags["foo"] = fmt.Sprint(i)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		client.CaptureMessage("message", nil)
+	}
+	<-done
+	client.Wait()
+
+	if client.Tags["foo"] != "bar" {
+		t.Errorf("incorrect tag: got %s, want bar", client.Tags["foo"])
+	}
+}
+
+func TestConcurrentClose(t *testing.T) {
+	client, _ := newTestClient()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				client.CaptureMessage("message", nil)
+			}
+		}()
+	}
+	client.Close()
+	client.Close()
+	wg.Wait()
+
+	_, ch := client.Capture(NewPacket("after close"), nil)
+	if err := <-ch; err != ErrClientClosed {
+		t.Errorf("incorrect error: got %v, want %v", err, ErrClientClosed)
+	}
+}
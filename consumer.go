@@ -0,0 +1,188 @@
+package raven
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// A QueueMessage describes a message received from a queue such as SQS,
+// Pub/Sub or NATS.
+type QueueMessage struct {
+	ID    string
+	Queue string
+
+	// The delivery attempt, starting at 1, if the queue reports it.
+	Attempt int
+
+	// Metadata sent along with the message. It is reported as tags.
+	Attributes map[string]string
+
+	Payload []byte
+}
+
+// An Outcome tells the consumer what to do with a message once handled.
+type Outcome int
+
+const (
+	// Ack acknowledges the message: it was processed.
+	Ack Outcome = iota
+
+	// Retry leaves the message to be delivered again: handling it failed
+	// for a transient reason.
+	Retry
+
+	// Reject gives up on the message, e.g. by sending it to a dead-letter
+	// queue: handling it will never succeed.
+	Reject
+)
+
+func (o Outcome) String() string {
+	switch o {
+	case Ack:
+		return "ack"
+	case Retry:
+		return "retry"
+	case Reject:
+		return "reject"
+	}
+	return "outcome(" + strconv.Itoa(int(o)) + ")"
+}
+
+// A MessageHandler processes a message received from a queue.
+type MessageHandler func(ctx gocontext.Context, msg *QueueMessage) error
+
+// Consumer runs message handlers, recovering their panics and reporting their
+// errors to Sentry with the message metadata. Its zero value is usable.
+type Consumer struct {
+	// The client reporting errors, DefaultClient if nil.
+	Client *Client
+
+	// Whether to attach the message payload to events. JSON payloads have
+	// the values of fields that look like credentials masked.
+	AttachPayload bool
+
+	// Decides whether an error is retryable, ClassifyError if nil. Errors
+	// that can't be classified are retried.
+	Classify ErrorClassifier
+}
+
+// Consume handles msg with handler and decides its outcome. Retryable errors
+// are reported as warnings and fatal ones, including panics, as errors. The
+// report of a fatal error is sent before Consume returns, so that it isn't
+// lost if the process dies right after rejecting the message.
+func (c *Consumer) Consume(ctx gocontext.Context, msg *QueueMessage, handler MessageHandler) (outcome Outcome, err error) {
+	client := c.Client
+	if client == nil {
+		client = DefaultClientInstance()
+	}
+
+	defer func() {
+		if rval := recover(); rval != nil {
+			panicErr := &PanicError{Value: rval}
+			packet := client.newPanicPacket(2, rval, nil)
+			var ch chan error
+			panicErr.EventID, ch = c.capture(client, packet, msg, FATAL)
+			waitCapture(ctx, panicErr.EventID, ch)
+			outcome, err = Reject, panicErr
+		}
+	}()
+
+	err = handler(ctx, msg)
+	if err == nil {
+		return Ack, nil
+	}
+	// The consumer is shutting down, the message will be delivered again.
+	if ctx.Err() != nil {
+		return Retry, err
+	}
+
+	classify := c.Classify
+	if classify == nil {
+		classify = ClassifyError
+	}
+	retryable, ok := classify(err)
+
+	packet := client.newErrorPacket(1, err, nil)
+
+	if retryable || !ok {
+		c.capture(client, packet, msg, WARNING)
+		return Retry, err
+	}
+	eventID, ch := c.capture(client, packet, msg, ERROR)
+	waitCapture(ctx, eventID, ch)
+	return Reject, err
+}
+
+// Consume handles msg with handler, reporting errors to the default *Client
+func Consume(ctx gocontext.Context, msg *QueueMessage, handler MessageHandler) (Outcome, error) {
+	return (&Consumer{}).Consume(ctx, msg, handler)
+}
+
+func (c *Consumer) capture(client *Client, packet *Packet, msg *QueueMessage, level Severity) (string, chan error) {
+	packet.Level = level
+	tags := map[string]string{"messaging.queue": msg.Queue}
+	if msg.ID != "" {
+		tags["messaging.message_id"] = msg.ID
+	}
+	if msg.Attempt > 0 {
+		tags["messaging.attempt"] = strconv.Itoa(msg.Attempt)
+	}
+	for k, v := range msg.Attributes {
+		tags["messaging.attributes."+k] = v
+	}
+	if c.AttachPayload && len(msg.Payload) > 0 {
+		packet.Extra["messaging.payload"] = sanitizePayload(msg.Payload)
+	}
+	return client.Capture(packet, tags)
+}
+
+func waitCapture(ctx gocontext.Context, eventID string, ch chan error) {
+	if eventID == "" {
+		return
+	}
+	select {
+	case <-ch:
+	case <-ctx.Done():
+	}
+}
+
+// sanitizePayload masks the fields of a JSON payload that look like they hold
+// credentials, and truncates it to MaxResponseBodySize bytes.
+func sanitizePayload(payload []byte) string {
+	var v interface{}
+	if json.Unmarshal(payload, &v) == nil {
+		if sanitized, err := json.Marshal(sanitizeJSON(v)); err == nil {
+			payload = sanitized
+		}
+	}
+	if len(payload) > MaxResponseBodySize {
+		payload = payload[:MaxResponseBodySize]
+	}
+	return string(payload)
+}
+
+func sanitizeJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			masked := false
+			for _, keyword := range argSecretFields {
+				if strings.Contains(strings.ToLower(k), keyword) {
+					v[k] = "********"
+					masked = true
+					break
+				}
+			}
+			if !masked {
+				v[k] = sanitizeJSON(value)
+			}
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = sanitizeJSON(value)
+		}
+	}
+	return v
+}
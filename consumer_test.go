@@ -0,0 +1,64 @@
+package raven
+
+import (
+	gocontext "context"
+	"errors"
+	"testing"
+)
+
+func TestConsume(t *testing.T) {
+	msg := &QueueMessage{
+		ID:         "m-1",
+		Queue:      "orders",
+		Attempt:    2,
+		Attributes: map[string]string{"tenant": "acme"},
+		Payload:    []byte(`{"order":{"id":7,"api_key":"abc"},"password":"hunter2"}`),
+	}
+
+	tests := []struct {
+		handler  MessageHandler
+		outcome  Outcome
+		expected Severity
+	}{
+		{func(gocontext.Context, *QueueMessage) error { return nil }, Ack, ""},
+		{func(gocontext.Context, *QueueMessage) error { return &testTimeoutError{} }, Retry, WARNING},
+		{func(gocontext.Context, *QueueMessage) error { return errors.New("unknown") }, Retry, WARNING},
+		{func(gocontext.Context, *QueueMessage) error { return &statusError{"bad request", 400} }, Reject, ERROR},
+		{func(gocontext.Context, *QueueMessage) error { panic("boom") }, Reject, FATAL},
+	}
+
+	for i, test := range tests {
+		client, transport := newTestClient()
+		consumer := &Consumer{Client: client, AttachPayload: true}
+
+		outcome, err := consumer.Consume(gocontext.Background(), msg, test.handler)
+		client.Wait()
+		if outcome != test.outcome {
+			t.Errorf("%d: incorrect outcome: got %s, want %s", i, outcome, test.outcome)
+		}
+		if test.expected == "" {
+			if err != nil || len(transport.packets) != 0 {
+				t.Errorf("%d: expected no error, got %v", i, err)
+			}
+			continue
+		}
+
+		if len(transport.packets) != 1 {
+			t.Fatalf("%d: expected 1 event, got %d", i, len(transport.packets))
+		}
+		packet := transport.packets[0]
+		if packet.Level != test.expected {
+			t.Errorf("%d: incorrect Level: got %s, want %s", i, packet.Level, test.expected)
+		}
+		tags := make(map[string]string)
+		for _, tag := range packet.Tags {
+			tags[tag.Key] = tag.Value
+		}
+		if tags["messaging.queue"] != "orders" || tags["messaging.attempt"] != "2" || tags["messaging.attributes.tenant"] != "acme" {
+			t.Errorf("%d: incorrect tags: %+v", i, packet.Tags)
+		}
+		if expected := `{"order":{"api_key":"********","id":7},"password":"********"}`; packet.Extra["messaging.payload"] != expected {
+			t.Errorf("%d: incorrect payload: got %v, want %s", i, packet.Extra["messaging.payload"], expected)
+		}
+	}
+}
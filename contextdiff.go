@@ -0,0 +1,143 @@
+package raven
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// The extra key under which a ContextDiffer attaches its diff.
+const contextDiffKey = "context_diff"
+
+// ContextDiffer remembers the context of the previous event of every issue
+// and attaches to each new occurrence what changed since: tags, extra values,
+// release and environment. It helps spot the deploy or configuration change
+// that correlates with a recurrence. The diff is set as the "context_diff"
+// extra value, e.g.
+//
+//	{"release": {"from": "1.2.0", "to": "1.3.0"}, "tags": {"region": {"from": "eu", "to": null}}}
+//
+// Example:
+//
+//	client.AddEventProcessor(raven.NewContextDiffer().Process)
+type ContextDiffer struct {
+	mu       sync.Mutex
+	previous map[string]*eventContext
+}
+
+// A ValueChange is a difference reported by a ContextDiffer. From is nil for
+// added values, To for removed ones.
+type ValueChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+type eventContext struct {
+	tags        map[string]string
+	extra       map[string]interface{}
+	release     string
+	environment string
+	seen        time.Time
+}
+
+// NewContextDiffer creates a ContextDiffer, remembering up to
+// MaxTrackedFingerprints issues.
+func NewContextDiffer() *ContextDiffer {
+	return &ContextDiffer{previous: make(map[string]*eventContext)}
+}
+
+// Process is an EventProcessor attaching the diff to packet.
+func (d *ContextDiffer) Process(packet *Packet, err error) bool {
+	current := &eventContext{
+		tags:        make(map[string]string, len(packet.Tags)),
+		extra:       make(map[string]interface{}, len(packet.Extra)),
+		release:     packet.Release,
+		environment: packet.Environment,
+		seen:        time.Now(),
+	}
+	for _, tag := range packet.Tags {
+		current.tags[tag.Key] = tag.Value
+	}
+	for k, v := range packet.Extra {
+		if k != contextDiffKey {
+			current.extra[k] = deepCopy(v)
+		}
+	}
+
+	key := fingerprintKey(packet)
+	d.mu.Lock()
+	previous := d.previous[key]
+	if previous == nil && len(d.previous) >= MaxTrackedFingerprints {
+		d.evict()
+	}
+	d.previous[key] = current
+	d.mu.Unlock()
+
+	if previous == nil {
+		return true
+	}
+	if diff := diffContexts(previous, current); len(diff) > 0 {
+		if packet.Extra == nil {
+			packet.Extra = Extra{}
+		}
+		packet.Extra[contextDiffKey] = diff
+	}
+	return true
+}
+
+// evict forgets the least recently seen issue. d.mu must be held.
+func (d *ContextDiffer) evict() {
+	var oldest string
+	var oldestSeen time.Time
+	for key, c := range d.previous {
+		if oldest == "" || c.seen.Before(oldestSeen) {
+			oldest, oldestSeen = key, c.seen
+		}
+	}
+	delete(d.previous, oldest)
+}
+
+func diffContexts(from, to *eventContext) map[string]interface{} {
+	diff := make(map[string]interface{})
+	if from.release != to.release {
+		diff["release"] = ValueChange{from.release, to.release}
+	}
+	if from.environment != to.environment {
+		diff["environment"] = ValueChange{from.environment, to.environment}
+	}
+
+	tags := make(map[string]ValueChange)
+	for k, v := range to.tags {
+		if old, ok := from.tags[k]; !ok {
+			tags[k] = ValueChange{nil, v}
+		} else if old != v {
+			tags[k] = ValueChange{old, v}
+		}
+	}
+	for k, v := range from.tags {
+		if _, ok := to.tags[k]; !ok {
+			tags[k] = ValueChange{v, nil}
+		}
+	}
+	if len(tags) > 0 {
+		diff["tags"] = tags
+	}
+
+	extra := make(map[string]ValueChange)
+	for k, v := range to.extra {
+		if old, ok := from.extra[k]; !ok {
+			extra[k] = ValueChange{nil, v}
+		} else if !reflect.DeepEqual(old, v) {
+			extra[k] = ValueChange{old, v}
+		}
+	}
+	for k, v := range from.extra {
+		if _, ok := to.extra[k]; !ok {
+			extra[k] = ValueChange{v, nil}
+		}
+	}
+	if len(extra) > 0 {
+		diff["extra"] = extra
+	}
+	return diff
+}
@@ -0,0 +1,50 @@
+package raven
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestContextDiffer(t *testing.T) {
+	d := NewContextDiffer()
+	packet := func(release string, tags Tags, extra Extra) *Packet {
+		p := NewPacketWithExtra("failed", extra)
+		p.Release, p.Tags = release, tags
+		return p
+	}
+
+	first := packet("1.2.0", Tags{{"region", "eu"}, {"host", "a"}}, Extra{"attempt": 1, "user": "jane"})
+	d.Process(first, nil)
+	if _, ok := first.Extra[contextDiffKey]; ok {
+		t.Error("unexpected diff on the first occurrence")
+	}
+
+	same := packet("1.2.0", Tags{{"region", "eu"}, {"host", "a"}}, Extra{"attempt": 1, "user": "jane"})
+	d.Process(same, nil)
+	if diff, ok := same.Extra[contextDiffKey]; ok {
+		t.Errorf("unexpected diff without changes: %+v", diff)
+	}
+
+	changed := packet("1.3.0", Tags{{"host", "b"}, {"canary", "true"}}, Extra{"attempt": 2, "user": "jane"})
+	d.Process(changed, nil)
+	expected := map[string]interface{}{
+		"release": ValueChange{"1.2.0", "1.3.0"},
+		"tags": map[string]ValueChange{
+			"region": {"eu", nil},
+			"host":   {"a", "b"},
+			"canary": {nil, "true"},
+		},
+		"extra": map[string]ValueChange{
+			"attempt": {1, 2},
+		},
+	}
+	if diff := changed.Extra[contextDiffKey]; !reflect.DeepEqual(diff, expected) {
+		t.Errorf("incorrect diff:\ngot  %+v\nwant %+v", diff, expected)
+	}
+
+	other := NewPacket("another issue")
+	d.Process(other, nil)
+	if _, ok := other.Extra[contextDiffKey]; ok {
+		t.Error("unexpected diff on the first occurrence of another issue")
+	}
+}
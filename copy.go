@@ -0,0 +1,27 @@
+package raven
+
+// snapshot copies the tags and extra of packet, so that the caller may keep
+// modifying the slices and maps it built the packet with while the packet
+// waits to be serialized. Maps and slices nested in extra are copied too;
+// pointers and structs are not.
+func (packet *Packet) snapshot() {
+	if packet.Tags != nil {
+		packet.Tags = append([]Tag(nil), packet.Tags...)
+	}
+	if packet.Extra != nil {
+		extra := make(Extra, len(packet.Extra))
+		for k, v := range packet.Extra {
+			extra[k] = deepCopy(v)
+		}
+		packet.Extra = extra
+	}
+}
+
+// copyTags returns a copy of tags.
+func copyTags(tags map[string]string) map[string]string {
+	c := make(map[string]string, len(tags))
+	for k, v := range tags {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,62 @@
+package raven
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDeepCopy(t *testing.T) {
+	type point struct{ X, Y int }
+	values := []interface{}{
+		nil,
+		"string",
+		42,
+		point{1, 2},
+		[]string{"a", "b"},
+		map[string]int{"a": 1},
+		map[string]interface{}{"nested": []interface{}{map[string]string{"k": "v"}}},
+		Extra{"list": []int{1, 2}},
+		[]string(nil),
+	}
+
+	for i, v := range values {
+		if c := deepCopy(v); !reflect.DeepEqual(c, v) {
+			t.Errorf("%d: incorrect copy: got %#v, want %#v", i, c, v)
+		}
+	}
+
+	original := map[string]interface{}{"nested": []interface{}{map[string]string{"k": "v"}}}
+	c := deepCopy(original).(map[string]interface{})
+	c["nested"].([]interface{})[0].(map[string]string)["k"] = "changed"
+	if original["nested"].([]interface{})[0].(map[string]string)["k"] != "v" {
+		t.Error("copy shares a nested map with the original")
+	}
+}
+
+func TestCaptureSnapshotsTagsAndExtra(t *testing.T) {
+	client, _ := newTestClient()
+	transport := &blockingTransport{started: make(chan struct{}, 1), release: make(chan struct{})}
+	client.Transport = transport
+
+	ids := []string{"1", "2"}
+	extra := Extra{"ids": ids}
+	tags := make([]Tag, 1, 4)
+	tags[0] = Tag{"a", "1"}
+	packet := NewPacketWithExtra("test", extra)
+	packet.Tags = tags
+
+	client.Capture(packet, map[string]string{"b": "2"})
+	ids[0] = "changed"
+	_ = append(tags, Tag{"c", "3"})
+	<-transport.started
+
+	if packet.Extra["ids"].([]string)[0] != "1" {
+		t.Errorf("extra shared with the caller: %v", packet.Extra["ids"])
+	}
+	if packet.Tags[1] != (Tag{"b", "2"}) {
+		t.Errorf("tags shared with the caller: %v", packet.Tags)
+	}
+
+	close(transport.release)
+	client.Wait()
+}
@@ -0,0 +1,112 @@
+package raven
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type debugQueue struct {
+	Length   int `json:"length"`
+	Capacity int `json:"capacity"`
+}
+
+type debugInfo struct {
+	URL       string     `json:"url"`
+	ProjectID string     `json:"project_id"`
+	Queue     debugQueue `json:"queue"`
+
+	Queued  uint64 `json:"queued"`
+	Sent    uint64 `json:"sent"`
+	Failed  uint64 `json:"failed"`
+	Dropped uint64 `json:"dropped"`
+
+	LastSuccess         *time.Time `json:"last_success,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorTime       *time.Time `json:"last_error_time,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	RateLimitedUntil    *time.Time `json:"rate_limited_until,omitempty"`
+
+	// Most recent first
+	RecentEvents []recentEvent `json:"recent_events"`
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func (client *Client) debugInfo() *debugInfo {
+	info := &debugInfo{
+		URL:       client.URL(),
+		ProjectID: client.ProjectID(),
+		Queue:     debugQueue{len(client.queue), cap(client.queue)},
+	}
+
+	s := &client.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	info.Queued, info.Sent, info.Failed, info.Dropped = s.queued, s.sent, s.failed, s.dropped
+	info.LastSuccess = optionalTime(s.lastSuccess)
+	if s.lastError != nil {
+		info.LastError = s.lastError.Error()
+		info.LastErrorTime = optionalTime(s.lastErrorTime)
+	}
+	info.ConsecutiveFailures = s.consecutiveFailures
+	if time.Now().Before(s.rateLimitedUntil) {
+		info.RateLimitedUntil = optionalTime(s.rateLimitedUntil)
+	}
+	info.RecentEvents = make([]recentEvent, 0, len(s.recent))
+	for i := len(s.recent) - 1; i >= 0; i-- {
+		info.RecentEvents = append(info.RecentEvents, s.recent[i])
+	}
+	return info
+}
+
+// DebugHandler returns an http.Handler reporting the state of the client as
+// JSON: recently captured events and what became of them, queue usage, rate
+// limiting and the last transport error. It helps diagnosing events that
+// don't show up in Sentry, and should only be mounted on an internal port.
+//
+// Example:
+//
+//	http.Handle("/debug/raven", raven.DebugHandler())
+func (client *Client) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(client.debugInfo())
+	})
+}
+
+// DebugHandler returns an http.Handler reporting the state of the default *Client
+func DebugHandler() http.Handler { return DefaultClientInstance().DebugHandler() }
+
+var (
+	debugLoggerMu sync.RWMutex
+	debugLogger   *log.Logger
+)
+
+// SetDebugLogger makes the package log what it does behind the scenes, e.g.
+// retrying an event it had to truncate, to l. Nothing is logged by default.
+func SetDebugLogger(l *log.Logger) {
+	debugLoggerMu.Lock()
+	defer debugLoggerMu.Unlock()
+	debugLogger = l
+}
+
+func debugf(format string, args ...interface{}) {
+	debugLoggerMu.RLock()
+	l := debugLogger
+	debugLoggerMu.RUnlock()
+
+	if l != nil {
+		l.Printf(format, args...)
+	}
+}
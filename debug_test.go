@@ -0,0 +1,77 @@
+package raven
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type failingTransport struct {
+	err error
+}
+
+func (t *failingTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.err
+}
+
+func TestDebugHandler(t *testing.T) {
+	client, _ := newTestClient()
+	client.CaptureMessage("first", nil)
+	client.Wait()
+
+	until := time.Now().Add(time.Minute)
+	client.Transport = &failingTransport{&RateLimitError{Until: until, Reason: "over quota"}}
+	eventID := client.CaptureMessage("second", nil)
+	client.Wait()
+
+	rec := httptest.NewRecorder()
+	client.DebugHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/raven", nil))
+
+	var info debugInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("invalid JSON %s: %v", rec.Body.String(), err)
+	}
+	if info.Queued != 2 || info.Sent != 1 || info.Failed != 1 || info.ConsecutiveFailures != 1 {
+		t.Errorf("incorrect counters: %+v", info)
+	}
+	if info.Queue.Capacity != MaxQueueBuffer {
+		t.Errorf("incorrect queue capacity: %d", info.Queue.Capacity)
+	}
+	if info.RateLimitedUntil == nil || !info.RateLimitedUntil.Equal(until) {
+		t.Errorf("incorrect rate limit: %v", info.RateLimitedUntil)
+	}
+	if len(info.RecentEvents) != 2 {
+		t.Fatalf("expected 2 recent events, got %+v", info.RecentEvents)
+	}
+	if e := info.RecentEvents[0]; e.EventID != eventID || e.Status != "failed" || e.Error == "" {
+		t.Errorf("incorrect recent event: %+v", e)
+	}
+	if e := info.RecentEvents[1]; e.Message != "first" || e.Status != "sent" {
+		t.Errorf("incorrect recent event: %+v", e)
+	}
+}
+
+func TestHTTPTransportRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "30")
+		w.Header().Set("X-Sentry-Error", "over quota")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	transport := &HTTPTransport{Client: http.DefaultClient}
+	err := transport.Send(server.URL, "", NewPacket("test"))
+
+	rle, ok := err.(*RateLimitError)
+	if !ok {
+		t.Fatalf("expected a *RateLimitError, got %v", err)
+	}
+	if d := rle.Until.Sub(time.Now()); d < 25*time.Second || d > 30*time.Second {
+		t.Errorf("incorrect retry after: %v", d)
+	}
+	if rle.Reason != "over quota" {
+		t.Errorf("incorrect reason: %s", rle.Reason)
+	}
+}
@@ -0,0 +1,48 @@
+//go:build raven_minimal
+// +build raven_minimal
+
+package raven
+
+// deepCopy returns a copy of v sharing no map or slice with it. Minimal builds
+// leave out reflection and only copy the maps and slices JSON decodes to,
+// along with string maps and slices; other values are shared.
+func deepCopy(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v == nil {
+			return v
+		}
+		c := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			c[k] = deepCopy(e)
+		}
+		return c
+	case Extra:
+		if v == nil {
+			return v
+		}
+		return Extra(deepCopy(map[string]interface{}(v)).(map[string]interface{}))
+	case []interface{}:
+		if v == nil {
+			return v
+		}
+		c := make([]interface{}, len(v))
+		for i, e := range v {
+			c[i] = deepCopy(e)
+		}
+		return c
+	case map[string]string:
+		if v == nil {
+			return v
+		}
+		return copyTags(v)
+	case []string:
+		if v == nil {
+			return v
+		}
+		c := make([]string, len(v))
+		copy(c, v)
+		return c
+	}
+	return v
+}
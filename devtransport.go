@@ -0,0 +1,153 @@
+package raven
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var severityColors = map[Severity]string{
+	DEBUG:   "\x1b[90m",
+	INFO:    "\x1b[34m",
+	WARNING: "\x1b[33m",
+	ERROR:   "\x1b[31m",
+	FATAL:   "\x1b[1;35m",
+}
+
+const colorReset = "\x1b[0m"
+
+// DevTransport renders events human-readably instead of sending them to
+// Sentry, for local development. Clients use it when the SENTRY_DEV
+// environment variable is true.
+type DevTransport struct {
+	// Where events are rendered, os.Stderr if nil.
+	Writer io.Writer
+
+	// Whether to highlight levels and the failing line with ANSI colors.
+	Color bool
+
+	mu sync.Mutex
+}
+
+// NewDevTransport creates a DevTransport writing to os.Stderr, with colors
+// when it is a terminal, unless the NO_COLOR environment variable is set.
+func NewDevTransport() *DevTransport {
+	return &DevTransport{Writer: os.Stderr, Color: os.Getenv("NO_COLOR") == "" && isTerminal(os.Stderr)}
+}
+
+// isTerminal reports whether f is a terminal rather than, e.g., a file or a
+// pipe to a log collector.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func (t *DevTransport) Send(url, authHeader string, packet *Packet) error {
+	var b bytes.Buffer
+	t.render(&b, packet)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	w := t.Writer
+	if w == nil {
+		w = os.Stderr
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+func (t *DevTransport) color(code, s string) string {
+	if !t.Color || code == "" {
+		return s
+	}
+	return code + s + colorReset
+}
+
+func (t *DevTransport) render(b *bytes.Buffer, packet *Packet) {
+	level := strings.ToUpper(string(packet.Level))
+	fmt.Fprintf(b, "%s [%s] %s %s\n", t.color(severityColors[packet.Level], level), packet.Logger, packet.Message, t.color("\x1b[90m", "("+packet.EventID+")"))
+	if packet.Culprit != "" {
+		fmt.Fprintf(b, "  culprit: %s\n", packet.Culprit)
+	}
+	if len(packet.Tags) > 0 {
+		tags := make([]string, len(packet.Tags))
+		for i, tag := range packet.Tags {
+			tags[i] = tag.Key + "=" + tag.Value
+		}
+		fmt.Fprintf(b, "  tags: %s\n", strings.Join(tags, ", "))
+	}
+
+	for _, inter := range packet.Interfaces {
+		switch inter := inter.(type) {
+		case *Exception:
+			t.renderException(b, inter)
+		case *Exceptions:
+			for _, ex := range inter.Values {
+				t.renderException(b, ex)
+			}
+		case Exceptions:
+			for _, ex := range inter.Values {
+				t.renderException(b, ex)
+			}
+		case *Stacktrace:
+			t.renderStacktrace(b, inter)
+		case *Http:
+			fmt.Fprintf(b, "  request: %s %s\n", inter.Method, inter.URL)
+		}
+	}
+
+	extra := make([]string, 0, len(packet.Extra))
+	for k, v := range packet.Extra {
+		if !strings.HasPrefix(k, "runtime.") {
+			extra = append(extra, fmt.Sprintf("%s=%v", k, v))
+		}
+	}
+	if len(extra) > 0 {
+		sort.Strings(extra)
+		fmt.Fprintf(b, "  extra: %s\n", strings.Join(extra, ", "))
+	}
+	b.WriteString("\n")
+}
+
+func (t *DevTransport) renderException(b *bytes.Buffer, ex *Exception) {
+	fmt.Fprintf(b, "  %s: %s\n", ex.Type, ex.Value)
+	if ex.Stacktrace != nil {
+		t.renderStacktrace(b, ex.Stacktrace)
+	}
+}
+
+// renderStacktrace prints the frames innermost first, the way Go does, with
+// the source context of in-app frames.
+func (t *DevTransport) renderStacktrace(b *bytes.Buffer, s *Stacktrace) {
+	for i := len(s.Frames) - 1; i >= 0; i-- {
+		frame := s.Frames[i]
+		name := frame.Function
+		if frame.Module != "" {
+			name = frame.Module + "." + frame.Function
+		}
+		location := fmt.Sprintf("    %s:%d in %s\n", frame.Filename, frame.Lineno, name)
+		if !frame.InApp {
+			location = t.color("\x1b[90m", location)
+		}
+		b.WriteString(location)
+
+		if !frame.InApp || frame.ContextLine == "" {
+			continue
+		}
+		line := frame.Lineno - len(frame.PreContext)
+		for _, l := range frame.PreContext {
+			fmt.Fprintf(b, "      %5d | %s\n", line, l)
+			line++
+		}
+		fmt.Fprintf(b, "%s\n", t.color("\x1b[1m", fmt.Sprintf("    > %5d | %s", line, frame.ContextLine)))
+		line++
+		for _, l := range frame.PostContext {
+			fmt.Fprintf(b, "      %5d | %s\n", line, l)
+			line++
+		}
+	}
+}
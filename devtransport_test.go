@@ -0,0 +1,57 @@
+package raven
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDevTransport(t *testing.T) {
+	var buf bytes.Buffer
+	client := newClient(nil)
+	client.Transport = &DevTransport{Writer: &buf}
+	client.SetIncludePaths([]string{"github.com/getsentry/raven-go"})
+
+	client.CaptureError(errors.New("connection refused"), map[string]string{"region": "eu"})
+	client.Wait()
+
+	output := buf.String()
+	for _, expected := range []string{
+		"ERROR [root] connection refused",
+		"tags: region=eu",
+		"*errors.errorString: connection refused",
+		"devtransport_test.go:",
+		`>   `,
+		`client.CaptureError(errors.New("connection refused")`,
+	} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("expected %q in output:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "\x1b[") {
+		t.Error("expected no colors")
+	}
+}
+
+func TestDevTransportFromEnvironment(t *testing.T) {
+	defer os.Unsetenv("SENTRY_DEV")
+	os.Setenv("SENTRY_DEV", "true")
+
+	if _, ok := newClient(nil).Transport.(*DevTransport); !ok {
+		t.Error("expected a DevTransport")
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	if isTerminal(w) {
+		t.Error("expected a pipe not to be a terminal")
+	}
+}
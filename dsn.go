@@ -0,0 +1,189 @@
+package raven
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrInvalidScheme       = errors.New("raven: dsn scheme should be http or https")
+	ErrMissingHost         = errors.New("raven: dsn missing host")
+	ErrInvalidProjectID    = errors.New("raven: dsn project id should be numeric")
+	ErrSecretKeyNotAllowed = errors.New("raven: dsn secret keys are deprecated and not allowed in strict mode")
+)
+
+// DSN is a parsed Sentry DSN, of the form
+// {scheme}://{public_key}[:{secret_key}]@{host}{path}/{project_id}
+type DSN struct {
+	Scheme    string
+	PublicKey string
+	SecretKey string
+
+	// The host, including the port if any.
+	Host string
+
+	// The path Sentry is served under, without the project id. Usually empty.
+	Path string
+
+	ProjectID string
+}
+
+// ParseDSN parses and validates a DSN. In strict mode, legacy DSNs carrying a
+// secret key are rejected, and so are project ids that aren't numeric.
+func ParseDSN(dsn string, strict bool) (*DSN, error) {
+	uri, err := url.Parse(dsn)
+	if err != nil {
+		// url.Error embeds the whole DSN, keys included.
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err
+		}
+		return nil, fmt.Errorf("raven: invalid dsn %q: %v", redactDSN(dsn), err)
+	}
+
+	if uri.Scheme != "http" && uri.Scheme != "https" {
+		return nil, ErrInvalidScheme
+	}
+	if uri.Host == "" {
+		return nil, ErrMissingHost
+	}
+	if uri.User == nil || uri.User.Username() == "" {
+		return nil, ErrMissingUser
+	}
+
+	d := &DSN{
+		Scheme:    uri.Scheme,
+		PublicKey: uri.User.Username(),
+		Host:      uri.Host,
+	}
+	if secretKey, ok := uri.User.Password(); ok {
+		if strict {
+			return nil, ErrSecretKeyNotAllowed
+		}
+		d.SecretKey = secretKey
+	}
+
+	path := strings.TrimSuffix(uri.Path, "/")
+	idx := strings.LastIndex(path, "/")
+	if idx == -1 || idx == len(path)-1 {
+		return nil, ErrMissingProjectID
+	}
+	d.Path, d.ProjectID = path[:idx], path[idx+1:]
+	if strict {
+		for _, c := range d.ProjectID {
+			if c < '0' || c > '9' {
+				return nil, ErrInvalidProjectID
+			}
+		}
+	}
+
+	return d, nil
+}
+
+// String returns the DSN with its keys masked, so that it can safely be
+// logged, e.g. https://****@example.com/1
+func (d *DSN) String() string {
+	return fmt.Sprintf("%s://****@%s%s/%s", d.Scheme, d.Host, d.Path, d.ProjectID)
+}
+
+// redactDSN masks the keys of a DSN that may not even parse.
+func redactDSN(dsn string) string {
+	start := 0
+	if i := strings.Index(dsn, "//"); i >= 0 && !strings.Contains(dsn[:i], "/") {
+		start = i + 2
+	}
+	end := strings.IndexAny(dsn[start:], "@/")
+	if end < 0 || dsn[start+end] != '@' {
+		return dsn
+	}
+	return dsn[:start] + "****" + dsn[start+end:]
+}
+
+// StoreURL returns the URL events are sent to.
+func (d *DSN) StoreURL() string {
+	return fmt.Sprintf("%s://%s%s/api/%s/store/", d.Scheme, d.Host, d.Path, d.ProjectID)
+}
+
+// The protocol version sent when none is set with SetProtocolVersion.
+const defaultProtocolVersion = 7
+
+// authHeader builds the X-Sentry-Auth header. The secret key is only sent when
+// withSecret is set: Sentry stopped requiring it and it should not leak into
+// proxies and logs.
+func (d *DSN) authHeader(version int, sentryClient string, withSecret bool) string {
+	header := fmt.Sprintf("Sentry sentry_version=%d", version)
+	if sentryClient != "" {
+		header += ", sentry_client=" + sentryClient
+	}
+	header += ", sentry_key=" + d.PublicKey
+	if withSecret && d.SecretKey != "" {
+		header += ", sentry_secret=" + d.SecretKey
+	}
+	return header
+}
+
+// updateAuthHeader recomputes the auth header after its inputs changed.
+// client.mu must be held.
+func (client *Client) updateAuthHeader() {
+	for _, route := range client.dsnRoutes {
+		route.authHeader = client.authHeaderFor(route.dsn)
+	}
+	for _, route := range client.tenantRoutes {
+		route.authHeader = client.authHeaderFor(route.dsn)
+	}
+	if client.dsn == nil {
+		return
+	}
+	client.authHeader = client.authHeaderFor(client.dsn)
+}
+
+// authHeaderFor returns the auth header for d matching the client's settings.
+// client.mu must be held.
+func (client *Client) authHeaderFor(d *DSN) string {
+	version := client.protocolVersion
+	if version == 0 {
+		version = defaultProtocolVersion
+	}
+	var sentryClient string
+	if client.sdk.Name != "" {
+		sentryClient = client.sdk.Name + "/" + client.sdk.Version
+	}
+	return d.authHeader(version, sentryClient, client.sendSecretKey)
+}
+
+// SetProtocolVersion sets the version of the Sentry protocol announced to the
+// server, 7 by default. Old self-hosted servers may need 4, along with
+// SetSendSecretKey.
+func (client *Client) SetProtocolVersion(version int) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.protocolVersion = version
+	client.updateAuthHeader()
+}
+
+// SetProtocolVersion sets the protocol version of the default *Client
+func SetProtocolVersion(version int) { DefaultClientInstance().SetProtocolVersion(version) }
+
+// SetSendSecretKey controls whether the deprecated secret key of a legacy DSN
+// is sent to the server. Only old self-hosted servers need it.
+func (client *Client) SetSendSecretKey(send bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.sendSecretKey = send
+	client.updateAuthHeader()
+}
+
+// SetSendSecretKey controls whether the default *Client sends secret keys
+func SetSendSecretKey(send bool) { DefaultClientInstance().SetSendSecretKey(send) }
+
+// SetStrictDSN makes SetDSN reject legacy DSNs that carry a secret key, and
+// DSNs whose project id isn't numeric.
+func (client *Client) SetStrictDSN(strict bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.strictDSN = strict
+}
+
+// SetStrictDSN enables strict DSN validation on the default *Client
+func SetStrictDSN(strict bool) { DefaultClientInstance().SetStrictDSN(strict) }
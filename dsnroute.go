@@ -0,0 +1,87 @@
+package raven
+
+// A PacketMatcher selects events, e.g. to route them to another project.
+type PacketMatcher func(packet *Packet) bool
+
+// MatchLogger matches events from one of loggers.
+func MatchLogger(loggers ...string) PacketMatcher {
+	return func(packet *Packet) bool { return containsString(loggers, packet.Logger) }
+}
+
+// MatchEnvironment matches events from one of environments.
+func MatchEnvironment(environments ...string) PacketMatcher {
+	return func(packet *Packet) bool { return containsString(environments, packet.Environment) }
+}
+
+// MatchTag matches events having a tag key set to one of values, or to any
+// value if none is given.
+func MatchTag(key string, values ...string) PacketMatcher {
+	return func(packet *Packet) bool {
+		for _, tag := range packet.Tags {
+			if tag.Key == key && (len(values) == 0 || containsString(values, tag.Value)) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type dsnRoute struct {
+	match      PacketMatcher
+	dsn        *DSN
+	url        string
+	authHeader string
+}
+
+// AddDSNRoute sends the events matched by match to dsn rather than to the
+// client's DSN, e.g. to send the errors of one component of a service to the
+// project of the team owning it. Matchers are consulted in the order they were
+// added, once event processors have run, and the first match wins.
+//
+// Example:
+//
+//	client.AddDSNRoute(paymentsDSN, raven.MatchTag("component", "payments"))
+func (client *Client) AddDSNRoute(dsn string, match PacketMatcher) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	d, err := ParseDSN(dsn, client.strictDSN)
+	if err != nil {
+		return err
+	}
+	client.dsnRoutes = append(client.dsnRoutes, &dsnRoute{
+		match:      match,
+		dsn:        d,
+		url:        d.StoreURL(),
+		authHeader: client.authHeaderFor(d),
+	})
+	return nil
+}
+
+// AddDSNRoute routes the events of the default *Client matched by match to dsn
+func AddDSNRoute(dsn string, match PacketMatcher) error {
+	return DefaultClientInstance().AddDSNRoute(dsn, match)
+}
+
+// matchDSNRoute returns a copy of the route of the tenant packet was captured
+// for with ForTenant, or else of the first route matching packet, if any. A
+// "tenant" tag alone doesn't select a tenant's route, so that events can't
+// leak to a tenant's project by carrying its name. Copies are taken while
+// client.mu is held since updateAuthHeader changes routes.
+func (client *Client) matchDSNRoute(packet *Packet) *dsnRoute {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	if packet.tenant != nil {
+		if route := client.tenantRoutes[packet.tenant.id]; route != nil {
+			r := *route
+			return &r
+		}
+	}
+	for _, route := range client.dsnRoutes {
+		if route.match(packet) {
+			r := *route
+			return &r
+		}
+	}
+	return nil
+}
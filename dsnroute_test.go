@@ -0,0 +1,140 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestDSNRoutes(t *testing.T) {
+	client := newClient(nil)
+	release := make(chan struct{})
+	close(release)
+	transport := &urlTransport{blockingTransport: blockingTransport{started: make(chan struct{}, 10), release: release}}
+	client.Transport = transport
+	client.SetDSN("https://platform@example.com/1")
+	client.SetSDK("raven-go", "1.2.0")
+
+	if err := client.AddDSNRoute("https://payments@example.com/2", MatchTag("component", "payments")); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.AddDSNRoute("https://jobs@example.com/3", MatchLogger("jobs", "cron")); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.AddDSNRoute("https://invalid", MatchEnvironment("staging")); err == nil {
+		t.Error("expected an error for an invalid DSN")
+	}
+
+	packets := []*Packet{
+		{Message: "platform"},
+		{Message: "payments", Logger: "jobs", Tags: Tags{{"component", "payments"}}},
+		{Message: "jobs", Logger: "cron"},
+		{Message: "explicit", Logger: "jobs", Project: "42"},
+	}
+	for _, packet := range packets {
+		_, ch := client.Capture(packet, nil)
+		<-ch
+	}
+
+	expected := []string{
+		"https://example.com/api/1/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=platform 1",
+		"https://example.com/api/2/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=payments 2",
+		"https://example.com/api/3/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=jobs 3",
+		"https://example.com/api/3/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=jobs 42",
+	}
+	if !reflect.DeepEqual(transport.urls, expected) {
+		t.Errorf("incorrect destinations:\ngot  %q\nwant %q", transport.urls, expected)
+	}
+}
+
+func TestDSNRoutesWhileUpdatingAuth(t *testing.T) {
+	client, _ := newTestClient()
+	client.SetDSN("https://platform@example.com/1")
+	if err := client.AddDSNRoute("https://payments@example.com/2", MatchLogger("payments")); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+				client.SetSendSecretKey(i%2 == 0)
+			}
+		}
+	}()
+	for i := 0; i < 50; i++ {
+		client.Capture(&Packet{Message: "payments", Logger: "payments"}, nil)
+	}
+	close(done)
+	wg.Wait()
+	client.Wait()
+}
+
+func TestDSNRoutesQueueFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "queue")
+
+	blocking := &blockingTransport{make(chan struct{}), make(chan struct{})}
+	client := newClient(nil)
+	client.Transport = blocking
+	client.SetDSN("https://platform@example.com/1")
+	if err := client.AddDSNRoute("https://payments@example.com/2", MatchLogger("payments")); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetQueueFile(path); err != nil {
+		t.Fatal(err)
+	}
+	client.CaptureMessage("in flight", nil)
+	<-blocking.started
+	client.Capture(&Packet{Message: "payments", Logger: "payments"}, nil)
+	client.Close()
+	close(blocking.release)
+	client.Wait()
+
+	// The route isn't set up again before the queue file is loaded
+	client = newClient(nil)
+	transport := &urlTransport{blockingTransport: blockingTransport{started: make(chan struct{}, 1), release: make(chan struct{})}}
+	close(transport.release)
+	client.Transport = transport
+	client.SetDSN("https://platform@example.com/1")
+	if err := client.SetQueueFile(path); err != nil {
+		t.Fatal(err)
+	}
+	client.Wait()
+
+	expected := "https://example.com/api/2/store/ Sentry sentry_version=7, sentry_client=" + client.sdk.UserAgent() + ", sentry_key=payments 2"
+	if len(transport.urls) != 1 || transport.urls[0] != expected {
+		t.Errorf("incorrect destinations:\ngot  %q\nwant %q", transport.urls, expected)
+	}
+}
+
+func TestMatchTag(t *testing.T) {
+	packet := &Packet{Tags: Tags{{"component", "payments"}}}
+	tests := []struct {
+		matcher  PacketMatcher
+		expected bool
+	}{
+		{MatchTag("component"), true},
+		{MatchTag("component", "billing", "payments"), true},
+		{MatchTag("component", "billing"), false},
+		{MatchTag("team"), false},
+	}
+	for i, test := range tests {
+		if got := test.matcher(packet); got != test.expected {
+			t.Errorf("incorrect match %d: got %t, want %t", i, got, test.expected)
+		}
+	}
+}
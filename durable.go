@@ -0,0 +1,142 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// The extension of the files holding unacknowledged events.
+const journalExt = ".event"
+
+// SetDurableDir makes the client deliver events at least once: each event is
+// written to a file in dir before being queued and only removed once Sentry
+// acknowledged it. Events still in dir, because they failed, were dropped or
+// the process died before delivering them, are redelivered right away and by
+// RedeliverPending. Redelivered events keep their ID, which Sentry
+// deduplicates. It trades throughput for durability, and is meant for
+// critical events. The queue file key and codec, if set, apply to these
+// files. An empty dir disables it.
+func (client *Client) SetDurableDir(dir string) error {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return err
+		}
+	}
+
+	client.mu.Lock()
+	client.durableDir = dir
+	client.mu.Unlock()
+
+	if dir == "" {
+		return nil
+	}
+	_, err := client.RedeliverPending()
+	return err
+}
+
+// SetDurableDir sets the durable directory of the default *Client
+func SetDurableDir(dir string) error { return DefaultClientInstance().SetDurableDir(dir) }
+
+// RedeliverPending queues again the events of the durable directory that
+// weren't acknowledged yet, returning how many there were. Events still
+// waiting in the queue may be sent twice.
+func (client *Client) RedeliverPending() (int, error) {
+	client.mu.RLock()
+	dir := client.durableDir
+	client.mu.RUnlock()
+	if dir == "" {
+		return 0, nil
+	}
+
+	names, err := filepath.Glob(filepath.Join(dir, "*"+journalExt))
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, name := range names {
+		p, err := client.readJournal(name)
+		if err != nil {
+			debugf("raven: can't read pending event %s: %v", name, err)
+			continue
+		}
+		client.restoreTarget(p)
+		client.wg.Add(1)
+		client.enqueue(p.packet, p.url, p.authHeader, p.publicKey, make(chan error, 1))
+		count++
+	}
+	return count, nil
+}
+
+// RedeliverPending queues again the unacknowledged events of the default *Client
+func RedeliverPending() (int, error) { return DefaultClientInstance().RedeliverPending() }
+
+// journal writes the packet of p and its target to the durable directory, if
+// set, returning the path of its file.
+func (client *Client) journal(p *outgoingPacket) string {
+	packet := p.packet
+	client.mu.RLock()
+	dir := client.durableDir
+	aead := client.queueFileCipher
+	codec := client.queueCodec
+	client.mu.RUnlock()
+	if dir == "" || packet.EventID == "" {
+		return ""
+	}
+	if codec == nil {
+		codec = CanonicalCodec
+	}
+
+	packet.resolveStacktraces()
+	path := filepath.Join(dir, packet.EventID+journalExt)
+	buf, errs := encodeRecords(codec, []*outgoingPacket{p})
+	err := errs[0]
+	if err == nil {
+		err = writeQueueFile(path, aead, buf)
+	}
+	if err != nil {
+		debugf("raven: can't persist event %s: %v", packet.EventID, err)
+		return ""
+	}
+	return path
+}
+
+func (client *Client) readJournal(path string) (*outgoingPacket, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	client.mu.RLock()
+	aead := client.queueFileCipher
+	codec := client.queueCodec
+	client.mu.RUnlock()
+	if codec == nil {
+		codec = CanonicalCodec
+	}
+
+	if aead != nil {
+		if buf, err = openQueueFile(aead, buf); err != nil {
+			return nil, err
+		}
+	}
+	pending, err := decodeRecords(codec, buf)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) != 1 || pending[0].packet.EventID != strings.TrimSuffix(filepath.Base(path), journalExt) {
+		return nil, ErrQueueFileCorrupted
+	}
+	return pending[0], nil
+}
+
+// ack removes the file of a delivered event.
+func ack(path string) {
+	if path == "" {
+		return
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		debugf("raven: can't remove delivered event %s: %v", path, err)
+	}
+}
@@ -0,0 +1,100 @@
+package raven
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDurableDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, _ := newTestClient()
+	client.SetDSN("https://u@example.com/1")
+	client.Transport = &failingTransport{errors.New("connection refused")}
+	if err := client.SetDurableDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	eventID, ch := client.Capture(NewPacket("critical"), nil)
+	if err := <-ch; err == nil {
+		t.Fatal("expected the send to fail")
+	}
+	if _, err := os.Stat(filepath.Join(dir, eventID+journalExt)); err != nil {
+		t.Fatalf("expected the failed event to be kept: %v", err)
+	}
+
+	client, transport := newTestClient()
+	client.SetDSN("https://u@example.com/1")
+	if err := client.SetDurableDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	client.CaptureMessage("delivered", nil)
+	client.Wait()
+
+	if len(transport.packets) != 2 || transport.packets[0].EventID != eventID {
+		t.Fatalf("expected event %s to be redelivered, got %+v", eventID, transport.packets)
+	}
+	if names, _ := filepath.Glob(filepath.Join(dir, "*")); len(names) != 0 {
+		t.Errorf("expected delivered events to be removed, got %v", names)
+	}
+	if n, err := client.RedeliverPending(); n != 0 || err != nil {
+		t.Errorf("incorrect redelivery: got (%d, %v), want (0, <nil>)", n, err)
+	}
+}
+
+func TestDurableDirWithoutDSN(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, _ := newTestClient()
+	if err := client.SetDurableDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	client.CaptureMessage("undelivered", nil)
+	client.Wait()
+
+	if names, _ := filepath.Glob(filepath.Join(dir, "*"+journalExt)); len(names) != 1 {
+		t.Errorf("expected the event to be kept, got %v", names)
+	}
+}
+
+func TestDurableDirWithLazyStacktraces(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	client, _ := newTestClient()
+	client.SetDSN("https://u@example.com/1")
+	client.SetLazyStacktraces(true)
+	client.Transport = &failingTransport{errors.New("connection refused")}
+	if err := client.SetDurableDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	eventID, ch := client.Capture(client.newErrorPacket(0, errors.New("failed"), nil), nil)
+	<-ch
+
+	p, err := client.readJournal(filepath.Join(dir, eventID+journalExt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, inter := range p.packet.Interfaces {
+		if ex, ok := inter.(*Exception); ok {
+			if ex.Stacktrace == nil || len(ex.Stacktrace.Frames) == 0 {
+				t.Errorf("incorrect journaled stack trace: got %+v, want frames", ex.Stacktrace)
+			}
+			return
+		}
+	}
+	t.Errorf("expected an exception in the journaled event, got %+v", p.packet.Interfaces)
+}
@@ -0,0 +1,54 @@
+package raven
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+)
+
+type envelopeHeader struct {
+	EventID string `json:"event_id,omitempty"`
+	DSN     string `json:"dsn,omitempty"`
+	SentAt  string `json:"sent_at"`
+
+	// The dynamic sampling context of the trace a transaction belongs to.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+type envelopeItemHeader struct {
+	Type   string `json:"type"`
+	Length int    `json:"length"`
+}
+
+// serializeEnvelope wraps packet in a Sentry envelope, the format accepted by
+// the envelope endpoint and Relay.
+// https://develop.sentry.dev/sdk/envelopes/
+func serializeEnvelope(packet *Packet, dsn string) ([]byte, error) {
+	payload, err := packet.JSON()
+	if err != nil {
+		return nil, err
+	}
+	return newEnvelope(packet.EventID, dsn, "event", payload)
+}
+
+// newEnvelope builds an envelope holding a single item.
+func newEnvelope(eventID, dsn, itemType string, payload []byte) ([]byte, error) {
+	return newEnvelopeWithHeader(envelopeHeader{EventID: eventID, DSN: dsn}, itemType, payload)
+}
+
+// newEnvelopeWithHeader builds an envelope holding a single item, with header
+// as its header. The time it is sent at is set.
+func newEnvelopeWithHeader(header envelopeHeader, itemType string, payload []byte) ([]byte, error) {
+	header.SentAt = time.Now().UTC().Format(time.RFC3339)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if err := enc.Encode(header); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(envelopeItemHeader{itemType, len(payload)}); err != nil {
+		return nil, err
+	}
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,36 @@
+package raven
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSerializeEnvelope(t *testing.T) {
+	packet := NewPacket("failed")
+	packet.Init("1")
+
+	envelope, err := serializeEnvelope(packet, "https://public@sentry.example.com/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(envelope, []byte("\n")), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 lines, got %q", envelope)
+	}
+
+	var header envelopeHeader
+	json.Unmarshal(lines[0], &header)
+	if header.EventID != packet.EventID || header.DSN != "https://public@sentry.example.com/1" || header.SentAt == "" {
+		t.Errorf("incorrect envelope header: %s", lines[0])
+	}
+	var item envelopeItemHeader
+	json.Unmarshal(lines[1], &item)
+	if item.Type != "event" || item.Length != len(lines[2]) {
+		t.Errorf("incorrect item header: %s", lines[1])
+	}
+	if packetJSON, _ := packet.JSON(); !bytes.Equal(lines[2], packetJSON) {
+		t.Errorf("incorrect payload: got %s, want %s", lines[2], packetJSON)
+	}
+}
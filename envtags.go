@@ -0,0 +1,28 @@
+package raven
+
+import (
+	"os"
+	"strings"
+)
+
+// SetEnvTags tags every event with the values of the environment variables
+// names, e.g. REGION, POD_NAME or GIT_SHA, as read when SetEnvTags is called.
+// Tags are named after the lowercased variable names. Unset or empty
+// variables are skipped. Clients read the comma-separated names in
+// SENTRY_ENV_TAGS when they are created.
+func (client *Client) SetEnvTags(names []string) {
+	tags := make(map[string]string, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if value := os.Getenv(name); name != "" && value != "" {
+			tags[strings.ToLower(name)] = value
+		}
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.envTags = tags
+}
+
+// SetEnvTags tags the events of the default *Client with the values of the environment variables names
+func SetEnvTags(names ...string) { DefaultClientInstance().SetEnvTags(names) }
@@ -0,0 +1,43 @@
+package raven
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSetEnvTags(t *testing.T) {
+	os.Setenv("RAVEN_TEST_REGION", "eu-west-1")
+	os.Setenv("RAVEN_TEST_POD_NAME", "web-7f9c")
+	os.Setenv("RAVEN_TEST_EMPTY", "")
+	defer os.Unsetenv("RAVEN_TEST_REGION")
+	defer os.Unsetenv("RAVEN_TEST_POD_NAME")
+	defer os.Unsetenv("RAVEN_TEST_EMPTY")
+
+	client, transport := newTestClient()
+	client.SetEnvTags([]string{"RAVEN_TEST_REGION", "RAVEN_TEST_POD_NAME", "RAVEN_TEST_EMPTY", "RAVEN_TEST_UNSET"})
+	os.Setenv("RAVEN_TEST_REGION", "us-east-1")
+	client.CaptureMessage("failed", nil)
+	client.Wait()
+
+	tags := make(map[string]string)
+	for _, tag := range transport.packets[0].Tags {
+		tags[tag.Key] = tag.Value
+	}
+	expected := map[string]string{"raven_test_region": "eu-west-1", "raven_test_pod_name": "web-7f9c"}
+	if !reflect.DeepEqual(tags, expected) {
+		t.Errorf("incorrect tags: got %v, want %v", tags, expected)
+	}
+}
+
+func TestEnvTagsFromEnvironment(t *testing.T) {
+	os.Setenv("RAVEN_TEST_REGION", "eu-west-1")
+	os.Setenv("SENTRY_ENV_TAGS", "RAVEN_TEST_REGION, RAVEN_TEST_UNSET")
+	defer os.Unsetenv("RAVEN_TEST_REGION")
+	defer os.Unsetenv("SENTRY_ENV_TAGS")
+
+	client := newClient(nil)
+	if expected := map[string]string{"raven_test_region": "eu-west-1"}; !reflect.DeepEqual(client.envTags, expected) {
+		t.Errorf("incorrect tags: got %v, want %v", client.envTags, expected)
+	}
+}
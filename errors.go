@@ -1,9 +1,34 @@
 package raven
 
+import (
+	"crypto/x509"
+	"database/sql"
+	"database/sql/driver"
+	"net"
+	"os"
+	"syscall"
+)
+
 type causer interface {
 	Cause() error
 }
 
+type wrapper interface {
+	Unwrap() error
+}
+
+// unwrap returns the next error in the chain, following both
+// github.com/pkg/errors style causes and standard library wrapping.
+func unwrap(err error) error {
+	switch e := err.(type) {
+	case causer:
+		return e.Cause()
+	case wrapper:
+		return e.Unwrap()
+	}
+	return nil
+}
+
 type errWrappedWithExtra struct {
 	err       error
 	extraInfo map[string]interface{}
@@ -41,20 +66,71 @@ type ErrWithExtra interface {
 func extractExtra(err error) Extra {
 	extra := Extra{}
 
-	currentErr := err
-	for currentErr != nil {
+	for currentErr := err; currentErr != nil; currentErr = unwrap(currentErr) {
+		introspectError(currentErr, extra)
+
 		if errWithExtra, ok := currentErr.(ErrWithExtra); ok {
 			for k, v := range errWithExtra.ExtraInfo() {
 				extra[k] = v
 			}
 		}
+	}
+
+	return extra
+}
 
-		if errWithCause, ok := currentErr.(causer); ok {
-			currentErr = errWithCause.Cause()
-		} else {
-			currentErr = nil
+type sqlStater interface {
+	SQLState() string
+}
+
+// Copies the structured fields of well-known standard library error types
+// into extra, so that they can be searched on rather than parsed out of the
+// error message.
+func introspectError(err error, extra Extra) {
+	switch e := err.(type) {
+	case *net.OpError:
+		extra["net.OpError.Op"] = e.Op
+		extra["net.OpError.Net"] = e.Net
+		if e.Source != nil {
+			extra["net.OpError.Source"] = e.Source.String()
+		}
+		if e.Addr != nil {
+			extra["net.OpError.Addr"] = e.Addr.String()
 		}
+		extra["net.OpError.Timeout"] = e.Timeout()
+	case *net.DNSError:
+		extra["net.DNSError.Name"] = e.Name
+		extra["net.DNSError.Server"] = e.Server
+		extra["net.DNSError.IsTimeout"] = e.IsTimeout
+		extra["net.DNSError.IsNotFound"] = e.IsNotFound
+	case *os.PathError:
+		extra["os.PathError.Op"] = e.Op
+		extra["os.PathError.Path"] = e.Path
+	case *os.LinkError:
+		extra["os.LinkError.Op"] = e.Op
+		extra["os.LinkError.Old"] = e.Old
+		extra["os.LinkError.New"] = e.New
+	case *os.SyscallError:
+		extra["os.SyscallError.Syscall"] = e.Syscall
+	case syscall.Errno:
+		extra["syscall.Errno"] = uintptr(e)
+	case x509.HostnameError:
+		extra["x509.HostnameError.Host"] = e.Host
+	case x509.UnknownAuthorityError:
+		if e.Cert != nil {
+			extra["x509.UnknownAuthorityError.Issuer"] = e.Cert.Issuer.String()
+		}
+	case x509.CertificateInvalidError:
+		extra["x509.CertificateInvalidError.Reason"] = int(e.Reason)
+		if e.Detail != "" {
+			extra["x509.CertificateInvalidError.Detail"] = e.Detail
+		}
+	case sqlStater:
+		extra["sql.SQLState"] = e.SQLState()
 	}
 
-	return extra
+	switch err {
+	case sql.ErrNoRows, sql.ErrTxDone, sql.ErrConnDone, driver.ErrBadConn:
+		extra["sql.Error"] = err.Error()
+	}
 }
@@ -1,8 +1,12 @@
 package raven
 
 import (
+	"database/sql"
 	"fmt"
+	"net"
+	"os"
 	"reflect"
+	"syscall"
 	"testing"
 
 	pkgErrors "github.com/pkg/errors"
@@ -142,3 +146,52 @@ func TestExtractErrorPullsExtraData(t *testing.T) {
 		}
 	}
 }
+
+type testSQLError struct{}
+
+func (e *testSQLError) Error() string    { return "duplicate key" }
+func (e *testSQLError) SQLState() string { return "23505" }
+
+func TestExtractExtraIntrospectsKnownErrors(t *testing.T) {
+	addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 5432}
+
+	testCases := []struct {
+		Error    error
+		Expected map[string]interface{}
+	}{
+		{
+			Error: &net.OpError{Op: "dial", Net: "tcp", Addr: addr, Err: &os.SyscallError{Syscall: "connect", Err: syscall.ECONNREFUSED}},
+			Expected: map[string]interface{}{
+				"net.OpError.Op":          "dial",
+				"net.OpError.Net":         "tcp",
+				"net.OpError.Addr":        "127.0.0.1:5432",
+				"net.OpError.Timeout":     false,
+				"os.SyscallError.Syscall": "connect",
+				"syscall.Errno":           uintptr(syscall.ECONNREFUSED),
+			},
+		},
+		{
+			Error: WrapWithExtra(fmt.Errorf("loading config: %w", &os.PathError{Op: "open", Path: "/etc/app.conf", Err: syscall.ENOENT}), nil),
+			Expected: map[string]interface{}{
+				"os.PathError.Op":   "open",
+				"os.PathError.Path": "/etc/app.conf",
+				"syscall.Errno":     uintptr(syscall.ENOENT),
+			},
+		},
+		{
+			Error:    pkgErrors.Wrap(&testSQLError{}, "insert user"),
+			Expected: map[string]interface{}{"sql.SQLState": "23505"},
+		},
+		{
+			Error:    pkgErrors.Wrap(sql.ErrNoRows, "find user"),
+			Expected: map[string]interface{}{"sql.Error": sql.ErrNoRows.Error()},
+		},
+	}
+
+	for i, test := range testCases {
+		extracted := extractExtra(test.Error)
+		if !reflect.DeepEqual(map[string]interface{}(extracted), test.Expected) {
+			t.Errorf("Case [%d]: Got: %+v, expected: %+v", i, extracted, test.Expected)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+//go:build !raven_minimal
+// +build !raven_minimal
+
+package raven
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Iteratively collects the fields tagged `sentry:"tag"` of an error and its
+// underlying errors, so that custom error types carry their own tags:
+//
+//	type QuotaError struct {
+//		Tenant string `sentry:"tag"`
+//		Plan   string `sentry:"tag,billing.plan"`
+//	}
+//
+// Tags are named after the lowercased field name unless a name follows the
+// tag. Tags of outer errors take precedence. Fields must hold scalars or
+// fmt.Stringers, or pointers to them.
+func extractTags(err error) map[string]string {
+	var tags map[string]string
+
+	for currentErr := err; currentErr != nil; currentErr = unwrap(currentErr) {
+		v := reflect.ValueOf(currentErr)
+		for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			continue
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			name, ok := sentryTagName(field)
+			if !ok {
+				continue
+			}
+			value, ok := tagValue(v.Field(i))
+			if !ok {
+				continue
+			}
+			if tags == nil {
+				tags = make(map[string]string)
+			}
+			if _, ok := tags[name]; !ok {
+				tags[name] = value
+			}
+		}
+	}
+
+	return tags
+}
+
+// tagValue formats the value of a tagged field, looking through pointers and
+// interfaces. Only fmt.Stringers and scalars make for tags: other values, such
+// as structs or slices, and nil pointers are left out.
+func tagValue(value reflect.Value) (string, bool) {
+	for {
+		if (value.Kind() == reflect.Ptr || value.Kind() == reflect.Interface) && value.IsNil() {
+			return "", false
+		}
+		if value.CanInterface() {
+			if s, ok := value.Interface().(fmt.Stringer); ok {
+				return s.String(), true
+			}
+		}
+		if value.Kind() != reflect.Ptr && value.Kind() != reflect.Interface {
+			break
+		}
+		value = value.Elem()
+	}
+
+	switch value.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return fmt.Sprint(value), true
+	}
+	return "", false
+}
+
+// sentryTagName returns the tag name of a field tagged `sentry:"tag"`.
+func sentryTagName(field reflect.StructField) (string, bool) {
+	parts := strings.SplitN(field.Tag.Get("sentry"), ",", 2)
+	if parts[0] != "tag" {
+		return "", false
+	}
+	if len(parts) == 2 && parts[1] != "" {
+		return parts[1], true
+	}
+	return strings.ToLower(field.Name), true
+}
@@ -0,0 +1,10 @@
+//go:build raven_minimal
+// +build raven_minimal
+
+package raven
+
+// extractTags returns no tags: collecting the fields tagged `sentry:"tag"`
+// relies on reflection, which minimal builds leave out.
+func extractTags(err error) map[string]string {
+	return nil
+}
@@ -0,0 +1,101 @@
+//go:build !raven_minimal
+// +build !raven_minimal
+
+package raven
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	pkgErrors "github.com/pkg/errors"
+)
+
+type quotaError struct {
+	Tenant  string  `sentry:"tag"`
+	Plan    string  `sentry:"tag,billing.plan"`
+	Limit   int     `sentry:"tag"`
+	Owner   *string `sentry:"tag"`
+	Message string
+}
+
+type lookupError struct {
+	Key     *string       `sentry:"tag"`
+	Retries interface{}   `sentry:"tag"`
+	Timeout time.Duration `sentry:"tag"`
+	Since   *time.Time    `sentry:"tag"`
+	Shards  []int         `sentry:"tag"`
+	Quota   *quotaError   `sentry:"tag"`
+}
+
+func (e lookupError) Error() string { return "lookup failed" }
+
+func (e *quotaError) Error() string { return e.Message }
+
+type tenantError struct {
+	err    error
+	tenant string `sentry:"tag"`
+}
+
+func (e tenantError) Error() string { return e.err.Error() }
+func (e tenantError) Unwrap() error { return e.err }
+
+func TestExtractTags(t *testing.T) {
+	err := pkgErrors.Wrap(&quotaError{Tenant: "acme", Plan: "free", Limit: 10, Message: "over quota"}, "upload")
+	expected := map[string]string{"tenant": "acme", "billing.plan": "free", "limit": "10"}
+	if actual := extractTags(err); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("incorrect tags: got %v, want %v", actual, expected)
+	}
+
+	err = tenantError{err, "globex"}
+	expected["tenant"] = "globex"
+	if actual := extractTags(err); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("incorrect tags: got %v, want %v", actual, expected)
+	}
+
+	if actual := extractTags(fmt.Errorf("plain")); actual != nil {
+		t.Errorf("incorrect tags: got %v, want none", actual)
+	}
+}
+
+func TestExtractTagsThroughPointers(t *testing.T) {
+	key, retries := "users/42", 3
+	since := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	err := lookupError{
+		Key:     &key,
+		Retries: &retries,
+		Timeout: time.Second,
+		Since:   &since,
+		Shards:  []int{1, 2},
+		Quota:   &quotaError{Tenant: "acme"},
+	}
+	expected := map[string]string{
+		"key":     "users/42",
+		"retries": "3",
+		"timeout": "1s",
+		"since":   since.String(),
+	}
+	if actual := extractTags(err); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("incorrect tags: got %v, want %v", actual, expected)
+	}
+}
+
+func TestCaptureErrorLiftsTags(t *testing.T) {
+	client, transport := newTestClient()
+	client.CaptureError(&quotaError{Tenant: "acme", Message: "over quota"}, nil)
+	client.Wait()
+
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+	var tenant string
+	for _, tag := range transport.packets[0].Tags {
+		if tag.Key == "tenant" {
+			tenant = tag.Value
+		}
+	}
+	if tenant != "acme" {
+		t.Errorf("incorrect tenant tag: got %q, want %q", tenant, "acme")
+	}
+}
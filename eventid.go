@@ -0,0 +1,113 @@
+package raven
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"time"
+)
+
+// An IDGenerator returns the ID of a packet, 32 lowercase hexadecimal
+// characters. It is called before the packet is initialized, so the packet
+// may have no timestamp yet.
+type IDGenerator func(packet *Packet) (string, error)
+
+// SetIDGenerator makes the client use gen for the IDs of events that have
+// none, instead of random UUIDs. IDs that gen fails to generate, or that are
+// not 32 lowercase hexadecimal characters, are replaced by random UUIDs.
+//
+// Example:
+//
+//	client.SetIDGenerator(raven.ULIDGenerator)
+func (client *Client) SetIDGenerator(gen IDGenerator) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.idGenerator = gen
+}
+
+// SetIDGenerator sets the event ID generator of the default *Client
+func SetIDGenerator(gen IDGenerator) { DefaultClientInstance().SetIDGenerator(gen) }
+
+// generateEventID sets the ID of packet with gen, unless it already has one.
+func generateEventID(packet *Packet, gen IDGenerator) {
+	if packet.EventID != "" || gen == nil {
+		return
+	}
+	id, err := gen(packet)
+	if err != nil {
+		debugf("raven: failed to generate an event ID: %v", err)
+		return
+	}
+	if !validEventID(id) {
+		debugf("raven: invalid generated event ID %q", id)
+		return
+	}
+	packet.EventID = id
+}
+
+// validEventID reports whether id is 32 lowercase hexadecimal characters, as
+// Sentry expects.
+func validEventID(id string) bool {
+	if len(id) != 32 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// ULIDGenerator is an IDGenerator returning ULIDs, hex-encoded: a millisecond
+// timestamp followed by random bits, so that IDs sort by the time of their
+// event, e.g. in archives of sent events.
+func ULIDGenerator(packet *Packet) (string, error) {
+	t := time.Time(packet.Timestamp)
+	if t.IsZero() {
+		t = time.Now()
+	}
+	var id [16]byte
+	binary.BigEndian.PutUint64(id[:8], uint64(t.UnixNano()/int64(time.Millisecond))<<16)
+	if _, err := io.ReadFull(rand.Reader, id[6:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id[:]), nil
+}
+
+// TraceIDGenerator is an IDGenerator aligning event IDs with the trace of the
+// packet, set with SetSpan: the first half of the ID is the first half of the
+// trace ID, and the rest is random, so that the events of a trace share a
+// prefix. Packets without a trace get random UUIDs.
+func TraceIDGenerator(packet *Packet) (string, error) {
+	trace, _ := packet.Contexts["trace"].(map[string]interface{})
+	traceID, _ := trace["trace_id"].(string)
+	if !validEventID(traceID) {
+		return uuid()
+	}
+	random := make([]byte, 8)
+	if _, err := io.ReadFull(rand.Reader, random); err != nil {
+		return "", err
+	}
+	return traceID[:16] + hex.EncodeToString(random), nil
+}
+
+// SetSpan sets the trace context of packet to span, linking the event to the
+// trace in Sentry.
+func (packet *Packet) SetSpan(span *Span) {
+	trace := map[string]interface{}{
+		"trace_id": span.TraceID,
+		"span_id":  span.SpanID,
+	}
+	if span.ParentSpanID != "" {
+		trace["parent_span_id"] = span.ParentSpanID
+	}
+	if span.Op != "" {
+		trace["op"] = span.Op
+	}
+	if packet.Contexts == nil {
+		packet.Contexts = make(map[string]interface{})
+	}
+	packet.Contexts["trace"] = trace
+}
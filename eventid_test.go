@@ -0,0 +1,103 @@
+package raven
+
+import (
+	gocontext "context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestValidEventID(t *testing.T) {
+	cases := []struct {
+		id    string
+		valid bool
+	}{
+		{"0123456789abcdef0123456789abcdef", true},
+		{"0123456789ABCDEF0123456789ABCDEF", false},
+		{"0123456789abcdef", false},
+		{"01234567-89ab-cdef-0123-456789abcdef", false},
+		{"0123456789abcdef0123456789abcdeg", false},
+	}
+	for _, test := range cases {
+		if valid := validEventID(test.id); valid != test.valid {
+			t.Errorf("incorrect validity of %q: got %t, want %t", test.id, valid, test.valid)
+		}
+	}
+}
+
+func TestULIDGenerator(t *testing.T) {
+	at := func(t time.Time) *Packet {
+		packet := NewPacket("failed")
+		packet.Timestamp = Timestamp(t)
+		return packet
+	}
+	now := time.Now()
+	earlier, _ := ULIDGenerator(at(now.Add(-time.Second)))
+	later, _ := ULIDGenerator(at(now))
+	again, _ := ULIDGenerator(at(now))
+	if !validEventID(earlier) || !validEventID(later) {
+		t.Fatalf("invalid IDs: %q, %q", earlier, later)
+	}
+	if earlier >= later {
+		t.Errorf("IDs not sorted by time: %q >= %q", earlier, later)
+	}
+	if later == again || later[:12] != again[:12] {
+		t.Errorf("incorrect IDs at the same time: %q, %q", later, again)
+	}
+}
+
+func TestTraceIDGenerator(t *testing.T) {
+	span, _ := StartSpan(gocontext.Background(), "http.server", "GET /")
+	packet := NewPacket("failed")
+	packet.SetSpan(span)
+	id, err := TraceIDGenerator(packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !validEventID(id) || id[:16] != span.TraceID[:16] {
+		t.Errorf("incorrect ID: got %q, want prefix %q", id, span.TraceID[:16])
+	}
+	if trace := packet.Contexts["trace"].(map[string]interface{}); trace["span_id"] != span.SpanID || trace["op"] != "http.server" {
+		t.Errorf("incorrect trace context: %+v", trace)
+	}
+
+	if id, err := TraceIDGenerator(NewPacket("untraced")); err != nil || !validEventID(id) {
+		t.Errorf("incorrect ID without trace: %q, %v", id, err)
+	}
+}
+
+func TestSetIDGenerator(t *testing.T) {
+	client, transport := newTestClient()
+	ids := []string{"0123456789abcdef0123456789abcdef", "not an ID", ""}
+	client.SetIDGenerator(func(packet *Packet) (string, error) {
+		id := ids[0]
+		ids = ids[1:]
+		if id == "" {
+			return "", errors.New("exhausted")
+		}
+		return id, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		client.CaptureMessage("failed", nil)
+	}
+	explicit := NewPacket("explicit")
+	explicit.EventID = "fedcba9876543210fedcba9876543210"
+	client.Capture(explicit, nil)
+	client.Wait()
+
+	if len(transport.packets) != 4 {
+		t.Fatalf("incorrect number of packets sent: got %d, want 4", len(transport.packets))
+	}
+	if id := transport.packets[0].EventID; id != "0123456789abcdef0123456789abcdef" {
+		t.Errorf("incorrect generated ID: %q", id)
+	}
+	for _, packet := range transport.packets[1:3] {
+		if !validEventID(packet.EventID) {
+			t.Errorf("invalid fallback ID: %q", packet.EventID)
+		}
+	}
+	if id := transport.packets[3].EventID; id != "fedcba9876543210fedcba9876543210" {
+		t.Errorf("explicit ID overridden: %q", id)
+	}
+}
@@ -2,21 +2,22 @@ package raven
 
 import (
 	"reflect"
-	"regexp"
 )
 
-var errorMsgPattern = regexp.MustCompile(`\A(\w+): (.+)\z`)
-
+// NewException builds an exception from err. The concrete Go type of err is
+// reported as the exception type and its package path as the module, so that
+// errors group by type rather than by message.
 func NewException(err error, stacktrace *Stacktrace) *Exception {
-	msg := err.Error()
+	typ := reflect.TypeOf(err)
 	ex := &Exception{
 		Stacktrace: stacktrace,
-		Value:      msg,
-		Type:       reflect.TypeOf(err).String(),
+		Value:      err.Error(),
+		Type:       typ.String(),
 	}
-	if m := errorMsgPattern.FindStringSubmatch(msg); m != nil {
-		ex.Module, ex.Value = m[1], m[2]
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
 	}
+	ex.Module = typ.PkgPath()
 	return ex
 }
 
@@ -48,3 +49,13 @@ type Exceptions struct {
 }
 
 func (es Exceptions) Class() string { return "exception" }
+
+// Culprit returns the culprit of the last exception of the chain that has one.
+func (es Exceptions) Culprit() string {
+	for i := len(es.Values) - 1; i >= 0; i-- {
+		if culprit := es.Values[i].Culprit(); culprit != "" {
+			return culprit
+		}
+	}
+	return ""
+}
@@ -3,6 +3,8 @@ package raven
 import (
 	"encoding/json"
 	"errors"
+	"net"
+	"syscall"
 	"testing"
 )
 
@@ -10,8 +12,11 @@ var newExceptionTests = []struct {
 	err error
 	Exception
 }{
-	{errors.New("foobar"), Exception{Value: "foobar", Type: "*errors.errorString"}},
-	{errors.New("bar: foobar"), Exception{Value: "foobar", Type: "*errors.errorString", Module: "bar"}},
+	{errors.New("foobar"), Exception{Value: "foobar", Type: "*errors.errorString", Module: "errors"}},
+	{errors.New("bar: foobar"), Exception{Value: "bar: foobar", Type: "*errors.errorString", Module: "errors"}},
+	{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("refused")}, Exception{Value: "dial tcp: refused", Type: "*net.OpError", Module: "net"}},
+	{&validationError{"email"}, Exception{Value: "invalid email", Type: "*raven.validationError", Module: "github.com/getsentry/raven-go"}},
+	{syscall.ENOENT, Exception{Value: "no such file or directory", Type: "syscall.Errno", Module: "syscall"}},
 }
 
 func TestNewException(t *testing.T) {
@@ -30,7 +35,7 @@ func TestNewException(t *testing.T) {
 }
 
 func TestNewException_JSON(t *testing.T) {
-	expected := `{"value":"foobar","type":"*errors.errorString"}`
+	expected := `{"value":"foobar","type":"*errors.errorString","module":"errors"}`
 	e := NewException(errors.New("foobar"), nil)
 	b, _ := json.Marshal(e)
 	if string(b) != expected {
@@ -0,0 +1,25 @@
+package raven
+
+import "io"
+
+// SetFallbackWriter makes the client write events to w, one JSON document per
+// line, when no DSN is set, instead of silently discarding them. It lets
+// developers see the full details of errors, stacktraces included, without a
+// Sentry project, e.g. with raven.SetFallbackWriter(os.Stderr).
+func (client *Client) SetFallbackWriter(w io.Writer) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.fallback = w
+}
+
+// SetFallbackWriter sets the fallback writer of the default *Client
+func SetFallbackWriter(w io.Writer) { DefaultClientInstance().SetFallbackWriter(w) }
+
+func writeFallback(w io.Writer, packet *Packet) error {
+	packetJSON, err := packet.JSON()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(packetJSON, '\n'))
+	return err
+}
@@ -0,0 +1,35 @@
+package raven
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestFallbackWriter(t *testing.T) {
+	client, transport := newTestClient()
+	var buf bytes.Buffer
+	client.SetFallbackWriter(&buf)
+
+	client.CaptureError(errors.New("failed"), nil)
+	client.Wait()
+
+	if len(transport.packets) != 0 {
+		t.Errorf("expected the transport not to be used, got %d events", len(transport.packets))
+	}
+	var event map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &event); err != nil {
+		t.Fatalf("incorrect output %q: %v", buf.String(), err)
+	}
+	if event["message"] != "failed" || event["exception"] == nil {
+		t.Errorf("incorrect event: %+v", event)
+	}
+
+	client.SetDSN("https://public@sentry.example.com/1")
+	client.CaptureError(errors.New("failed"), nil)
+	client.Wait()
+	if len(transport.packets) != 1 {
+		t.Errorf("expected the transport to be used once a DSN is set, got %d events", len(transport.packets))
+	}
+}
@@ -0,0 +1,182 @@
+package raven
+
+import (
+	gocontext "context"
+	"net/http"
+	"reflect"
+)
+
+// ContextErrorFilter returns an EventProcessor for events whose error chain
+// contains context.Canceled or context.DeadlineExceeded, which are usually the
+// result of a client going away rather than a bug. Such events are downgraded
+// to level, or dropped when level is empty. When loggers are given, only
+// events from those loggers are affected.
+//
+// Example:
+//
+//	client.AddEventProcessor(raven.ContextErrorFilter("", "http"))
+//	client.AddEventProcessor(raven.ContextErrorFilter(raven.WARNING, "jobs"))
+func ContextErrorFilter(level Severity, loggers ...string) EventProcessor {
+	return func(packet *Packet, err error) bool {
+		if !isContextError(err) {
+			return true
+		}
+		if len(loggers) > 0 && !containsString(loggers, packet.Logger) {
+			return true
+		}
+		if level == "" {
+			return false
+		}
+		packet.Level = level
+		return true
+	}
+}
+
+func isContextError(err error) bool {
+	for ; err != nil; err = unwrap(err) {
+		if err == gocontext.Canceled || err == gocontext.DeadlineExceeded {
+			return true
+		}
+	}
+	return false
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// hasErrorType reports whether err or any error it wraps is of one of types,
+// or implements it for interface types.
+func hasErrorType(err error, types []reflect.Type) bool {
+	if len(types) == 0 {
+		return false
+	}
+	for ; err != nil; err = unwrap(err) {
+		errType := reflect.TypeOf(err)
+		for _, t := range types {
+			if errType == t || t.Kind() == reflect.Interface && errType.Implements(t) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// An ErrorClassifier decides whether err is transient, that is whether the
+// operation that failed is worth retrying. ok is false when it can't tell.
+type ErrorClassifier func(err error) (retryable, ok bool)
+
+type temporary interface {
+	Temporary() bool
+}
+
+type timeout interface {
+	Timeout() bool
+}
+
+type statusCoder interface {
+	StatusCode() int
+}
+
+// ClassifyError is the default ErrorClassifier. It walks the error chain
+// looking for Temporary() and Timeout() methods, gRPC status codes and HTTP
+// status codes.
+func ClassifyError(err error) (retryable, ok bool) {
+	for ; err != nil; err = unwrap(err) {
+		if e, isTimeout := err.(timeout); isTimeout && e.Timeout() {
+			return true, true
+		}
+		if e, isTemporary := err.(temporary); isTemporary {
+			return e.Temporary(), true
+		}
+		if e, isStatusCoder := err.(statusCoder); isStatusCoder {
+			code := e.StatusCode()
+			return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests ||
+				(code >= 500 && code != http.StatusNotImplemented), true
+		}
+		if code, isGRPC := grpcCode(err); isGRPC {
+			return retryableGRPCCodes[code], true
+		}
+	}
+	return false, false
+}
+
+// gRPC codes worth retrying: DeadlineExceeded, ResourceExhausted, Aborted and
+// Unavailable. https://grpc.github.io/grpc/core/md_doc_statuscodes.html
+var retryableGRPCCodes = map[uint64]bool{4: true, 8: true, 10: true, 14: true}
+
+// grpcCode extracts the status code of an error created by the
+// google.golang.org/grpc/status package without depending on it.
+func grpcCode(err error) (uint64, bool) {
+	v := reflect.ValueOf(err)
+	if isNilValue(v) {
+		return 0, false
+	}
+	method := v.MethodByName("GRPCStatus")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return 0, false
+	}
+	status := method.Call(nil)[0]
+	if isNilValue(status) {
+		return 0, false
+	}
+	code := status.MethodByName("Code")
+	if !code.IsValid() || code.Type().NumIn() != 0 || code.Type().NumOut() != 1 {
+		return 0, false
+	}
+	switch c := code.Call(nil)[0]; c.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return c.Uint(), true
+	}
+	return 0, false
+}
+
+// isNilValue reports whether v is a nil pointer or interface, whose methods
+// can't be called safely.
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// RetryableClassifier returns an EventProcessor that tags events with
+// "retryable" when their error can be classified, replacing any such tag, and
+// downgrades events at the ERROR level to level when the error is retryable.
+// classifiers are consulted in order before falling back to ClassifyError.
+//
+// Example:
+//
+//	client.AddEventProcessor(raven.RetryableClassifier(raven.WARNING))
+func RetryableClassifier(level Severity, classifiers ...ErrorClassifier) EventProcessor {
+	classifiers = append(classifiers, ClassifyError)
+	return func(packet *Packet, err error) bool {
+		if err == nil {
+			return true
+		}
+		for _, classify := range classifiers {
+			retryable, ok := classify(err)
+			if !ok {
+				continue
+			}
+			if retryable {
+				packet.setTag("retryable", "true")
+				if level != "" && packet.Level == ERROR {
+					packet.Level = level
+				}
+			} else {
+				packet.setTag("retryable", "false")
+			}
+			break
+		}
+		return true
+	}
+}
@@ -0,0 +1,170 @@
+package raven
+
+import (
+	gocontext "context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	pkgErrors "github.com/pkg/errors"
+)
+
+func TestContextErrorFilter(t *testing.T) {
+	tests := []struct {
+		filter   EventProcessor
+		logger   string
+		err      error
+		keep     bool
+		expected Severity
+	}{
+		{ContextErrorFilter(""), "root", errors.New("failed"), true, ERROR},
+		{ContextErrorFilter(""), "root", gocontext.Canceled, false, ERROR},
+		{ContextErrorFilter(""), "root", pkgErrors.Wrap(gocontext.DeadlineExceeded, "query"), false, ERROR},
+		{ContextErrorFilter(WARNING), "root", gocontext.Canceled, true, WARNING},
+		{ContextErrorFilter("", "http"), "jobs", gocontext.Canceled, true, ERROR},
+		{ContextErrorFilter("", "http"), "http", gocontext.Canceled, false, ERROR},
+	}
+
+	for i, test := range tests {
+		packet := &Packet{Logger: test.logger, Level: ERROR}
+		if keep := test.filter(packet, test.err); keep != test.keep {
+			t.Errorf("%d: incorrect decision: got %v, want %v", i, keep, test.keep)
+		}
+		if packet.Level != test.expected {
+			t.Errorf("%d: incorrect Level: got %s, want %s", i, packet.Level, test.expected)
+		}
+	}
+}
+
+func TestEventProcessorDropsPacket(t *testing.T) {
+	client, transport := newTestClient()
+	client.AddEventProcessor(ContextErrorFilter(""))
+
+	if eventID := client.CaptureError(pkgErrors.Wrap(gocontext.Canceled, "request"), nil); eventID != "" {
+		t.Errorf("expected the event to be dropped, got %s", eventID)
+	}
+	if eventID := client.CaptureError(errors.New("failed"), nil); eventID == "" {
+		t.Error("expected the event to be captured")
+	}
+	client.Wait()
+
+	if len(transport.packets) != 1 {
+		t.Errorf("expected 1 event to be sent, got %d", len(transport.packets))
+	}
+}
+
+type testTimeoutError struct{}
+
+func (e *testTimeoutError) Error() string   { return "i/o timeout" }
+func (e *testTimeoutError) Timeout() bool   { return true }
+func (e *testTimeoutError) Temporary() bool { return true }
+
+type testGRPCStatus struct{ code uint32 }
+
+func (s *testGRPCStatus) Code() uint32 { return s.code }
+
+type testGRPCError struct{ code uint32 }
+
+func (e *testGRPCError) Error() string               { return "rpc error" }
+func (e *testGRPCError) GRPCStatus() *testGRPCStatus { return &testGRPCStatus{e.code} }
+
+// testNilGRPCError has no status, as for gRPC errors with the OK code.
+type testNilGRPCError struct{}
+
+func (e testNilGRPCError) Error() string               { return "rpc error" }
+func (e testNilGRPCError) GRPCStatus() *testGRPCStatus { return nil }
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		err           error
+		retryable, ok bool
+	}{
+		{errors.New("failed"), false, false},
+		{pkgErrors.Wrap(&testTimeoutError{}, "read"), true, true},
+		{&statusError{"", 503}, true, true},
+		{&statusError{"", 429}, true, true},
+		{&statusError{"", 400}, false, true},
+		{&statusError{"", 501}, false, true},
+		{&testGRPCError{14}, true, true},
+		{&testGRPCError{3}, false, true},
+		{(*testGRPCError)(nil), false, false},
+		{testNilGRPCError{}, false, false},
+	}
+
+	for i, test := range tests {
+		retryable, ok := ClassifyError(test.err)
+		if retryable != test.retryable || ok != test.ok {
+			t.Errorf("%d: incorrect classification: got (%v, %v), want (%v, %v)", i, retryable, ok, test.retryable, test.ok)
+		}
+	}
+}
+
+func TestRetryableClassifier(t *testing.T) {
+	notFound := errors.New("not found")
+	classifier := RetryableClassifier(WARNING, func(err error) (bool, bool) {
+		return false, err == notFound
+	})
+
+	packet := &Packet{Level: ERROR}
+	classifier(packet, &statusError{"", 503})
+	if packet.Level != WARNING || !reflect.DeepEqual(packet.Tags, Tags{{"retryable", "true"}}) {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+
+	packet = &Packet{Level: ERROR}
+	classifier(packet, notFound)
+	if packet.Level != ERROR || !reflect.DeepEqual(packet.Tags, Tags{{"retryable", "false"}}) {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+
+	packet = &Packet{Level: ERROR, Tags: Tags{{"retryable", "true"}, {"job", "sync"}}}
+	classifier(packet, notFound)
+	if !reflect.DeepEqual(packet.Tags, Tags{{"retryable", "false"}, {"job", "sync"}}) {
+		t.Errorf("incorrect tags: %+v", packet.Tags)
+	}
+
+	packet = &Packet{Level: ERROR}
+	classifier(packet, errors.New("failed"))
+	if packet.Level != ERROR || len(packet.Tags) != 0 {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+}
+
+type validationError struct{ field string }
+
+func (e *validationError) Error() string { return "invalid " + e.field }
+
+func TestSetIgnoreErrorTypes(t *testing.T) {
+	client, transport := newTestClient()
+	err := client.SetIgnoreErrorTypes([]reflect.Type{
+		reflect.TypeOf((*validationError)(nil)),
+		reflect.TypeOf((*timeout)(nil)).Elem(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ignored := []error{
+		&validationError{"email"},
+		pkgErrors.Wrap(&validationError{"name"}, "signup"),
+		fmt.Errorf("dial: %w", &testTimeoutError{}),
+	}
+	for _, err := range ignored {
+		if eventID := client.CaptureError(err, nil); eventID != "" {
+			t.Errorf("expected %q to be ignored", err)
+		}
+	}
+	if eventID := client.CaptureError(errors.New("invalid email"), nil); eventID == "" {
+		t.Error("expected the event to be captured")
+	}
+	client.Wait()
+
+	if len(transport.packets) != 1 {
+		t.Errorf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+
+	if err := client.SetIgnoreErrorTypes([]reflect.Type{reflect.TypeOf("")}); err == nil || err.Error() != "raven: string is not an error type" {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package raven
+
+import "sync"
+
+// A Flag is the result of a feature flag evaluation.
+type Flag struct {
+	Flag   string `json:"flag"`
+	Result bool   `json:"result"`
+}
+
+// A FlagProvider reports the feature flags evaluated so far, e.g. by wrapping
+// a LaunchDarkly client or an OpenFeature hook.
+type FlagProvider interface {
+	EvaluatedFlags() []Flag
+}
+
+// FlagsProcessor returns an EventProcessor that snapshots the flags evaluated
+// by provider into the "flags" context of each event, where they show up in
+// Sentry's feature flag debugging UI.
+func FlagsProcessor(provider FlagProvider) EventProcessor {
+	return func(packet *Packet, err error) bool {
+		if flags := provider.EvaluatedFlags(); len(flags) > 0 {
+			packet.AddContexts(map[string]interface{}{
+				"flags": map[string][]Flag{"values": flags},
+			})
+		}
+		return true
+	}
+}
+
+// FlagBuffer is a FlagProvider remembering the most recent evaluation of up to
+// size flags. Integrations call Record each time a flag is evaluated.
+type FlagBuffer struct {
+	size int
+
+	mu    sync.Mutex
+	flags []Flag
+}
+
+// The size of a FlagBuffer created with a size that isn't positive.
+const defaultFlagBufferSize = 100
+
+// NewFlagBuffer creates a FlagBuffer holding at most size flags, or 100 if
+// size isn't positive.
+func NewFlagBuffer(size int) *FlagBuffer {
+	if size <= 0 {
+		size = defaultFlagBufferSize
+	}
+	return &FlagBuffer{size: size}
+}
+
+// Record stores the result of evaluating flag, evicting the least recently
+// evaluated flag when the buffer is full.
+func (b *FlagBuffer) Record(flag string, result bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for i, f := range b.flags {
+		if f.Flag == flag {
+			b.flags = append(b.flags[:i], b.flags[i+1:]...)
+			break
+		}
+	}
+	if len(b.flags) >= b.size {
+		b.flags = b.flags[1:]
+	}
+	b.flags = append(b.flags, Flag{flag, result})
+}
+
+// EvaluatedFlags returns a copy of the recorded flags, oldest first.
+func (b *FlagBuffer) EvaluatedFlags() []Flag {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Flag(nil), b.flags...)
+}
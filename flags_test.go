@@ -0,0 +1,53 @@
+package raven
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestFlagBuffer(t *testing.T) {
+	buffer := NewFlagBuffer(2)
+	buffer.Record("a", true)
+	buffer.Record("b", false)
+	buffer.Record("a", false)
+	buffer.Record("c", true)
+
+	expected := []Flag{{"a", false}, {"c", true}}
+	if actual := buffer.EvaluatedFlags(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("incorrect flags: got %+v, want %+v", actual, expected)
+	}
+}
+
+func TestFlagBufferDefaultSize(t *testing.T) {
+	buffer := NewFlagBuffer(0)
+	for i := 0; i <= defaultFlagBufferSize; i++ {
+		buffer.Record(fmt.Sprint(i), true)
+	}
+
+	flags := buffer.EvaluatedFlags()
+	if len(flags) != defaultFlagBufferSize || flags[0].Flag != "1" {
+		t.Errorf("incorrect flags: got %d starting with %+v, want %d", len(flags), flags[0], defaultFlagBufferSize)
+	}
+}
+
+func TestFlagsProcessor(t *testing.T) {
+	buffer := NewFlagBuffer(10)
+	processor := FlagsProcessor(buffer)
+
+	packet := &Packet{}
+	processor(packet, nil)
+	if packet.Contexts != nil {
+		t.Errorf("expected no flags context, got %+v", packet.Contexts)
+	}
+
+	buffer.Record("new-checkout", true)
+	processor(packet, nil)
+
+	expected := map[string]interface{}{
+		"flags": map[string][]Flag{"values": {{"new-checkout", true}}},
+	}
+	if !reflect.DeepEqual(packet.Contexts, expected) {
+		t.Errorf("incorrect Contexts: got %+v, want %+v", packet.Contexts, expected)
+	}
+}
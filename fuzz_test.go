@@ -0,0 +1,100 @@
+//go:build go1.18
+// +build go1.18
+
+package raven
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func FuzzParseDSN(f *testing.F) {
+	for _, dsn := range []string{
+		"https://u:p@example.com/sentry/1",
+		"http://u@example.com:9000/2",
+		"https://u@example.com/",
+		"://",
+		"",
+	} {
+		f.Add(dsn, false)
+	}
+
+	f.Fuzz(func(t *testing.T, dsn string, strict bool) {
+		_, parseErr := ParseDSN(dsn, strict)
+
+		// An empty DSN disables the client
+		if dsn == "" {
+			return
+		}
+		client := newClient(nil)
+		client.SetStrictDSN(strict)
+		if err := client.SetDSN(dsn); (err == nil) != (parseErr == nil) {
+			t.Errorf("SetDSN and ParseDSN disagree on %q: %v, %v", dsn, err, parseErr)
+		}
+	})
+}
+
+func FuzzUnmarshalTags(f *testing.F) {
+	for _, data := range []string{
+		`{"foo":"bar"}`,
+		`[["foo","bar"],["bar","baz"]]`,
+		`[]`,
+		`null`,
+		``,
+		`[`,
+	} {
+		f.Add([]byte(data))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var tags Tags
+		if err := tags.UnmarshalJSON(data); err != nil {
+			return
+		}
+		tagsJSON, err := json.Marshal(tags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Tags
+		if err := json.Unmarshal(tagsJSON, &decoded); err != nil {
+			t.Fatalf("unable to decode %s: %v", tagsJSON, err)
+		}
+		if !reflect.DeepEqual(decoded, tags) {
+			t.Errorf("incorrect Tags after round trip: got %+v, want %+v", decoded, tags)
+		}
+	})
+}
+
+func FuzzPacketJSON(f *testing.F) {
+	f.Add("message", "key", "value", `{"extra":1}`)
+	f.Add("", "", "", ``)
+	f.Add("\x00<&> ", "\xff", "\"", `[1,"a",null]`)
+
+	f.Fuzz(func(t *testing.T, message, key, value, extra string) {
+		packet := NewPacket(message, &Message{message, nil})
+		packet.Tags = Tags{{key, value}}
+		var extraValue interface{}
+		if json.Unmarshal([]byte(extra), &extraValue) == nil {
+			packet.Extra[key] = extraValue
+		}
+		if err := packet.Init("1"); err != nil {
+			t.Fatal(err)
+		}
+
+		packetJSON, err := packet.JSON()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !json.Valid(packetJSON) {
+			t.Fatalf("invalid JSON: %s", packetJSON)
+		}
+		decoded := &Packet{}
+		if err := decoded.UnmarshalCanonical(packetJSON); err != nil {
+			t.Fatalf("unable to decode %s: %v", packetJSON, err)
+		}
+		if len(decoded.Interfaces) != 1 || decoded.Interfaces[0].Class() != "logentry" {
+			t.Errorf("incorrect Interfaces: %+v", decoded.Interfaces)
+		}
+	})
+}
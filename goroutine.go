@@ -0,0 +1,56 @@
+package raven
+
+import "fmt"
+
+// PanicError is returned by functions wrapped with Recover when they panic.
+type PanicError struct {
+	// The value passed to panic.
+	Value interface{}
+
+	// The ID of the event reporting the panic, empty if it was not captured.
+	EventID string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Recover wraps f so that a panic is reported to Sentry and returned as a
+// *PanicError instead of crashing the process. The result can be passed
+// directly to errgroup.Group.Go or submitted to a worker pool.
+func (client *Client) Recover(f func() error) func() error {
+	return func() (err error) {
+		rval, eventID := client.CapturePanic(func() { err = f() }, nil)
+		if rval != nil {
+			err = &PanicError{Value: rval, EventID: eventID}
+		}
+		return err
+	}
+}
+
+// Recover wraps f so that a panic is reported to Sentry with the default
+// *Client and returned as a *PanicError.
+func Recover(f func() error) func() error {
+	return DefaultClientInstance().Recover(f)
+}
+
+// Go runs f in a new goroutine, reporting both a panic and a non-nil returned
+// error to Sentry. The returned channel receives the result of f, or a
+// *PanicError, once the goroutine finishes.
+func (client *Client) Go(f func() error) <-chan error {
+	ch := make(chan error, 1)
+	go func() {
+		err := client.Recover(f)()
+		if _, ok := err.(*PanicError); err != nil && !ok {
+			client.CaptureError(err, nil)
+		}
+		ch <- err
+	}()
+	return ch
+}
+
+// Go runs f in a new goroutine, reporting panics and errors with the default
+// *Client.
+func Go(f func() error) <-chan error {
+	return DefaultClientInstance().Go(f)
+}
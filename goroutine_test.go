@@ -0,0 +1,48 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	client, transport := newTestClient()
+
+	err := client.Recover(func() error { panic("boom") })()
+	client.Wait()
+
+	perr, ok := err.(*PanicError)
+	if !ok {
+		t.Fatalf("expected a *PanicError, got %#v", err)
+	}
+	if perr.Value != "boom" || perr.EventID == "" {
+		t.Errorf("incorrect PanicError: %+v", perr)
+	}
+	if len(transport.packets) != 1 || transport.packets[0].Message != "boom" {
+		t.Errorf("expected the panic to be reported, got %+v", transport.packets)
+	}
+
+	expected := errors.New("failed")
+	if err := client.Recover(func() error { return expected })(); err != expected {
+		t.Errorf("incorrect error: got %v, want %v", err, expected)
+	}
+}
+
+func TestGo(t *testing.T) {
+	client, transport := newTestClient()
+
+	if err := <-client.Go(func() error { panic("boom") }); err == nil {
+		t.Error("expected the panic to be returned")
+	}
+	if err := <-client.Go(func() error { return errors.New("failed") }); err == nil {
+		t.Error("expected the error to be returned")
+	}
+	if err := <-client.Go(func() error { return nil }); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	client.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.packets))
+	}
+}
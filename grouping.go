@@ -0,0 +1,123 @@
+package raven
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"path"
+	"strings"
+)
+
+// Context lines longer than this are ignored for grouping, as the server does,
+// since they are usually minified or generated code.
+const maxGroupingContextLine = 120
+
+// GroupingHash returns the hash the server is expected to group packet by,
+// mirroring Sentry's default grouping strategy: the fingerprint when one is
+// set, otherwise the exception types and their in-app frames, otherwise the
+// stacktrace, otherwise the message. It lets tests assert that two errors
+// will end up in the same issue before fingerprint changes are shipped:
+//
+//	if raven.GroupingHash(a) != raven.GroupingHash(b) {
+//		t.Errorf("not grouped together: %q != %q", raven.GroupingComponents(a), raven.GroupingComponents(b))
+//	}
+//
+// It is a preview: server-side grouping enhancements and project settings are
+// not taken into account.
+func GroupingHash(packet *Packet) string {
+	h := md5.New()
+	for _, c := range GroupingComponents(packet) {
+		h.Write([]byte(c))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// GroupingComponents returns the values GroupingHash hashes, which is useful
+// to understand why two packets are not grouped together.
+func GroupingComponents(packet *Packet) []string {
+	if len(packet.Fingerprint) == 0 {
+		return defaultGroupingComponents(packet)
+	}
+	var components []string
+	for _, f := range packet.Fingerprint {
+		if f == "{{ default }}" || f == "{{default}}" {
+			components = append(components, defaultGroupingComponents(packet)...)
+		} else {
+			components = append(components, f)
+		}
+	}
+	return components
+}
+
+func defaultGroupingComponents(packet *Packet) []string {
+	var exceptions []*Exception
+	var stacktrace *Stacktrace
+	message := packet.Message
+	for _, inter := range packet.Interfaces {
+		switch i := inter.(type) {
+		case *Exception:
+			exceptions = append(exceptions, i)
+		case *Exceptions:
+			exceptions = append(exceptions, i.Values...)
+		case Exceptions:
+			exceptions = append(exceptions, i.Values...)
+		case *Stacktrace:
+			stacktrace = i
+		case *Message:
+			// The unformatted message groups events differing only by
+			// their parameters.
+			message = i.Message
+		}
+	}
+
+	if len(exceptions) > 0 {
+		var components []string
+		for _, e := range exceptions {
+			frames := groupingFrames(e.Stacktrace)
+			if len(frames) > 0 {
+				components = append(components, e.Type)
+				components = append(components, frames...)
+			} else {
+				components = append(components, e.Type, e.Value)
+			}
+		}
+		return components
+	}
+	if frames := groupingFrames(stacktrace); len(frames) > 0 {
+		return frames
+	}
+	return []string{message}
+}
+
+// groupingFrames returns the components of the in-app frames of s, or of all
+// its frames when none is in-app.
+func groupingFrames(s *Stacktrace) []string {
+	if s == nil {
+		return nil
+	}
+	s.resolve()
+	inApp := false
+	for _, frame := range s.Frames {
+		if frame.InApp {
+			inApp = true
+			break
+		}
+	}
+
+	var components []string
+	for _, frame := range s.Frames {
+		if frame == nil || inApp && !frame.InApp {
+			continue
+		}
+		if frame.Module != "" {
+			components = append(components, frame.Module)
+		} else if frame.Filename != "" {
+			components = append(components, path.Base(frame.Filename))
+		}
+		components = append(components, frame.Function)
+		if line := strings.TrimSpace(frame.ContextLine); line != "" && len(line) <= maxGroupingContextLine {
+			components = append(components, line)
+		}
+	}
+	return components
+}
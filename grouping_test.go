@@ -0,0 +1,90 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+
+	pkgErrors "github.com/pkg/errors"
+)
+
+var groupingPrefixes = []string{"github.com/getsentry/raven-go"}
+
+type userQuotaError struct{ user string }
+
+func (e *userQuotaError) Error() string { return "quota exceeded for " + e.user }
+
+func failAt(err error) *Packet {
+	return NewPacket(err.Error(), NewException(err, NewStacktrace(0, 1, groupingPrefixes)))
+}
+
+func TestGroupingHash(t *testing.T) {
+	fail := func(err error) *Packet { return failAt(err) }
+
+	frame := func(function, contextLine string) *StacktraceFrame {
+		return &StacktraceFrame{Module: "app", Function: function, ContextLine: contextLine, InApp: true}
+	}
+	stacktrace := func(frames ...*StacktraceFrame) *Stacktrace { return &Stacktrace{Frames: frames} }
+
+	withFingerprint := func(p *Packet, fingerprint ...string) *Packet {
+		p.Fingerprint = fingerprint
+		return p
+	}
+
+	type testCase struct {
+		name    string
+		a, b    *Packet
+		grouped bool
+	}
+	cases := []testCase{
+		{"same call site, different values", fail(&userQuotaError{"jane"}), fail(&userQuotaError{"joe"}), true},
+		{"different call sites", fail(&userQuotaError{"jane"}), failAt(&userQuotaError{"jane"}), false},
+		{"different types", fail(&userQuotaError{"jane"}), fail(errors.New("quota exceeded for jane")), false},
+		{
+			"same message template",
+			NewPacket("user jane", &Message{Message: "user %s", Params: []interface{}{"jane"}}),
+			NewPacket("user joe", &Message{Message: "user %s", Params: []interface{}{"joe"}}),
+			true,
+		},
+		{"different messages", NewPacket("user jane"), NewPacket("user joe"), false},
+		{
+			"library frames ignored",
+			NewPacket("a", stacktrace(frame("main", "run()"), &StacktraceFrame{Module: "net/http", Function: "serve"})),
+			NewPacket("b", stacktrace(frame("main", "run()"), &StacktraceFrame{Module: "net/http", Function: "handle"})),
+			true,
+		},
+		{
+			"context line changed",
+			NewPacket("a", stacktrace(frame("main", "run()"))),
+			NewPacket("a", stacktrace(frame("main", "run(ctx)"))),
+			false,
+		},
+		{
+			"exception without stacktrace",
+			NewPacket("", &Exception{Type: "*errors.errorString", Value: "a"}),
+			NewPacket("", &Exception{Type: "*errors.errorString", Value: "b"}),
+			false,
+		},
+		{"same fingerprint", withFingerprint(NewPacket("a"), "quota"), withFingerprint(NewPacket("b"), "quota"), true},
+		{
+			"fingerprint extending the default",
+			withFingerprint(NewPacket("a"), "{{ default }}", "eu"),
+			withFingerprint(NewPacket("a"), "{{ default }}", "us"),
+			false,
+		},
+	}
+	for _, test := range cases {
+		if grouped := GroupingHash(test.a) == GroupingHash(test.b); grouped != test.grouped {
+			t.Errorf("%s: incorrect grouping: got %t, want %t (%q, %q)",
+				test.name, grouped, test.grouped, GroupingComponents(test.a), GroupingComponents(test.b))
+		}
+	}
+}
+
+func TestGroupingHashLazyStacktrace(t *testing.T) {
+	err := pkgErrors.New("failed")
+	a := NewPacket("", NewException(err, lazyStacktrace(err, nil, 0, 1, groupingPrefixes)))
+	b := NewPacket("", NewException(err, GetOrNewStacktrace(err, nil, 0, 1, groupingPrefixes)))
+	if GroupingHash(a) != GroupingHash(b) {
+		t.Errorf("lazy stack trace grouped differently: %q != %q", GroupingComponents(a), GroupingComponents(b))
+	}
+}
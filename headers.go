@@ -0,0 +1,73 @@
+package raven
+
+import "net/http"
+
+// SetHeader sets an HTTP header sent along with the packet, e.g. a tenant or
+// routing hint Relay can apply rules on. It is not part of the event.
+func (packet *Packet) SetHeader(key, value string) {
+	if packet.Headers == nil {
+		packet.Headers = make(http.Header)
+	}
+	packet.Headers.Set(key, value)
+}
+
+// WithHeaders sets HTTP headers sent along with the event it is passed to, for
+// the capture functions that don't take a packet. It is passed along with the
+// interfaces of an event but never sent as one.
+func WithHeaders(headers map[string]string) Interface {
+	return headersOption(copyTags(headers))
+}
+
+type headersOption map[string]string
+
+func (h headersOption) Class() string { return "headers" }
+
+// applyHeaders removes any WithHeaders or tenant option from the packet's
+// interfaces and applies it.
+func (packet *Packet) applyHeaders() {
+	var interfaces []Interface
+	for i, inter := range packet.Interfaces {
+		switch inter.(type) {
+		case headersOption, tenantOption:
+		default:
+			if interfaces != nil {
+				interfaces = append(interfaces, inter)
+			}
+			continue
+		}
+		if interfaces == nil {
+			interfaces = append(make([]Interface, 0, len(packet.Interfaces)), packet.Interfaces[:i]...)
+		}
+		switch option := inter.(type) {
+		case headersOption:
+			for k, v := range option {
+				packet.SetHeader(k, v)
+			}
+		case tenantOption:
+			packet.tenant = option.tenant
+		}
+	}
+	if interfaces != nil {
+		packet.Interfaces = interfaces
+	}
+}
+
+// requestHeaders returns the HTTP headers to send the packet with: its own,
+// plus a sentry-trace header correlating it with the trace it belongs to, as
+// set by SetSpan, unless it has one already.
+func (packet *Packet) requestHeaders() http.Header {
+	headers := make(http.Header, len(packet.Headers)+1)
+	for k, v := range packet.Headers {
+		headers[k] = v
+	}
+	if headers.Get("Sentry-Trace") != "" {
+		return headers
+	}
+	trace, _ := packet.Contexts["trace"].(map[string]interface{})
+	traceID, _ := trace["trace_id"].(string)
+	spanID, _ := trace["span_id"].(string)
+	if traceID != "" && spanID != "" {
+		headers.Set("Sentry-Trace", traceID+"-"+spanID)
+	}
+	return headers
+}
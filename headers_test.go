@@ -0,0 +1,85 @@
+package raven
+
+import (
+	"compress/zlib"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPacketHeaders(t *testing.T) {
+	type request struct {
+		header http.Header
+		body   string
+	}
+	requests := make(chan request, 2)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Type") == "application/octet-stream" {
+			body, _ = zlib.NewReader(base64.NewDecoder(base64.StdEncoding, r.Body))
+		}
+		event, _ := ioutil.ReadAll(body)
+		requests <- request{r.Header, string(event)}
+	}))
+	defer server.Close()
+
+	client := newClient(nil)
+	client.SetTransport(NewHTTPTransport(TransportOptions{Headers: map[string]string{"X-Region": "eu"}}))
+	if err := client.SetDSN(strings.Replace(server.URL, "://", "://public@", 1) + "/1"); err != nil {
+		t.Fatal(err)
+	}
+
+	client.CaptureError(errors.New("failed"), nil, WithHeaders(map[string]string{
+		"X-Tenant":      "acme",
+		"X-Sentry-Auth": "forged",
+	}))
+	packet := NewPacket("traced")
+	packet.SetHeader("X-Region", "us")
+	packet.SetSpan(&Span{TraceID: "0123456789abcdef0123456789abcdef", SpanID: "0123456789abcdef"})
+	client.Capture(packet, nil)
+	client.Wait()
+
+	first, second := <-requests, <-requests
+	if got := first.header.Get("X-Tenant"); got != "acme" {
+		t.Errorf("incorrect X-Tenant header: got %q, want acme", got)
+	}
+	if got := first.header.Get("X-Sentry-Auth"); got == "forged" {
+		t.Error("packet header overrode the auth header")
+	}
+	if got := first.header.Get("X-Region"); got != "eu" {
+		t.Errorf("incorrect X-Region header: got %q, want eu", got)
+	}
+	if got := first.header.Get("Sentry-Trace"); got != "" {
+		t.Errorf("incorrect Sentry-Trace header: got %q, want none", got)
+	}
+	var event map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(first.body), &event); err != nil {
+		t.Fatalf("can't decode the event: %v", err)
+	}
+	if _, ok := event["headers"]; ok {
+		t.Errorf("headers sent as part of the event: %s", first.body)
+	}
+
+	if got := second.header.Get("X-Region"); got != "us" {
+		t.Errorf("incorrect X-Region header: got %q, want us", got)
+	}
+	if got, want := second.header.Get("Sentry-Trace"), "0123456789abcdef0123456789abcdef-0123456789abcdef"; got != want {
+		t.Errorf("incorrect Sentry-Trace header: got %q, want %q", got, want)
+	}
+}
+
+func TestRequestHeadersKeepsSentryTrace(t *testing.T) {
+	packet := NewPacket("traced")
+	packet.SetSpan(&Span{TraceID: "a", SpanID: "b"})
+	packet.SetHeader("sentry-trace", "c-d-1")
+
+	if got := packet.requestHeaders().Get("Sentry-Trace"); got != "c-d-1" {
+		t.Errorf("incorrect Sentry-Trace header: got %q, want c-d-1", got)
+	}
+}
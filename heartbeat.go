@@ -0,0 +1,147 @@
+package raven
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The statuses of a check-in.
+const (
+	CheckInInProgress = "in_progress"
+	CheckInOK         = "ok"
+	CheckInError      = "error"
+)
+
+// A CheckIn reports the state of a job watched by a Sentry monitor, which
+// flags the job as missed when check-ins stop coming.
+// https://develop.sentry.dev/sdk/check-ins/
+type CheckIn struct {
+	ID          string  `json:"check_in_id"`
+	MonitorSlug string  `json:"monitor_slug"`
+	Status      string  `json:"status"`
+	Duration    float64 `json:"duration,omitempty"`
+	Release     string  `json:"release,omitempty"`
+	Environment string  `json:"environment,omitempty"`
+}
+
+// CaptureCheckIn sends checkIn to Sentry with the client's transport, which
+// must be an EnvelopeTransport, and waits for it to be accepted. Without a
+// DSN, it is written to the fallback writer if set. The ID, release and
+// environment default to a new ID and those of the client. It returns the ID
+// of the check-in, to report the end of a job started with a
+// CheckInInProgress check-in.
+func (client *Client) CaptureCheckIn(ctx gocontext.Context, checkIn *CheckIn) (string, error) {
+	c := *checkIn
+	if c.ID == "" {
+		var err error
+		if c.ID, err = uuid(); err != nil {
+			return "", err
+		}
+	}
+	client.mu.RLock()
+	if c.Release == "" {
+		c.Release = client.release
+	}
+	if c.Environment == "" {
+		c.Environment = client.environment
+	}
+	storeURL, authHeader := client.url, client.authHeader
+	transport := client.Transport
+	fallback := client.fallback
+	ua := client.sdk.UserAgent()
+	client.mu.RUnlock()
+
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	if storeURL == "" {
+		if fallback == nil {
+			return "", ErrNotConfigured
+		}
+		if _, err := fallback.Write(append(payload, '\n')); err != nil {
+			return "", err
+		}
+		return c.ID, nil
+	}
+
+	t, ok := transport.(EnvelopeTransport)
+	if !ok {
+		return "", ErrEnvelopesUnsupported
+	}
+	envelope, err := newEnvelope("", "", "check_in", payload)
+	if err != nil {
+		return "", err
+	}
+	envelopeURL := strings.TrimSuffix(storeURL, "store/") + "envelope/"
+	if err := t.SendEnvelope(withUserAgent(ctx, ua), envelopeURL, authHeader, envelope); err != nil {
+		return "", err
+	}
+	return c.ID, nil
+}
+
+// CaptureCheckIn sends checkIn to Sentry with the default *Client
+func CaptureCheckIn(ctx gocontext.Context, checkIn *CheckIn) (string, error) {
+	return DefaultClientInstance().CaptureCheckIn(ctx, checkIn)
+}
+
+// StartHeartbeat sends an "ok" check-in for monitorSlug right away and then
+// every interval, so that a Sentry monitor set up with a matching interval
+// reports the process as missed when it dies or hangs. Failures and late
+// heartbeats are passed to the client's HeartbeatMissedHandler. The returned
+// function stops the heartbeat. ErrInvalidInterval is returned for an interval
+// that isn't positive.
+func (client *Client) StartHeartbeat(interval time.Duration, monitorSlug string) (stop func(), err error) {
+	if interval <= 0 {
+		return nil, ErrInvalidInterval
+	}
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := time.Now()
+		client.heartbeat(monitorSlug, interval, 0)
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			now := time.Now()
+			late := now.Sub(last) - interval
+			last = now
+			client.heartbeat(monitorSlug, interval, late)
+		}
+	}()
+
+	return func() { once.Do(func() { close(done) }) }, nil
+}
+
+// StartHeartbeat starts a heartbeat reporting to the default *Client
+func StartHeartbeat(interval time.Duration, monitorSlug string) (stop func(), err error) {
+	return DefaultClientInstance().StartHeartbeat(interval, monitorSlug)
+}
+
+func (client *Client) heartbeat(monitorSlug string, interval, late time.Duration) {
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), interval)
+	_, err := client.CaptureCheckIn(ctx, &CheckIn{MonitorSlug: monitorSlug, Status: CheckInOK})
+	cancel()
+
+	// The ticker drops ticks the process was too busy or suspended to take.
+	if err == nil && late >= interval {
+		err = fmt.Errorf("raven: heartbeat %s is %v late", monitorSlug, late.Round(time.Millisecond))
+	}
+	client.mu.RLock()
+	handler := client.HeartbeatMissedHandler
+	client.mu.RUnlock()
+	if err != nil && handler != nil {
+		handler(monitorSlug, err)
+	}
+}
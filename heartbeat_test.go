@@ -0,0 +1,132 @@
+package raven
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCaptureCheckIn(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/1/envelope/" {
+			t.Errorf("incorrect path: %s", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Region"); got != "eu" {
+			t.Errorf("incorrect X-Region header: got %q, want eu", got)
+		}
+		body, _ = ioutil.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	client := newClient(nil)
+	client.SetTransport(NewHTTPTransport(TransportOptions{Headers: map[string]string{"X-Region": "eu"}}))
+	client.SetDSN(strings.Replace(server.URL, "://", "://u@", 1) + "/1")
+	client.SetRelease("1.2.3")
+
+	id, err := client.CaptureCheckIn(gocontext.Background(), &CheckIn{MonitorSlug: "nightly", Status: CheckInInProgress})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(body), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("incorrect envelope: %s", body)
+	}
+	var item envelopeItemHeader
+	json.Unmarshal(lines[1], &item)
+	if item.Type != "check_in" || item.Length != len(lines[2]) {
+		t.Errorf("incorrect item header: %s", lines[1])
+	}
+	var checkIn CheckIn
+	json.Unmarshal(lines[2], &checkIn)
+	expected := CheckIn{ID: id, MonitorSlug: "nightly", Status: CheckInInProgress, Release: "1.2.3"}
+	if checkIn != expected || len(id) != 32 {
+		t.Errorf("incorrect check-in: got %+v, want %+v", checkIn, expected)
+	}
+}
+
+func TestStartHeartbeat(t *testing.T) {
+	beats := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		beats <- string(body)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	missed := make(chan error, 10)
+	client := newClient(nil)
+	client.SetDSN(strings.Replace(server.URL, "://", "://u@", 1) + "/1")
+	client.HeartbeatMissedHandler = func(monitorSlug string, err error) {
+		if monitorSlug != "daemon" {
+			t.Errorf("incorrect monitor: %s", monitorSlug)
+		}
+		missed <- err
+	}
+
+	stop, err := client.StartHeartbeat(20*time.Millisecond, "daemon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case beat := <-beats:
+			if !strings.Contains(beat, `"monitor_slug":"daemon","status":"ok"`) {
+				t.Errorf("incorrect heartbeat: %s", beat)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("no heartbeat")
+		}
+		select {
+		case err := <-missed:
+			if !strings.Contains(err.Error(), "got http status 429") {
+				t.Errorf("incorrect error: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("missed heartbeat not reported")
+		}
+	}
+}
+
+func TestCaptureCheckInWithoutDSN(t *testing.T) {
+	var buf bytes.Buffer
+	client := newClient(nil)
+	if _, err := client.CaptureCheckIn(gocontext.Background(), &CheckIn{MonitorSlug: "nightly", Status: CheckInOK}); err != ErrNotConfigured {
+		t.Errorf("incorrect error: got %v, want %v", err, ErrNotConfigured)
+	}
+
+	client.SetFallbackWriter(&buf)
+	id, err := client.CaptureCheckIn(gocontext.Background(), &CheckIn{MonitorSlug: "nightly", Status: CheckInOK})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"check_in_id":"`+id+`","monitor_slug":"nightly"`) {
+		t.Errorf("incorrect fallback output: %s", buf.String())
+	}
+}
+
+func TestCaptureCheckInTransport(t *testing.T) {
+	client, _ := newTestClient()
+	client.SetDSN("https://u@example.com/1")
+	if _, err := client.CaptureCheckIn(gocontext.Background(), &CheckIn{MonitorSlug: "nightly", Status: CheckInOK}); err != ErrEnvelopesUnsupported {
+		t.Errorf("incorrect error: got %v, want %v", err, ErrEnvelopesUnsupported)
+	}
+}
+
+func TestStartHeartbeatInterval(t *testing.T) {
+	client := newClient(nil)
+	for _, interval := range []time.Duration{0, -time.Second} {
+		if stop, err := client.StartHeartbeat(interval, "daemon"); err != ErrInvalidInterval || stop != nil {
+			t.Errorf("%v: incorrect result: got (%v, %v), want (nil, %v)", interval, stop != nil, err, ErrInvalidInterval)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The time the process started, approximated by the package initialization.
+var processStart = time.Now()
+
+var argSecretFields = []string{"password", "passphrase", "passwd", "secret", "token", "key", "dsn"}
+
+// sanitizeArgs masks the values of command line flags that look like they hold
+// credentials, whether given as -flag=value or -flag value.
+func sanitizeArgs(args []string) []string {
+	sanitized := make([]string, len(args))
+	maskNext := false
+	for i, arg := range args {
+		if maskNext {
+			sanitized[i] = "********"
+			maskNext = false
+			continue
+		}
+		sanitized[i] = arg
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		name := strings.ToLower(strings.TrimLeft(arg, "-"))
+		value := ""
+		if j := strings.Index(name, "="); j >= 0 {
+			name, value = name[:j], arg[strings.Index(arg, "=")+1:]
+		}
+		for _, keyword := range argSecretFields {
+			if strings.Contains(name, keyword) {
+				if value != "" {
+					sanitized[i] = arg[:len(arg)-len(value)] + "********"
+				} else if !strings.Contains(arg, "=") {
+					maskNext = true
+				}
+				break
+			}
+		}
+	}
+	return sanitized
+}
+
+var (
+	hostContextsOnce sync.Once
+	appContext       map[string]interface{}
+	deviceContext    map[string]interface{}
+)
+
+func loadHostContexts() {
+	appContext = map[string]interface{}{
+		"app_start_time": processStart.UTC().Format(time.RFC3339),
+		"process_id":     os.Getpid(),
+		"args":           sanitizeArgs(os.Args[1:]),
+	}
+	if executable, err := os.Executable(); err == nil {
+		appContext["app_name"] = filepath.Base(executable)
+		appContext["executable"] = executable
+	}
+
+	deviceContext = map[string]interface{}{
+		"arch":            runtime.GOARCH,
+		"processor_count": runtime.NumCPU(),
+	}
+	if memory := hostMemorySize(); memory > 0 {
+		deviceContext["memory_size"] = memory
+	}
+	if boot := hostBootTime(); !boot.IsZero() {
+		deviceContext["boot_time"] = boot.UTC().Format(time.RFC3339)
+	}
+}
+
+// addHostContexts sets the "app" and "device" contexts of packet, describing
+// the process and the machine it runs on.
+func addHostContexts(packet *Packet) {
+	hostContextsOnce.Do(loadHostContexts)
+
+	app := make(map[string]interface{}, len(appContext)+1)
+	for k, v := range appContext {
+		app[k] = v
+	}
+	app["uptime_seconds"] = int64(time.Since(processStart) / time.Second)
+
+	device := deviceContext
+	if packet.ServerName != "" {
+		device = make(map[string]interface{}, len(deviceContext)+1)
+		for k, v := range deviceContext {
+			device[k] = v
+		}
+		device["name"] = packet.ServerName
+	}
+
+	packet.AddContexts(map[string]interface{}{
+		"app":    app,
+		"device": device,
+	})
+}
+
+// detectHostname returns the name of the host, falling back on the HOSTNAME
+// environment variable and /etc/hostname where os.Hostname fails, as it can
+// in sandboxes such as gVisor or in scratch containers. It returns "" if the
+// name can't be found, and on js where there is no host to name.
+func detectHostname() string {
+	if runtime.GOOS == "js" {
+		return ""
+	}
+	if name, err := os.Hostname(); err == nil && name != "" {
+		return name
+	}
+	if name := os.Getenv("HOSTNAME"); name != "" {
+		return name
+	}
+	if data, err := ioutil.ReadFile("/etc/hostname"); err == nil {
+		return strings.TrimSpace(string(data))
+	}
+	return ""
+}
+
+// SetServerName sets the server name of events that have none, overriding
+// the detected hostname, e.g. with the pod or instance name when the hostname
+// of a container is meaningless.
+func (client *Client) SetServerName(name string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.serverName = name
+}
+
+// SetServerName sets the server name of the events of the default *Client
+func SetServerName(name string) { DefaultClientInstance().SetServerName(name) }
+
+// SetHostContext controls whether events carry the "app" and "device"
+// contexts describing the process and host. They are sent by default.
+func (client *Client) SetHostContext(enabled bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.noHostContext = !enabled
+}
+
+// SetHostContext controls whether the default *Client sends the host contexts
+func SetHostContext(enabled bool) { DefaultClientInstance().SetHostContext(enabled) }
@@ -0,0 +1,10 @@
+//go:build !linux
+// +build !linux
+
+package raven
+
+import "time"
+
+func hostMemorySize() uint64 { return 0 }
+
+func hostBootTime() time.Time { return time.Time{} }
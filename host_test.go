@@ -0,0 +1,61 @@
+package raven
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeArgs(t *testing.T) {
+	args := []string{"serve", "-port", "8080", "--db-password=hunter2", "-api-token", "abc", "-verbose", "-dsn=https://key@host/1"}
+	expected := []string{"serve", "-port", "8080", "--db-password=********", "-api-token", "********", "-verbose", "-dsn=********"}
+
+	if actual := sanitizeArgs(args); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("incorrect args: got %q, want %q", actual, expected)
+	}
+}
+
+func TestHostContexts(t *testing.T) {
+	client, transport := newTestClient()
+	client.CaptureMessage("failed", nil)
+	client.SetHostContext(false)
+	client.SetRuntimeContext(false)
+	client.CaptureMessage("failed", nil)
+	client.Wait()
+
+	contexts := transport.packets[0].Contexts
+	app, _ := contexts["app"].(map[string]interface{})
+	if app == nil || app["process_id"] == nil || app["uptime_seconds"] == nil {
+		t.Errorf("incorrect app context: %+v", contexts["app"])
+	}
+	device, _ := contexts["device"].(map[string]interface{})
+	if device == nil || device["arch"] == nil || device["processor_count"] == nil {
+		t.Errorf("incorrect device context: %+v", contexts["device"])
+	}
+
+	if contexts := transport.packets[1].Contexts; contexts != nil {
+		t.Errorf("expected no host contexts, got %+v", contexts)
+	}
+}
+
+func TestSetServerName(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetServerName("web-7f9c")
+	client.CaptureMessage("failed", nil)
+	explicit := NewPacket("explicit")
+	explicit.ServerName = "worker-1"
+	client.Capture(explicit, nil)
+	client.Wait()
+
+	for i, expected := range []string{"web-7f9c", "worker-1"} {
+		packet := transport.packets[i]
+		if packet.ServerName != expected {
+			t.Errorf("incorrect server name: got %s, want %s", packet.ServerName, expected)
+		}
+		if device, _ := packet.Contexts["device"].(map[string]interface{}); device["name"] != expected {
+			t.Errorf("incorrect device name: got %v, want %s", device["name"], expected)
+		}
+	}
+	if _, ok := deviceContext["name"]; ok {
+		t.Error("device name set on the shared device context")
+	}
+}
@@ -1,15 +1,18 @@
 package raven
 
 import (
-	"errors"
+	"bufio"
+	"bytes"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
+	"reflect"
+	"runtime"
 	"runtime/debug"
 	"strings"
-
-	pkgErrors "github.com/pkg/errors"
 )
 
 func NewHttp(req *http.Request) *Http {
@@ -47,6 +50,110 @@ func sanitizeQuery(query url.Values) url.Values {
 	return query
 }
 
+var headerSecretFields = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key", "X-Sentry-Auth"}
+
+func sanitizeHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for k, v := range header {
+		headers[k] = strings.Join(v, ",")
+	}
+	for _, field := range headerSecretFields {
+		if _, ok := headers[field]; ok {
+			headers[field] = "********"
+		}
+	}
+	return headers
+}
+
+// The maximum number of bytes of a response body attached by CaptureHTTPError.
+var MaxResponseBodySize = 4096
+
+// newOutgoingHttp builds the request interface of a request made by an HTTP
+// client, scrubbing credentials from its URL and headers.
+func newOutgoingHttp(req *http.Request) *Http {
+	u := *req.URL
+	u.RawQuery, u.Fragment, u.User = "", "", nil
+	return &Http{
+		Method:  req.Method,
+		URL:     u.String(),
+		Query:   sanitizeQuery(req.URL.Query()).Encode(),
+		Headers: sanitizeHeaders(req.Header),
+	}
+}
+
+// responseExtra describes resp, reading at most MaxResponseBodySize bytes of
+// its body. The body is restored so that it can still be read by the caller.
+func responseExtra(resp *http.Response) Extra {
+	extra := Extra{
+		"http.response.status_code": resp.StatusCode,
+		"http.response.headers":     sanitizeHeaders(resp.Header),
+	}
+	if resp.Body != nil {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, int64(MaxResponseBodySize)))
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		if len(body) > 0 {
+			extra["http.response.body"] = string(body)
+		}
+	}
+	return extra
+}
+
+// statusError is reported by CaptureHTTPError when the response status is the
+// only thing that went wrong.
+type statusError struct {
+	msg  string
+	code int
+}
+
+func (e *statusError) Error() string   { return e.msg }
+func (e *statusError) StatusCode() int { return e.code }
+
+// CaptureHTTPError reports a failed call to an upstream HTTP service, including
+// the scrubbed request and response. err may be nil, in which case the error is
+// derived from the response status.
+func (client *Client) CaptureHTTPError(resp *http.Response, err error, tags map[string]string, interfaces ...Interface) string {
+	if client == nil || (resp == nil && err == nil) {
+		return ""
+	}
+
+	extra := Extra{}
+	if resp != nil {
+		extra = responseExtra(resp)
+		if resp.Request != nil {
+			req := newOutgoingHttp(resp.Request)
+			interfaces = append(interfaces, req)
+			if err == nil {
+				err = &statusError{fmt.Sprintf("%s %s: %s", req.Method, req.URL, resp.Status), resp.StatusCode}
+			}
+		} else if err == nil {
+			err = &statusError{"http status " + resp.Status, resp.StatusCode}
+		}
+	}
+
+	if client.shouldExcludeErr(err.Error()) {
+		return ""
+	}
+
+	packet := client.newErrorPacket(1, err, interfaces)
+	for k, v := range extra {
+		if _, ok := packet.Extra[k]; !ok {
+			packet.Extra[k] = v
+		}
+	}
+	eventID, _ := client.Capture(packet, tags)
+
+	return eventID
+}
+
+// CaptureHTTPError reports a failed call to an upstream HTTP service using the
+// default *Client.
+func CaptureHTTPError(resp *http.Response, err error, tags map[string]string, interfaces ...Interface) string {
+	return DefaultClientInstance().CaptureHTTPError(resp, err, tags, interfaces...)
+}
+
 // https://docs.getsentry.com/hosted/clientdev/interfaces/#context-interfaces
 type Http struct {
 	// Required
@@ -67,6 +174,7 @@ func (h *Http) Class() string { return "request" }
 
 // Recovery handler to wrap the stdlib net/http Mux.
 // Example:
+//
 //	http.HandleFunc("/", raven.RecoveryHandler(func(w http.ResponseWriter, r *http.Request) {
 //		...
 //	}))
@@ -76,24 +184,25 @@ func RecoveryHandler(handler func(http.ResponseWriter, *http.Request)) func(http
 
 // Recovery handler to wrap the stdlib net/http Mux.
 // Example:
-//  mux := http.NewServeMux
-//  ...
+//
+//	mux := http.NewServeMux
+//	...
 //	http.Handle("/", raven.Recoverer(mux))
 func Recoverer(handler http.Handler) http.Handler {
+	return recoverer(DefaultClientInstance, handler)
+}
+
+// recoverer reports the panics of handler to the client returned by
+// clientFor, resolved when one happens.
+func recoverer(clientFor func() *Client, handler http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if rval := recover(); rval != nil {
 				debug.PrintStack()
-				rvalStr := fmt.Sprint(rval)
-
-				var packet *Packet
-				if err, ok := rval.(error); ok {
-					cause := pkgErrors.Cause(err)
-					packet = NewPacket(rvalStr, NewException(errors.New(rvalStr), GetOrNewStacktrace(err, cause, 2, 3, nil)), NewHttp(r))
-				} else {
-					packet = NewPacket(rvalStr, NewException(errors.New(rvalStr), NewStacktrace(2, 3, nil)), NewHttp(r))
-				}
-				Capture(packet, nil)
+				client := clientFor()
+				packet := client.newPanicPacket(2, rval, []Interface{NewHttp(r)})
+				packet.Transaction = client.route(r)
+				client.Capture(packet, nil)
 				w.WriteHeader(http.StatusInternalServerError)
 			}
 		}()
@@ -101,3 +210,107 @@ func Recoverer(handler http.Handler) http.Handler {
 		handler.ServeHTTP(w, r)
 	})
 }
+
+// StatusRecoverer is like Recoverer, but also reports requests the handler
+// answers with a status code for which report returns true, even when it
+// doesn't panic. The event's culprit is the handler serving the request, and
+// it is named after the route when a RouteResolver is set.
+// Example:
+//
+//	http.Handle("/", raven.StatusRecoverer(mux, func(status int) bool { return status >= 500 }))
+func StatusRecoverer(handler http.Handler, report func(status int) bool) http.Handler {
+	return statusRecoverer(DefaultClientInstance, handler, report)
+}
+
+// StatusRecoverer is like the package-level StatusRecoverer, but reports the
+// panics and statuses of handler to client.
+func (client *Client) StatusRecoverer(handler http.Handler, report func(status int) bool) http.Handler {
+	return statusRecoverer(func() *Client { return client }, handler, report)
+}
+
+func statusRecoverer(clientFor func() *Client, handler http.Handler, report func(status int) bool) http.Handler {
+	return recoverer(clientFor, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sw := &statusWriter{ResponseWriter: w}
+		handler.ServeHTTP(sw, r)
+
+		status := sw.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		if !report(status) {
+			return
+		}
+
+		client := clientFor()
+		route := client.route(r)
+		path := route
+		if path == "" {
+			path = r.URL.Path
+		}
+		message := fmt.Sprintf("%s %s: %d %s", r.Method, path, status, http.StatusText(status))
+		packet := NewPacketWithExtra(message, Extra{"http.response.status_code": status}, &Message{message, nil}, NewHttp(r))
+		packet.Culprit = handlerName(handler, r)
+		packet.Transaction = route
+		packet.err = &statusError{message, status}
+		client.Capture(packet, nil)
+	}))
+}
+
+// statusWriter records the status code written by a handler.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack lets handlers take over the connection, e.g. for websockets, when the
+// underlying writer supports it.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("raven: %T doesn't support hijacking", w.ResponseWriter)
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer.
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// handlerName names the handler serving r, looking through a ServeMux.
+func handlerName(handler http.Handler, r *http.Request) string {
+	if mux, ok := handler.(*http.ServeMux); ok {
+		handler, _ = mux.Handler(r)
+	}
+	if f, ok := handler.(http.HandlerFunc); ok {
+		if fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer()); fn != nil {
+			return fn.Name()
+		}
+	}
+	t := reflect.TypeOf(handler)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.PkgPath() + "." + t.Name()
+}
@@ -1,9 +1,14 @@
 package raven
 
 import (
+	"bufio"
+	"io/ioutil"
+	"net"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -148,3 +153,152 @@ func TestSanitizeQuery(t *testing.T) {
 		}
 	}
 }
+
+type testTransport struct {
+	packets []*Packet
+}
+
+func (t *testTransport) Send(url, authHeader string, packet *Packet) error {
+	t.packets = append(t.packets, packet)
+	return nil
+}
+
+func newTestClient() (*Client, *testTransport) {
+	transport := &testTransport{}
+	client := newClient(nil)
+	client.Transport = transport
+	return client, transport
+}
+
+func TestCaptureHTTPError(t *testing.T) {
+	client, transport := newTestClient()
+
+	req, _ := http.NewRequest("POST", "https://api.example.com/v1/charges?token=abc&secret=x", nil)
+	req.Header.Set("Authorization", "Bearer abc")
+	resp := &http.Response{
+		Status:     "502 Bad Gateway",
+		StatusCode: 502,
+		Header:     http.Header{"Set-Cookie": {"session=1"}, "Content-Type": {"text/plain"}},
+		Body:       ioutil.NopCloser(strings.NewReader(strings.Repeat("x", MaxResponseBodySize+10))),
+		Request:    req,
+	}
+
+	if eventID := client.CaptureHTTPError(resp, nil, nil); eventID == "" {
+		t.Fatal("expected an event to be captured")
+	}
+	client.Wait()
+
+	packet := transport.packets[0]
+	if packet.Message != "POST https://api.example.com/v1/charges: 502 Bad Gateway" {
+		t.Errorf("incorrect Message: %s", packet.Message)
+	}
+	if packet.Extra["http.response.status_code"] != 502 {
+		t.Errorf("incorrect status code: %v", packet.Extra["http.response.status_code"])
+	}
+	if body := packet.Extra["http.response.body"].(string); len(body) != MaxResponseBodySize {
+		t.Errorf("incorrect body length: got %d, want %d", len(body), MaxResponseBodySize)
+	}
+	headers := packet.Extra["http.response.headers"].(map[string]string)
+	if headers["Set-Cookie"] != "********" || headers["Content-Type"] != "text/plain" {
+		t.Errorf("incorrect response headers: %+v", headers)
+	}
+
+	var h *Http
+	for _, inter := range packet.Interfaces {
+		if inter, ok := inter.(*Http); ok {
+			h = inter
+		}
+	}
+	if h == nil {
+		t.Fatal("expected a request interface")
+	}
+	if h.URL != "https://api.example.com/v1/charges" || h.Headers["Authorization"] != "********" {
+		t.Errorf("incorrect request: %+v", h)
+	}
+
+	// the body must still be readable by the caller
+	if body, _ := ioutil.ReadAll(resp.Body); len(body) != MaxResponseBodySize+10 {
+		t.Errorf("body was not restored: got %d bytes", len(body))
+	}
+}
+
+func failingHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusServiceUnavailable)
+}
+
+func TestStatusRecoverer(t *testing.T) {
+	transport := &testTransport{}
+	defer func(t Transport) { DefaultClient.Transport = t }(DefaultClient.Transport)
+	DefaultClient.Transport = transport
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fail", failingHandler)
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("ok")) })
+	handler := StatusRecoverer(mux, func(status int) bool { return status >= 500 })
+
+	for _, path := range []string{"/ok", "/fail"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	}
+	DefaultClient.Wait()
+
+	if len(transport.packets) != 1 {
+		t.Fatalf("expected 1 event, got %d", len(transport.packets))
+	}
+	packet := transport.packets[0]
+	if packet.Message != "GET /fail: 503 Service Unavailable" {
+		t.Errorf("incorrect Message: got %s", packet.Message)
+	}
+	if expected := "github.com/getsentry/raven-go.failingHandler"; packet.Culprit != expected {
+		t.Errorf("incorrect Culprit: got %s, want %s", packet.Culprit, expected)
+	}
+}
+
+func TestClientStatusRecoverer(t *testing.T) {
+	client, transport := newTestClient()
+	defer func(t Transport) { DefaultClient.Transport = t }(DefaultClient.Transport)
+	defaultTransport := &testTransport{}
+	DefaultClient.Transport = defaultTransport
+
+	handler := client.StatusRecoverer(http.HandlerFunc(failingHandler), func(status int) bool { return status >= 500 })
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/fail", nil))
+	panicking := client.StatusRecoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { panic("boom") }), func(int) bool { return false })
+	panicking.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+	client.Wait()
+	DefaultClient.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Errorf("incorrect number of events: got %d, want 2", len(transport.packets))
+	}
+	if len(defaultTransport.packets) != 0 {
+		t.Errorf("incorrect number of events sent by the default client: got %d, want 0", len(defaultTransport.packets))
+	}
+}
+
+type hijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (w *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.hijacked = true
+	return nil, nil, nil
+}
+
+func TestStatusWriterHijack(t *testing.T) {
+	var err error
+	handler := StatusRecoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, err = w.(http.Hijacker).Hijack()
+	}), func(int) bool { return false })
+
+	w := &hijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/ws", nil))
+	if err != nil || !w.hijacked {
+		t.Errorf("expected the connection to be hijacked, got %v", err)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/ws", nil))
+	if err == nil {
+		t.Error("expected an error hijacking a writer that doesn't support it")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build go1.8
+// +build go1.8
+
+package raven
+
+import "net/http"
+
+// Push initiates an HTTP/2 server push when the underlying writer supports it.
+func (w *statusWriter) Push(target string, opts *http.PushOptions) error {
+	if p, ok := w.ResponseWriter.(http.Pusher); ok {
+		return p.Push(target, opts)
+	}
+	return http.ErrNotSupported
+}
@@ -0,0 +1,38 @@
+//go:build go1.8
+// +build go1.8
+
+package raven
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (w *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	w.pushed = append(w.pushed, target)
+	return nil
+}
+
+func TestStatusWriterPush(t *testing.T) {
+	var err error
+	handler := StatusRecoverer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		err = w.(http.Pusher).Push("/app.js", nil)
+	}), func(int) bool { return false })
+
+	w := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if err != nil || len(w.pushed) != 1 || w.pushed[0] != "/app.js" {
+		t.Errorf("incorrect pushes: got %v (%v)", w.pushed, err)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if err != http.ErrNotSupported {
+		t.Errorf("incorrect error: got %v, want %v", err, http.ErrNotSupported)
+	}
+}
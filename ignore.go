@@ -0,0 +1,21 @@
+//go:build !raven_minimal
+// +build !raven_minimal
+
+package raven
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// compileIgnoreErrors returns the matcher of the messages of errors to ignore,
+// any of the regular expressions errs.
+func compileIgnoreErrors(errs []string) (errorMatcher, error) {
+	joinedRegexp := strings.Join(errs, "|")
+	r, err := regexp.Compile(joinedRegexp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile regexp %q for %q: %v", joinedRegexp, errs, err)
+	}
+	return r, nil
+}
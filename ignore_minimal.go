@@ -0,0 +1,28 @@
+//go:build raven_minimal
+// +build raven_minimal
+
+package raven
+
+import "strings"
+
+// substrings matches strings containing any of its elements.
+type substrings []string
+
+func (s substrings) MatchString(str string) bool {
+	for _, sub := range s {
+		if strings.Contains(str, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileIgnoreErrors returns the matcher of the messages of errors to ignore.
+// Minimal builds leave out regexp: errs are matched as plain substrings.
+func compileIgnoreErrors(errs []string) (errorMatcher, error) {
+	if len(errs) == 0 {
+		// Like the empty regular expression, match everything.
+		return substrings{""}, nil
+	}
+	return substrings(append([]string(nil), errs...)), nil
+}
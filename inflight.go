@@ -0,0 +1,45 @@
+package raven
+
+import "sync"
+
+// inFlight counts the captures in progress. Unlike a sync.WaitGroup, it can be
+// waited on while captures keep being added.
+type inFlight struct {
+	mu sync.Mutex
+	n  int
+
+	// Closed once n drops back to 0
+	idle chan struct{}
+}
+
+func (f *inFlight) Add(delta int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.n == 0 && delta > 0 {
+		f.idle = make(chan struct{})
+	}
+	f.n += delta
+	if f.n < 0 {
+		panic("raven: negative in-flight capture count")
+	}
+	if f.n == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+func (f *inFlight) Done() { f.Add(-1) }
+
+// Idle returns a channel closed once no capture is in progress.
+func (f *inFlight) Idle() <-chan struct{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.idle == nil {
+		idle := make(chan struct{})
+		close(idle)
+		return idle
+	}
+	return f.idle
+}
+
+func (f *inFlight) Wait() { <-f.Idle() }
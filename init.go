@@ -0,0 +1,104 @@
+package raven
+
+import (
+	"log"
+	"os"
+)
+
+// ClientOptions configures the client built by Init. Field names follow
+// sentry-go's, to ease moving between the two. Zero values keep the defaults,
+// which read SENTRY_DSN, SENTRY_RELEASE and SENTRY_ENVIRONMENT.
+type ClientOptions struct {
+	Dsn         string
+	Release     string
+	Environment string
+	ServerName  string
+	Tags        map[string]string
+
+	// SampleRate is the rate at which events are sent; 0 sends them all.
+	SampleRate       float32
+	TracesSampleRate float32
+	IgnoreErrors     []string
+
+	// Debug logs what the package does behind the scenes to stderr, see
+	// SetDebugLogger.
+	Debug bool
+
+	Transport       Transport
+	EventProcessors []EventProcessor
+	Integrations    []Integration
+}
+
+// An Integration sets up a client built by Init, e.g. by adding event
+// processors or starting to record breadcrumbs.
+type Integration interface {
+	Name() string
+	SetupOnce(client *Client)
+}
+
+// Init builds a client from opts and installs it as the default client, in
+// place of the multiple calls otherwise needed to configure it:
+//
+//	err := raven.Init(raven.ClientOptions{
+//		Dsn:         "https://public@sentry.example.com/1",
+//		Release:     version,
+//		Environment: "production",
+//	})
+//
+// The default client is left untouched if an option is invalid. A client
+// installed by an earlier call, or with SetDefaultClient, is closed once
+// replaced, sending the events it has queued.
+func Init(opts ClientOptions) error {
+	client := newClient(opts.Tags)
+	if opts.Dsn != "" {
+		if err := client.SetDSN(opts.Dsn); err != nil {
+			return err
+		}
+	}
+	if opts.Release != "" {
+		client.SetRelease(opts.Release)
+	}
+	if opts.Environment != "" {
+		client.SetEnvironment(opts.Environment)
+	}
+	if opts.ServerName != "" {
+		client.SetServerName(opts.ServerName)
+	}
+	if opts.SampleRate != 0 {
+		if err := client.SetSampleRate(opts.SampleRate); err != nil {
+			return err
+		}
+	}
+	if opts.TracesSampleRate != 0 {
+		if err := client.SetTracesSampleRate(opts.TracesSampleRate); err != nil {
+			return err
+		}
+	}
+	if len(opts.IgnoreErrors) > 0 {
+		if err := client.SetIgnoreErrors(opts.IgnoreErrors); err != nil {
+			return err
+		}
+	}
+	if opts.Transport != nil {
+		client.Transport = opts.Transport
+	}
+	for _, p := range opts.EventProcessors {
+		client.AddEventProcessor(p)
+	}
+
+	// The options are valid, so the package can now be set up.
+	if opts.Debug {
+		SetDebugLogger(log.New(os.Stderr, "", log.LstdFlags))
+	}
+	for _, integration := range opts.Integrations {
+		debugf("raven: setting up integration %s", integration.Name())
+		integration.SetupOnce(client)
+	}
+
+	previous := DefaultClientInstance()
+	SetDefaultClient(client)
+	if previous != DefaultClient {
+		previous.Close()
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package raven
+
+import "testing"
+
+type testIntegration struct{ client *Client }
+
+func (i *testIntegration) Name() string             { return "test" }
+func (i *testIntegration) SetupOnce(client *Client) { i.client = client }
+
+func TestInit(t *testing.T) {
+	defer SetDefaultClient(nil)
+
+	transport := &testTransport{}
+	integration := &testIntegration{}
+	err := Init(ClientOptions{
+		Dsn:         "https://u@example.com/1",
+		Release:     "1.2.3",
+		Environment: "staging",
+		Tags:        map[string]string{"region": "eu"},
+		Transport:   transport,
+		EventProcessors: []EventProcessor{func(packet *Packet, err error) bool {
+			return packet.Message != "dropped"
+		}},
+		Integrations: []Integration{integration},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := DefaultClientInstance()
+	if client == DefaultClient {
+		t.Fatal("default client was not replaced")
+	}
+	if integration.client != client {
+		t.Errorf("incorrect integration client: got %p, want %p", integration.client, client)
+	}
+	if got := client.URL(); got != "https://example.com/api/1/store/" {
+		t.Errorf("incorrect URL: got %s, want https://example.com/api/1/store/", got)
+	}
+
+	CaptureMessage("dropped", nil)
+	CaptureMessage("kept", nil)
+	Wait()
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+	packet := transport.packets[0]
+	if packet.Release != "1.2.3" {
+		t.Errorf("incorrect release: got %s, want 1.2.3", packet.Release)
+	}
+	if packet.Environment != "staging" {
+		t.Errorf("incorrect environment: got %s, want staging", packet.Environment)
+	}
+	if len(packet.Tags) == 0 || packet.Tags[0] != (Tag{"region", "eu"}) {
+		t.Errorf("incorrect tags: got %v, want [{region eu}]", packet.Tags)
+	}
+}
+
+func TestInitInvalidOptions(t *testing.T) {
+	defer SetDefaultClient(nil)
+	defer SetDebugLogger(nil)
+	SetDebugLogger(nil)
+
+	for _, opts := range []ClientOptions{
+		{Dsn: "://invalid", Debug: true},
+		{SampleRate: 2},
+		{TracesSampleRate: -1},
+	} {
+		if err := Init(opts); err == nil {
+			t.Errorf("Init(%+v) returned no error", opts)
+		}
+		if got := DefaultClientInstance(); got != DefaultClient {
+			t.Errorf("incorrect default client after Init(%+v): got %p, want %p", opts, got, DefaultClient)
+		}
+	}
+
+	debugLoggerMu.RLock()
+	defer debugLoggerMu.RUnlock()
+	if debugLogger != nil {
+		t.Error("expected no debug logger to be set by an invalid Init")
+	}
+}
+
+func TestInitClosesPrevious(t *testing.T) {
+	defer SetDefaultClient(nil)
+
+	if err := Init(ClientOptions{Transport: &testTransport{}}); err != nil {
+		t.Fatal(err)
+	}
+	previous := DefaultClientInstance()
+	if err := Init(ClientOptions{Transport: &testTransport{}}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ch := previous.Capture(NewPacket("after"), nil); <-ch != ErrClientClosed {
+		t.Error("expected the previous default client to be closed")
+	}
+	if _, ch := DefaultClientInstance().Capture(NewPacket("after"), nil); <-ch == ErrClientClosed {
+		t.Error("expected the new default client to be open")
+	}
+
+	// DefaultClient is left open, as it stays reachable.
+	SetDefaultClient(nil)
+	if err := Init(ClientOptions{Transport: &testTransport{}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ch := DefaultClient.Capture(NewPacket("after"), nil); <-ch == ErrClientClosed {
+		t.Error("expected DefaultClient to be left open")
+	}
+}
@@ -1,5 +1,10 @@
 package raven
 
+import (
+	"path/filepath"
+	"strings"
+)
+
 // https://docs.getsentry.com/hosted/clientdev/interfaces/#message-interface
 type Message struct {
 	// Required
@@ -11,6 +16,17 @@ type Message struct {
 
 func (m *Message) Class() string { return "logentry" }
 
+// NewMessage returns a structured log entry: the format is kept apart from its
+// params, so that events differing only by their params group together.
+//
+//	raven.Capture(raven.NewPacket(fmt.Sprintf(format, id), raven.NewMessage(format, id)), nil)
+func NewMessage(format string, params ...interface{}) *Message {
+	if len(params) == 0 {
+		params = nil
+	}
+	return &Message{Message: format, Params: params}
+}
+
 // https://docs.getsentry.com/hosted/clientdev/interfaces/#template-interface
 type Template struct {
 	// Required
@@ -26,6 +42,24 @@ type Template struct {
 
 func (t *Template) Class() string { return "template" }
 
+// NewTemplate returns the template interface of an error raised at line lineno
+// of the template file filename, loading context lines of source around it the
+// way stack trace frames do. The line itself is loaded even if context is 0.
+func NewTemplate(filename string, lineno, context int) *Template {
+	t := &Template{Filename: filename, Lineno: lineno, AbsolutePath: filename}
+	if abs, err := filepath.Abs(filename); err == nil {
+		t.AbsolutePath = abs
+	}
+
+	frame := &StacktraceFrame{AbsolutePath: t.AbsolutePath, Lineno: lineno}
+	if context == 0 {
+		context = -1
+	}
+	loadFrameContext(sourceCodeLoader, frame, context)
+	t.ContextLine, t.PreContext, t.PostContext = frame.ContextLine, frame.PreContext, frame.PostContext
+	return t
+}
+
 // https://docs.getsentry.com/hosted/clientdev/interfaces/#context-interfaces
 type User struct {
 	// All fields are optional
@@ -47,3 +81,9 @@ type Query struct {
 }
 
 func (q *Query) Class() string { return "query" }
+
+// NewQuery returns the query interface of a database query run by engine, e.g.
+// "postgres".
+func NewQuery(query, engine string) *Query {
+	return &Query{Query: strings.TrimSpace(query), Engine: engine}
+}
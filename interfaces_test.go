@@ -0,0 +1,66 @@
+package raven
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNewMessage(t *testing.T) {
+	cases := []struct {
+		message  *Message
+		expected string
+	}{
+		{NewMessage("user %s not found", "jane"), `{"message":"user %s not found","params":["jane"]}`},
+		{NewMessage("not found"), `{"message":"not found"}`},
+	}
+	for _, test := range cases {
+		data, err := json.Marshal(test.message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != test.expected {
+			t.Errorf("incorrect JSON: got %s, want %s", data, test.expected)
+		}
+	}
+}
+
+func TestNewQuery(t *testing.T) {
+	query := NewQuery("\n\tSELECT * FROM users\n\tWHERE id = $1\n", "postgres")
+	expected := &Query{Query: "SELECT * FROM users\n\tWHERE id = $1", Engine: "postgres"}
+	if !reflect.DeepEqual(query, expected) {
+		t.Errorf("incorrect query: got %+v, want %+v", query, expected)
+	}
+}
+
+func TestNewTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "page.html")
+	if err := ioutil.WriteFile(filename, []byte("<html>\n<h1>{{.Title}}</h1>\n<p>{{.Body.Text}}</p>\n</html>\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	template := NewTemplate(filename, 3, 1)
+	expected := &Template{
+		Filename:     filename,
+		Lineno:       3,
+		ContextLine:  "<p>{{.Body.Text}}</p>",
+		PreContext:   []string{"<h1>{{.Title}}</h1>"},
+		PostContext:  []string{"</html>"},
+		AbsolutePath: filename,
+	}
+	if !reflect.DeepEqual(template, expected) {
+		t.Errorf("incorrect template:\ngot  %+v\nwant %+v", template, expected)
+	}
+
+	if template := NewTemplate(filename, 2, 0); template.ContextLine != "<h1>{{.Title}}</h1>" || template.PreContext != nil {
+		t.Errorf("incorrect template without context: %+v", template)
+	}
+}
@@ -0,0 +1,137 @@
+// Command loadtest pushes events through a raven client at a configurable rate
+// against a fake Sentry server, and reports throughput, drop rate, delivery
+// latencies and allocations. It guards the worker and transport against
+// performance regressions:
+//
+//	go run ./internal/loadtest -rate 500 -duration 10s -max-drop-rate 0.01
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/getsentry/raven-go"
+)
+
+var (
+	rate          = flag.Int("rate", 1000, "events captured per second")
+	duration      = flag.Duration("duration", 10*time.Second, "how long to capture events for")
+	serverLatency = flag.Duration("server-latency", 0, "time the fake server takes to respond")
+	queueSize     = flag.Int("queue", raven.MaxQueueBuffer, "size of the client queue")
+	maxDropRate   = flag.Float64("max-drop-rate", -1, "fail if more than this fraction of events is dropped")
+)
+
+// The interval at which batches of events are captured.
+const tick = 10 * time.Millisecond
+
+type result struct {
+	captured  uint64
+	delivered uint64
+	dropped   uint64
+	failed    uint64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+}
+
+func (r *result) record(captured time.Time, err error) {
+	switch err {
+	case nil:
+		atomic.AddUint64(&r.delivered, 1)
+		r.mu.Lock()
+		r.latencies = append(r.latencies, time.Since(captured))
+		r.mu.Unlock()
+	case raven.ErrPacketDropped:
+		atomic.AddUint64(&r.dropped, 1)
+	default:
+		atomic.AddUint64(&r.failed, 1)
+	}
+}
+
+func (r *result) percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+	return r.latencies[int(p*float64(len(r.latencies)-1))]
+}
+
+func main() {
+	flag.Parse()
+
+	var received uint64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		io.Copy(ioutil.Discard, req.Body)
+		time.Sleep(*serverLatency)
+		atomic.AddUint64(&received, 1)
+		w.Write([]byte(`{"id":"0"}`))
+	}))
+	defer server.Close()
+
+	raven.MaxQueueBuffer = *queueSize
+	client, err := raven.New("http://public@" + strings.TrimPrefix(server.URL, "http://") + "/1")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	r := &result{}
+	var waiters sync.WaitGroup
+	start := time.Now()
+	ticker := time.NewTicker(tick)
+	perTick := float64(*rate) * tick.Seconds()
+	var due float64
+	for now := range ticker.C {
+		if now.Sub(start) >= *duration {
+			break
+		}
+		for due += perTick; due >= 1; due-- {
+			packet := raven.NewPacket("load test event", raven.NewException(errors.New("load test"), raven.NewStacktrace(0, 3, nil)))
+			captured := time.Now()
+			_, ch := client.Capture(packet, map[string]string{"loadtest": "true"})
+			atomic.AddUint64(&r.captured, 1)
+			waiters.Add(1)
+			go func() {
+				defer waiters.Done()
+				r.record(captured, <-ch)
+			}()
+		}
+	}
+	ticker.Stop()
+	capturing := time.Since(start)
+	waiters.Wait()
+	elapsed := time.Since(start)
+
+	runtime.ReadMemStats(&after)
+	sort.Slice(r.latencies, func(i, j int) bool { return r.latencies[i] < r.latencies[j] })
+
+	dropRate := float64(r.dropped) / float64(r.captured)
+	fmt.Printf("captured:   %d events in %s (%.0f/s)\n", r.captured, capturing.Round(time.Millisecond), float64(r.captured)/capturing.Seconds())
+	fmt.Printf("delivered:  %d events in %s (%.0f/s), %d received by the server\n", r.delivered, elapsed.Round(time.Millisecond), float64(r.delivered)/elapsed.Seconds(), atomic.LoadUint64(&received))
+	fmt.Printf("dropped:    %d (%.2f%%), failed: %d\n", r.dropped, 100*dropRate, r.failed)
+	fmt.Printf("latency:    p50 %s, p90 %s, p99 %s, max %s\n", r.percentile(0.5), r.percentile(0.9), r.percentile(0.99), r.percentile(1))
+	if r.captured > 0 {
+		fmt.Printf("allocs:     %d/event, %d B/event\n", (after.Mallocs-before.Mallocs)/r.captured, (after.TotalAlloc-before.TotalAlloc)/r.captured)
+	}
+	fmt.Printf("heap:       %d KiB before, %d KiB after\n", before.HeapAlloc/1024, after.HeapAlloc/1024)
+
+	if *maxDropRate >= 0 && dropRate > *maxDropRate {
+		fmt.Printf("drop rate %.2f%% exceeds %.2f%%\n", 100*dropRate, 100**maxDropRate)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,31 @@
+package raven
+
+// A KafkaProducer publishes a message to a Kafka topic, e.g. by wrapping a
+// sarama.SyncProducer or a kafka-go Writer:
+//
+//	func (p *producer) Produce(topic string, key, value []byte) error {
+//		return p.writer.WriteMessages(ctx, kafka.Message{Topic: topic, Key: key, Value: value})
+//	}
+type KafkaProducer interface {
+	Produce(topic string, key, value []byte) error
+}
+
+// KafkaTransport publishes events to a Kafka topic as Sentry envelopes, for
+// organizations routing error traffic through their own pipeline before it
+// reaches Relay. Messages are keyed by event ID.
+type KafkaTransport struct {
+	Producer KafkaProducer
+	Topic    string
+
+	// The DSN written to the envelope headers, telling the pipeline where
+	// to forward the event. It should only hold the public key.
+	DSN string
+}
+
+func (t *KafkaTransport) Send(url, authHeader string, packet *Packet) error {
+	envelope, err := serializeEnvelope(packet, t.DSN)
+	if err != nil {
+		return err
+	}
+	return t.Producer.Produce(t.Topic, []byte(packet.EventID), envelope)
+}
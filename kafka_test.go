@@ -0,0 +1,34 @@
+package raven
+
+import (
+	"bytes"
+	"testing"
+)
+
+type testProducer struct {
+	topic      string
+	key, value []byte
+}
+
+func (p *testProducer) Produce(topic string, key, value []byte) error {
+	p.topic, p.key, p.value = topic, key, value
+	return nil
+}
+
+func TestKafkaTransport(t *testing.T) {
+	producer := &testProducer{}
+	transport := &KafkaTransport{Producer: producer, Topic: "sentry-events"}
+
+	packet := NewPacket("failed")
+	packet.Init("1")
+	if err := transport.Send("", "", packet); err != nil {
+		t.Fatal(err)
+	}
+
+	if producer.topic != "sentry-events" || string(producer.key) != packet.EventID {
+		t.Errorf("incorrect message: topic %s, key %s", producer.topic, producer.key)
+	}
+	if packetJSON, _ := packet.JSON(); !bytes.Contains(producer.value, packetJSON) {
+		t.Errorf("expected the event in the envelope, got %s", producer.value)
+	}
+}
@@ -0,0 +1,181 @@
+//go:build go1.16 && !raven_minimal
+// +build go1.16,!raven_minimal
+
+package raven
+
+import (
+	"fmt"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// The minimum time between two latency events sent by WatchLatency.
+var LatencyReportInterval = time.Minute
+
+// Candidate runtime/metrics names, newest first: metrics get renamed between
+// Go releases.
+var (
+	gcPauseMetrics      = []string{"/sched/pauses/total/gc:seconds", "/gc/pauses:seconds"}
+	schedLatencyMetrics = []string{"/sched/latencies:seconds"}
+)
+
+// Scalar metrics attached to latency events for context.
+var latencyContextMetrics = []string{
+	"/gc/cycles/total:gc-cycles",
+	"/gc/heap/goal:bytes",
+	"/memory/classes/heap/objects:bytes",
+	"/memory/classes/total:bytes",
+	"/sched/goroutines:goroutines",
+	"/sched/gomaxprocs:threads",
+}
+
+// WatchLatency starts a monitor reading the runtime's GC pause and scheduling
+// latency histograms every interval. It sends a WARNING event with a snapshot
+// of runtime metrics when a GC pause longer than gcPause, or a goroutine
+// waiting longer than schedLatency to be scheduled, was observed since the
+// last reading. Either check is disabled by a zero value. At most one event is
+// sent per LatencyReportInterval. The returned function stops the monitor.
+func (client *Client) WatchLatency(gcPause, schedLatency, interval time.Duration) (stop func()) {
+	m := newLatencyMonitor(gcPause, schedLatency)
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var lastReport time.Time
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			message := m.check()
+			if message != "" && time.Since(lastReport) >= LatencyReportInterval {
+				lastReport = time.Now()
+				client.captureLatency(message, m)
+			}
+		}
+	}()
+
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// WatchLatency starts a runtime latency monitor reporting to the default *Client
+func WatchLatency(gcPause, schedLatency, interval time.Duration) (stop func()) {
+	return DefaultClientInstance().WatchLatency(gcPause, schedLatency, interval)
+}
+
+type latencyMonitor struct {
+	gcPause      time.Duration
+	schedLatency time.Duration
+
+	samples  []metrics.Sample
+	previous [2]*metrics.Float64Histogram
+
+	// The longest pause and latency seen by the last check.
+	maxGCPause      time.Duration
+	maxSchedLatency time.Duration
+}
+
+func newLatencyMonitor(gcPause, schedLatency time.Duration) *latencyMonitor {
+	supported := make(map[string]bool)
+	for _, d := range metrics.All() {
+		supported[d.Name] = true
+	}
+	pick := func(names []string) string {
+		for _, name := range names {
+			if supported[name] {
+				return name
+			}
+		}
+		return ""
+	}
+
+	m := &latencyMonitor{gcPause: gcPause, schedLatency: schedLatency}
+	m.samples = []metrics.Sample{
+		{Name: pick(gcPauseMetrics)},
+		{Name: pick(schedLatencyMetrics)},
+	}
+	for _, name := range latencyContextMetrics {
+		if supported[name] {
+			m.samples = append(m.samples, metrics.Sample{Name: name})
+		}
+	}
+	m.read()
+	return m
+}
+
+func (m *latencyMonitor) read() {
+	metrics.Read(m.samples)
+	for i := range m.previous {
+		var max time.Duration
+		if m.samples[i].Value.Kind() == metrics.KindFloat64Histogram {
+			h := m.samples[i].Value.Float64Histogram()
+			max = maxNewSample(m.previous[i], h)
+			m.previous[i] = h
+		}
+		if i == 0 {
+			m.maxGCPause = max
+		} else {
+			m.maxSchedLatency = max
+		}
+	}
+}
+
+// check takes a new reading, returning why it should be reported, if it
+// should.
+func (m *latencyMonitor) check() string {
+	m.read()
+	return m.message()
+}
+
+func (m *latencyMonitor) message() string {
+	switch {
+	case m.gcPause > 0 && m.maxGCPause > m.gcPause:
+		return fmt.Sprintf("GC pause of at least %v exceeds %v", m.maxGCPause, m.gcPause)
+	case m.schedLatency > 0 && m.maxSchedLatency > m.schedLatency:
+		return fmt.Sprintf("scheduling latency of at least %v exceeds %v", m.maxSchedLatency, m.schedLatency)
+	}
+	return ""
+}
+
+// maxNewSample returns the lower bound of the highest histogram bucket that
+// gained samples since previous.
+func maxNewSample(previous, current *metrics.Float64Histogram) time.Duration {
+	for i := len(current.Counts) - 1; i >= 0; i-- {
+		count := current.Counts[i]
+		if previous != nil && i < len(previous.Counts) {
+			count -= previous.Counts[i]
+		}
+		if count > 0 {
+			if lower := current.Buckets[i]; lower > 0 {
+				return time.Duration(lower * float64(time.Second))
+			}
+			return 0
+		}
+	}
+	return 0
+}
+
+func (client *Client) captureLatency(message string, m *latencyMonitor) {
+	runtimeMetrics := map[string]interface{}{
+		"gc_pause_max_ms":      float64(m.maxGCPause) / float64(time.Millisecond),
+		"sched_latency_max_ms": float64(m.maxSchedLatency) / float64(time.Millisecond),
+	}
+	for _, s := range m.samples[2:] {
+		switch s.Value.Kind() {
+		case metrics.KindUint64:
+			runtimeMetrics[s.Name] = s.Value.Uint64()
+		case metrics.KindFloat64:
+			runtimeMetrics[s.Name] = s.Value.Float64()
+		}
+	}
+
+	packet := NewPacket(message, &Message{message, nil})
+	packet.Level = WARNING
+	packet.Logger = "raven.watchdog"
+	packet.Contexts = map[string]interface{}{"runtime_metrics": runtimeMetrics}
+	client.Capture(packet, nil)
+}
@@ -0,0 +1,21 @@
+//go:build !go1.16 || raven_minimal
+// +build !go1.16 raven_minimal
+
+package raven
+
+import "time"
+
+// The minimum time between two latency events sent by WatchLatency.
+var LatencyReportInterval = time.Minute
+
+// WatchLatency needs runtime/metrics, which Go releases before 1.16 lack and
+// minimal builds leave out, and does nothing without it. The returned function
+// does nothing either.
+func (client *Client) WatchLatency(gcPause, schedLatency, interval time.Duration) (stop func()) {
+	return func() {}
+}
+
+// WatchLatency starts a runtime latency monitor reporting to the default *Client
+func WatchLatency(gcPause, schedLatency, interval time.Duration) (stop func()) {
+	return DefaultClientInstance().WatchLatency(gcPause, schedLatency, interval)
+}
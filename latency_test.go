@@ -0,0 +1,47 @@
+//go:build go1.16 && !raven_minimal
+// +build go1.16,!raven_minimal
+
+package raven
+
+import (
+	"math"
+	"runtime/metrics"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxNewSample(t *testing.T) {
+	buckets := []float64{math.Inf(-1), 0.001, 0.01, 0.1, math.Inf(1)}
+	previous := &metrics.Float64Histogram{Counts: []uint64{0, 5, 1, 0}, Buckets: buckets}
+
+	tests := []struct {
+		counts   []uint64
+		expected time.Duration
+	}{
+		{[]uint64{0, 5, 1, 0}, 0},
+		{[]uint64{0, 9, 1, 0}, time.Millisecond},
+		{[]uint64{0, 9, 1, 1}, 100 * time.Millisecond},
+		{[]uint64{3, 5, 1, 0}, 0},
+	}
+
+	for i, test := range tests {
+		current := &metrics.Float64Histogram{Counts: test.counts, Buckets: buckets}
+		if actual := maxNewSample(previous, current); actual != test.expected {
+			t.Errorf("%d: incorrect max: got %v, want %v", i, actual, test.expected)
+		}
+	}
+}
+
+func TestLatencyMonitorCheck(t *testing.T) {
+	m := newLatencyMonitor(time.Millisecond, 0)
+	m.maxGCPause = 10 * time.Millisecond
+	if message := m.message(); !strings.HasPrefix(message, "GC pause") {
+		t.Errorf("incorrect message: %q", message)
+	}
+
+	m.maxGCPause = 0
+	if message := m.message(); message != "" {
+		t.Errorf("expected no report, got %q", message)
+	}
+}
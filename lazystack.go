@@ -0,0 +1,89 @@
+package raven
+
+import "runtime"
+
+// lazyFrames holds what is needed to resolve the frames of a stack trace.
+type lazyFrames struct {
+	pcs      []uintptr
+	context  int
+	prefixes []string
+}
+
+// SetLazyStacktraces makes CaptureError only record the return addresses of
+// the stack trace, resolving function names, files and source context in the
+// background worker rather than on the caller's goroutine. Event processors
+// then see the stack traces of captured errors without frames, and the
+// culprit is derived once they are resolved.
+func (client *Client) SetLazyStacktraces(lazy bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.lazyStacktraces = lazy
+}
+
+// SetLazyStacktraces sets whether the default *Client resolves stack traces in the background
+func SetLazyStacktraces(lazy bool) { DefaultClientInstance().SetLazyStacktraces(lazy) }
+
+// lazyStacktrace is like GetOrNewStacktrace, but leaves the frames to be
+// resolved by resolve.
+func lazyStacktrace(err, cause error, skip int, context int, appPackagePrefixes []string) *Stacktrace {
+	var pcs []uintptr
+	if stacktracer, ok := cause.(StackTracer); ok {
+		pcs = stackTracerPCs(stacktracer)
+	} else if stacktracer, ok := err.(StackTracer); ok {
+		pcs = stackTracerPCs(stacktracer)
+	} else {
+		pcs = make([]uintptr, 64)
+		for {
+			n := runtime.Callers(2+skip, pcs)
+			if n < len(pcs) {
+				pcs = pcs[:n]
+				break
+			}
+			pcs = make([]uintptr, 2*len(pcs))
+		}
+	}
+	return &Stacktrace{lazy: &lazyFrames{pcs, context, appPackagePrefixes}}
+}
+
+// resolve builds the frames of a lazy stack trace.
+func (s *Stacktrace) resolve() {
+	if s == nil || s.lazy == nil {
+		return
+	}
+	resolved := stacktraceFromPCs(s.lazy.pcs, s.lazy.context, s.lazy.prefixes)
+	s.Frames, s.FramesOmitted, s.lazy = resolved.Frames, resolved.FramesOmitted, nil
+}
+
+// resolveStacktraces resolves the lazy stack traces of the packet and derives
+// its culprit from them if it has none yet.
+func (packet *Packet) resolveStacktraces() {
+	var resolved bool
+	resolveException := func(e *Exception) {
+		if e != nil && e.Stacktrace != nil && e.Stacktrace.lazy != nil {
+			e.Stacktrace.resolve()
+			resolved = true
+		}
+	}
+	for _, inter := range packet.Interfaces {
+		switch i := inter.(type) {
+		case *Exception:
+			resolveException(i)
+		case *Exceptions:
+			for _, e := range i.Values {
+				resolveException(e)
+			}
+		case Exceptions:
+			for _, e := range i.Values {
+				resolveException(e)
+			}
+		case *Stacktrace:
+			if i.lazy != nil {
+				i.resolve()
+				resolved = true
+			}
+		}
+	}
+	if resolved {
+		packet.deriveCulprit()
+	}
+}
@@ -0,0 +1,46 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+
+	pkgErrors "github.com/pkg/errors"
+)
+
+func TestLazyStacktraces(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetIncludePaths([]string{"github.com/getsentry/raven-go"})
+	client.SetLazyStacktraces(true)
+
+	var unresolved int
+	client.AddEventProcessor(func(packet *Packet, err error) bool {
+		if ex, ok := packet.Interfaces[len(packet.Interfaces)-1].(*Exception); ok && len(ex.Stacktrace.Frames) == 0 {
+			unresolved++
+		}
+		return true
+	})
+
+	reportError(client, 0, errors.New("failed"))
+	reportError(client, 1, pkgErrors.New("failed"))
+	client.Wait()
+
+	if unresolved != 2 {
+		t.Errorf("incorrect unresolved stack trace count: got %d, want 2", unresolved)
+	}
+	expected := []string{
+		"github.com/getsentry/raven-go.reportError",
+		"github.com/getsentry/raven-go.TestLazyStacktraces",
+	}
+	if len(transport.packets) != len(expected) {
+		t.Fatalf("incorrect packet count: got %d, want %d", len(transport.packets), len(expected))
+	}
+	for i, packet := range transport.packets {
+		if packet.Culprit != expected[i] || packet.Transaction != expected[i] {
+			t.Errorf("incorrect culprit: got %s, want %s", packet.Culprit, expected[i])
+		}
+		ex := packet.Interfaces[len(packet.Interfaces)-1].(*Exception)
+		if ex.Stacktrace.lazy != nil || len(ex.Stacktrace.Frames) == 0 {
+			t.Errorf("stack trace of packet %d not resolved: %+v", i, ex.Stacktrace)
+		}
+	}
+}
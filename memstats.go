@@ -0,0 +1,78 @@
+package raven
+
+import (
+	"runtime"
+	"time"
+)
+
+// RuntimeStatsProcessor is an EventProcessor attaching a snapshot of the Go
+// runtime's memory and GC statistics to events as the "runtime" context, to
+// help diagnose errors happening under memory pressure. It stops the world
+// briefly to read them, so it is opt-in:
+//
+//	client.AddEventProcessor(raven.RuntimeStatsProcessor)
+func RuntimeStatsProcessor(packet *Packet, err error) bool {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	var lastPause time.Duration
+	if m.NumGC > 0 {
+		lastPause = time.Duration(m.PauseNs[(m.NumGC+255)%256])
+	}
+
+	stats := map[string]interface{}{
+		"name":              "go",
+		"version":           runtime.Version(),
+		"goroutines":        runtime.NumGoroutine(),
+		"heap_alloc":        m.HeapAlloc,
+		"heap_inuse":        m.HeapInuse,
+		"heap_objects":      m.HeapObjects,
+		"heap_sys":          m.HeapSys,
+		"sys":               m.Sys,
+		"num_gc":            m.NumGC,
+		"gc_pause_total_ms": float64(m.PauseTotalNs) / float64(time.Millisecond),
+		"gc_pause_last_ms":  float64(lastPause) / float64(time.Millisecond),
+		"gc_cpu_fraction":   m.GCCPUFraction,
+	}
+	if m.LastGC > 0 {
+		stats["last_gc"] = time.Unix(0, int64(m.LastGC)).UTC().Format(time.RFC3339)
+	}
+
+	// Complete the default runtime context rather than leave it be.
+	if defaults, ok := packet.Contexts["runtime"].(map[string]interface{}); ok {
+		for k, v := range defaults {
+			if _, ok := stats[k]; !ok {
+				stats[k] = v
+			}
+		}
+		delete(packet.Contexts, "runtime")
+	}
+	packet.AddContexts(map[string]interface{}{"runtime": stats})
+	return true
+}
+
+// addRuntimeContext sets the "runtime" context describing the Go runtime,
+// unless the packet already has one.
+func addRuntimeContext(packet *Packet) {
+	packet.AddContexts(map[string]interface{}{
+		"runtime": map[string]interface{}{
+			"name":       "go",
+			"version":    runtime.Version(),
+			"goroutines": runtime.NumGoroutine(),
+			"num_cpu":    runtime.NumCPU(),
+			"gomaxprocs": runtime.GOMAXPROCS(0),
+		},
+	})
+}
+
+// SetRuntimeContext controls whether events carry the "runtime" context
+// describing the Go runtime: its version, the number of goroutines and CPUs,
+// and GOMAXPROCS. It is sent by default.
+func (client *Client) SetRuntimeContext(enabled bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.noRuntimeContext = !enabled
+}
+
+// SetRuntimeContext controls whether the default *Client sends the runtime context
+func SetRuntimeContext(enabled bool) { DefaultClientInstance().SetRuntimeContext(enabled) }
@@ -0,0 +1,56 @@
+package raven
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestRuntimeStatsProcessor(t *testing.T) {
+	runtime.GC()
+
+	packet := &Packet{}
+	if !RuntimeStatsProcessor(packet, nil) {
+		t.Fatal("expected the packet to be kept")
+	}
+
+	stats, _ := packet.Contexts["runtime"].(map[string]interface{})
+	if stats == nil {
+		t.Fatalf("expected a runtime context, got %+v", packet.Contexts)
+	}
+	if stats["version"] != runtime.Version() {
+		t.Errorf("incorrect version: got %v, want %s", stats["version"], runtime.Version())
+	}
+	if numGC, _ := stats["num_gc"].(uint32); numGC == 0 {
+		t.Errorf("incorrect num_gc: got %v", stats["num_gc"])
+	}
+	if stats["last_gc"] == nil {
+		t.Error("expected last_gc to be set")
+	}
+}
+
+func TestRuntimeContext(t *testing.T) {
+	client, transport := newTestClient()
+	client.AddEventProcessor(RuntimeStatsProcessor)
+	client.CaptureMessage("with stats", nil)
+	client.Wait()
+
+	stats, _ := transport.packets[0].Contexts["runtime"].(map[string]interface{})
+	if stats == nil || stats["num_cpu"] != runtime.NumCPU() || stats["heap_alloc"] == nil {
+		t.Errorf("incorrect runtime context: %+v", stats)
+	}
+	for k := range transport.packets[0].Extra {
+		if strings.HasPrefix(k, "runtime.") {
+			t.Errorf("unexpected runtime extra: %s", k)
+		}
+	}
+
+	client, transport = newTestClient()
+	client.SetRuntimeContext(false)
+	client.CaptureMessage("without", nil)
+	client.Wait()
+
+	if _, ok := transport.packets[0].Contexts["runtime"]; ok {
+		t.Errorf("unexpected runtime context: %+v", transport.packets[0].Contexts["runtime"])
+	}
+}
@@ -0,0 +1,70 @@
+//go:build raven_minimal
+// +build raven_minimal
+
+package raven
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestMinimalIgnoreErrors(t *testing.T) {
+	client := newClient(nil)
+	if err := client.SetIgnoreErrors([]string{"ERR_TIMEOUT", "context canceled"}); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		message  string
+		excluded bool
+	}{
+		{"there was a ERR_TIMEOUT in handlers.go", true},
+		{"query: context canceled", true},
+		{"ERR_TIME", false},
+	}
+	for _, test := range cases {
+		if excluded := client.shouldExcludeErr(test.message); excluded != test.excluded {
+			t.Errorf("incorrect exclusion of %q: got %t, want %t", test.message, excluded, test.excluded)
+		}
+	}
+}
+
+func TestMinimalEncodeBody(t *testing.T) {
+	packetJSON := []byte(`{"message":"` + strings.Repeat("x", 2000) + `"}`)
+	body, contentType := encodeBody(packetJSON)
+	if contentType != "application/json" {
+		t.Errorf("incorrect content type: got %s, want application/json", contentType)
+	}
+	if b, ok := body.(interface{ Len() int }); !ok || b.Len() != len(packetJSON) {
+		t.Error("expected the body to be sent uncompressed")
+	}
+}
+
+func TestMinimalExtractTags(t *testing.T) {
+	if tags := extractTags(errors.New("failed")); tags != nil {
+		t.Errorf("unexpected tags: %v", tags)
+	}
+	if dirs := sourceDirs(); len(dirs) == 0 {
+		t.Error("expected GOROOT to be a source directory")
+	}
+}
+
+func TestMinimalImports(t *testing.T) {
+	out, err := exec.Command("go", "list", "-tags", "raven_minimal", "-deps", ".").Output()
+	if err != nil {
+		t.Skipf("can't list the dependencies: %v", err)
+	}
+	excluded := map[string]bool{
+		"compress/zlib":                true,
+		"github.com/certifi/gocertifi": true,
+		"go/build":                     true,
+		"regexp":                       true,
+		"runtime/metrics":              true,
+	}
+	for _, pkg := range strings.Fields(string(out)) {
+		if excluded[pkg] {
+			t.Errorf("minimal build imports %s", pkg)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package raven
+
+import (
+	"sort"
+	"strings"
+)
+
+type servicePrefix struct {
+	prefix  string
+	service string
+}
+
+// byPrefixLength sorts service prefixes from the longest to the shortest.
+type byPrefixLength []servicePrefix
+
+func (s byPrefixLength) Len() int           { return len(s) }
+func (s byPrefixLength) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byPrefixLength) Less(i, j int) bool { return len(s[i].prefix) > len(s[j].prefix) }
+
+// SetServices maps in-app package path prefixes to the names of the services
+// of a monorepo. Events get a "service" tag, and a logger named after the
+// service unless they have one, based on the package of the top in-app frame
+// of their stack trace. The longest matching prefix wins.
+//
+// Example:
+//
+//	client.SetServices(map[string]string{
+//		"github.com/acme/mono/billing": "billing",
+//		"github.com/acme/mono/search":  "search",
+//	})
+func (client *Client) SetServices(services map[string]string) {
+	prefixes := make([]servicePrefix, 0, len(services))
+	for prefix, service := range services {
+		prefixes = append(prefixes, servicePrefix{strings.TrimSuffix(prefix, "/"), service})
+	}
+	sort.Sort(byPrefixLength(prefixes))
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.services = prefixes
+}
+
+// SetServices sets the services of the default *Client
+func SetServices(services map[string]string) { DefaultClientInstance().SetServices(services) }
+
+// assignService sets the service tag and logger of packet.
+func assignService(packet *Packet, services []servicePrefix) {
+	frame := topInAppFrame(packet)
+	if frame == nil {
+		return
+	}
+	for _, s := range services {
+		if frame.Module != s.prefix && !strings.HasPrefix(frame.Module, s.prefix+"/") {
+			continue
+		}
+		if packet.Logger == "" {
+			packet.Logger = s.service
+		}
+		for _, tag := range packet.Tags {
+			if tag.Key == "service" {
+				return
+			}
+		}
+		packet.Tags = append(packet.Tags, Tag{"service", s.service})
+		return
+	}
+}
@@ -0,0 +1,55 @@
+package raven
+
+import "testing"
+
+func TestSetServices(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetDefaultLoggerName("mono")
+	client.SetServices(map[string]string{
+		"github.com/acme/mono":                "mono",
+		"github.com/acme/mono/billing/":       "billing",
+		"github.com/acme/mono/billing/export": "export",
+	})
+
+	raisedIn := func(module, logger string) *Packet {
+		packet := NewPacket(module, &Stacktrace{Frames: []*StacktraceFrame{
+			{Module: module, Function: "Handle", InApp: true},
+			{Module: "database/sql", Function: "Query"},
+		}})
+		packet.Logger = logger
+		return packet
+	}
+
+	cases := []struct {
+		packet  *Packet
+		logger  string
+		service string
+	}{
+		{raisedIn("github.com/acme/mono/billing", ""), "billing", "billing"},
+		{raisedIn("github.com/acme/mono/billing/invoices", ""), "billing", "billing"},
+		{raisedIn("github.com/acme/mono/billing/export", ""), "export", "export"},
+		{raisedIn("github.com/acme/mono/billingv2", ""), "mono", "mono"},
+		{raisedIn("github.com/acme/mono/billing", "payments"), "payments", "billing"},
+		{raisedIn("github.com/acme/tools", ""), "mono", ""},
+		{NewPacket("no stack trace"), "mono", ""},
+	}
+	for _, test := range cases {
+		_, ch := client.Capture(test.packet, nil)
+		<-ch
+	}
+	for i, test := range cases {
+		sent := transport.packets[i]
+		if sent.Logger != test.logger {
+			t.Errorf("%s: incorrect logger: got %q, want %q", sent.Message, sent.Logger, test.logger)
+		}
+		var service string
+		for _, tag := range sent.Tags {
+			if tag.Key == "service" {
+				service = tag.Value
+			}
+		}
+		if service != test.service {
+			t.Errorf("%s: incorrect service: got %q, want %q", sent.Message, service, test.service)
+		}
+	}
+}
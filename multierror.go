@@ -0,0 +1,98 @@
+package raven
+
+import (
+	pkgErrors "github.com/pkg/errors"
+)
+
+// Errors combining several others, as built by errors.Join, fmt.Errorf with
+// several %w verbs, github.com/hashicorp/go-multierror and go.uber.org/multierr.
+type (
+	joinedErrors  interface{ Unwrap() []error }
+	wrappedErrors interface{ WrappedErrors() []error }
+	multiErrors   interface{ Errors() []error }
+)
+
+// splitErrors returns the errors combined by the first multi-error in the
+// chain of err, or nil if there is none.
+func splitErrors(err error) []error {
+	for ; err != nil; err = unwrap(err) {
+		var errs []error
+		switch e := err.(type) {
+		case joinedErrors:
+			errs = e.Unwrap()
+		case wrappedErrors:
+			errs = e.WrappedErrors()
+		case multiErrors:
+			errs = e.Errors()
+		default:
+			continue
+		}
+
+		var nonNil []error
+		for _, e := range errs {
+			if e != nil {
+				nonNil = append(nonNil, e)
+			}
+		}
+		return nonNil
+	}
+	return nil
+}
+
+// errorException builds the exception interface for a captured error. A
+// multi-error gets one exception value per error it combines, using their own
+// stack traces when they have one, and stacktrace for the last one otherwise.
+func errorException(err error, stacktrace *Stacktrace, appPackagePrefixes []string) Interface {
+	errs := splitErrors(err)
+	if len(errs) == 0 {
+		return NewException(pkgErrors.Cause(err), stacktrace)
+	}
+
+	exceptions := &Exceptions{}
+	for i, e := range errs {
+		cause := pkgErrors.Cause(e)
+		var st *Stacktrace
+		if hasStackTrace(e) || hasStackTrace(cause) {
+			st = GetOrNewStacktrace(e, cause, 0, 3, appPackagePrefixes)
+		} else if i == len(errs)-1 {
+			st = stacktrace
+		}
+		exceptions.Values = append(exceptions.Values, NewException(cause, st))
+	}
+	return exceptions
+}
+
+func hasStackTrace(err error) bool {
+	_, ok := err.(StackTracer)
+	return ok
+}
+
+// SetSplitMultiErrors makes CaptureError report each error combined by a
+// multi-error as an event of its own instead of one event with an exception
+// value per error. The events share a "multi_error.id" tag.
+func (client *Client) SetSplitMultiErrors(split bool) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.splitMultiErrors = split
+}
+
+// SetSplitMultiErrors controls whether the default *Client reports multi-errors as separate events
+func SetSplitMultiErrors(split bool) { DefaultClientInstance().SetSplitMultiErrors(split) }
+
+// captureSplitErrors reports each of errs as a separate event, returning the
+// ID of the first one.
+func (client *Client) captureSplitErrors(skip int, errs []error, tags map[string]string, interfaces []Interface) string {
+	id, _ := uuid()
+	shared := map[string]string{"multi_error.id": id}
+	for k, v := range tags {
+		shared[k] = v
+	}
+
+	var eventID string
+	for _, err := range errs {
+		if id := client.captureError(skip+1, err, shared, interfaces); eventID == "" {
+			eventID = id
+		}
+	}
+	return eventID
+}
@@ -0,0 +1,28 @@
+//go:build go1.20
+// +build go1.20
+
+package raven
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSplitJoinedErrors(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+	tests := []struct {
+		err      error
+		expected []error
+	}{
+		{errors.Join(first, nil, second), []error{first, second}},
+		{fmt.Errorf("both: %w, %w", first, second), []error{first, second}},
+	}
+
+	for i, test := range tests {
+		actual := splitErrors(test.err)
+		if fmt.Sprint(actual) != fmt.Sprint(test.expected) {
+			t.Errorf("%d: incorrect errors: got %v, want %v", i, actual, test.expected)
+		}
+	}
+}
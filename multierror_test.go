@@ -0,0 +1,90 @@
+package raven
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	pkgErrors "github.com/pkg/errors"
+)
+
+type testMultiError struct{ errs []error }
+
+func (e *testMultiError) Error() string          { return fmt.Sprintf("%d errors occurred", len(e.errs)) }
+func (e *testMultiError) WrappedErrors() []error { return e.errs }
+
+func TestSplitErrors(t *testing.T) {
+	first, second := errors.New("first"), errors.New("second")
+	tests := []struct {
+		err      error
+		expected []error
+	}{
+		{first, nil},
+		{pkgErrors.Wrap(&testMultiError{[]error{second, first}}, "batch"), []error{second, first}},
+	}
+
+	for i, test := range tests {
+		actual := splitErrors(test.err)
+		if fmt.Sprint(actual) != fmt.Sprint(test.expected) {
+			t.Errorf("%d: incorrect errors: got %v, want %v", i, actual, test.expected)
+		}
+	}
+}
+
+func TestCaptureMultiError(t *testing.T) {
+	client, transport := newTestClient()
+	client.CaptureError(&testMultiError{[]error{pkgErrors.New("disk full"), &validationError{"email"}}}, nil)
+	client.Wait()
+
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+	var exceptions *Exceptions
+	for _, inter := range transport.packets[0].Interfaces {
+		if e, ok := inter.(*Exceptions); ok {
+			exceptions = e
+		}
+	}
+	if exceptions == nil || len(exceptions.Values) != 2 {
+		t.Fatalf("incorrect exceptions: %+v", transport.packets[0].Interfaces)
+	}
+	if ex := exceptions.Values[0]; ex.Value != "disk full" || ex.Stacktrace == nil {
+		t.Errorf("incorrect first exception: %+v", ex)
+	}
+	if ex := exceptions.Values[1]; ex.Value != "invalid email" || ex.Type != "*raven.validationError" || ex.Stacktrace == nil {
+		t.Errorf("incorrect second exception: %+v", ex)
+	}
+}
+
+func TestCaptureSplitMultiErrors(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetSplitMultiErrors(true)
+
+	eventID := client.CaptureError(&testMultiError{[]error{errors.New("first"), errors.New("second")}}, map[string]string{"job": "sync"})
+	client.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("incorrect packet count: got %d, want 2", len(transport.packets))
+	}
+	if eventID != transport.packets[0].EventID {
+		t.Errorf("incorrect event ID: got %s, want %s", eventID, transport.packets[0].EventID)
+	}
+
+	var ids []string
+	for i, packet := range transport.packets {
+		if expected := []string{"first", "second"}[i]; packet.Message != expected {
+			t.Errorf("incorrect message: got %s, want %s", packet.Message, expected)
+		}
+		tags := map[string]string{}
+		for _, tag := range packet.Tags {
+			tags[tag.Key] = tag.Value
+		}
+		if tags["job"] != "sync" || tags["multi_error.id"] == "" {
+			t.Errorf("incorrect tags: %v", tags)
+		}
+		ids = append(ids, tags["multi_error.id"])
+	}
+	if ids[0] != ids[1] {
+		t.Errorf("events don't share their multi_error.id: %v", ids)
+	}
+}
@@ -0,0 +1,50 @@
+package raven
+
+import "errors"
+
+// A NATSPublisher publishes data to a NATS subject. *nats.Conn implements it.
+type NATSPublisher interface {
+	Publish(subject string, data []byte) error
+}
+
+// NATSPublisherFunc adapts a function to a NATSPublisher, e.g. to publish
+// through JetStream and wait for the stream to acknowledge the event:
+//
+//	raven.NATSPublisherFunc(func(subject string, data []byte) error {
+//		_, err := js.Publish(subject, data)
+//		return err
+//	})
+type NATSPublisherFunc func(subject string, data []byte) error
+
+func (f NATSPublisherFunc) Publish(subject string, data []byte) error { return f(subject, data) }
+
+// NATSTransport publishes events to a NATS subject as Sentry envelopes, for
+// fleets whose only way out is a message bus. Core NATS delivers at most once;
+// set JetStream for the events to be persisted until consumed.
+type NATSTransport struct {
+	Conn    NATSPublisher
+	Subject string
+
+	// Used instead of Conn when set.
+	JetStream NATSPublisher
+
+	// The DSN written to the envelope headers, telling the consumer where
+	// to forward the event. It should only hold the public key.
+	DSN string
+}
+
+func (t *NATSTransport) Send(url, authHeader string, packet *Packet) error {
+	publisher := t.Conn
+	if t.JetStream != nil {
+		publisher = t.JetStream
+	}
+	if publisher == nil {
+		return errors.New("raven: NATSTransport has no connection")
+	}
+
+	envelope, err := serializeEnvelope(packet, t.DSN)
+	if err != nil {
+		return err
+	}
+	return publisher.Publish(t.Subject, envelope)
+}
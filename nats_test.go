@@ -0,0 +1,41 @@
+package raven
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNATSTransport(t *testing.T) {
+	var core, jetStream []string
+	transport := &NATSTransport{
+		Conn:    NATSPublisherFunc(func(subject string, data []byte) error { core = append(core, subject); return nil }),
+		Subject: "sentry.events",
+	}
+
+	packet := NewPacket("failed")
+	packet.Init("1")
+	if err := transport.Send("", "", packet); err != nil {
+		t.Fatal(err)
+	}
+
+	var published []byte
+	transport.JetStream = NATSPublisherFunc(func(subject string, data []byte) error {
+		jetStream = append(jetStream, subject)
+		published = data
+		return nil
+	})
+	if err := transport.Send("", "", packet); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(core) != 1 || len(jetStream) != 1 || jetStream[0] != "sentry.events" {
+		t.Errorf("incorrect publications: core %v, JetStream %v", core, jetStream)
+	}
+	if packetJSON, _ := packet.JSON(); !bytes.Contains(published, packetJSON) {
+		t.Errorf("expected the event in the envelope, got %s", published)
+	}
+
+	if err := (&NATSTransport{}).Send("", "", packet); err == nil {
+		t.Error("expected an error without a connection")
+	}
+}
@@ -0,0 +1,70 @@
+package raven
+
+import "strconv"
+
+// A CaptureOutcome tells what became of a captured event.
+type CaptureOutcome int
+
+const (
+	// CaptureQueued means the event was queued for delivery.
+	CaptureQueued CaptureOutcome = iota
+
+	// CaptureSampledOut means the event was dropped by sampling.
+	CaptureSampledOut
+
+	// CaptureFiltered means the event was dropped by the ignored errors or
+	// an event processor.
+	CaptureFiltered
+
+	// CaptureDropped means the event could not be queued, e.g. because the
+	// queue was full, the packet was invalid or the client is nil.
+	CaptureDropped
+)
+
+func (o CaptureOutcome) String() string {
+	switch o {
+	case CaptureQueued:
+		return "queued"
+	case CaptureSampledOut:
+		return "sampled out"
+	case CaptureFiltered:
+		return "filtered"
+	case CaptureDropped:
+		return "dropped"
+	}
+	return "outcome(" + strconv.Itoa(int(o)) + ")"
+}
+
+// CaptureWithOutcome is like Capture, but tells without blocking whether the
+// event was queued or intentionally dropped, rather than returning a channel.
+// The event ID is empty unless the event was queued.
+func (client *Client) CaptureWithOutcome(packet *Packet, captureTags map[string]string) (string, CaptureOutcome) {
+	eventID, ch := client.Capture(packet, captureTags)
+
+	// Events dropped before reaching the queue have their channel resolved
+	// by the time Capture returns.
+	var err error
+	select {
+	case err = <-ch:
+	default:
+		return eventID, CaptureQueued
+	}
+	switch err {
+	case ErrSampledOut:
+		return "", CaptureSampledOut
+	case ErrFiltered:
+		return "", CaptureFiltered
+	case ErrPacketDropped:
+		return "", CaptureDropped
+	}
+	if eventID == "" {
+		return "", CaptureDropped
+	}
+	// The worker already delivered the event.
+	return eventID, CaptureQueued
+}
+
+// CaptureWithOutcome is like Capture with the default *Client, but tells whether the event was queued or dropped
+func CaptureWithOutcome(packet *Packet, captureTags map[string]string) (string, CaptureOutcome) {
+	return DefaultClientInstance().CaptureWithOutcome(packet, captureTags)
+}
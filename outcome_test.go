@@ -0,0 +1,36 @@
+package raven
+
+import "testing"
+
+func TestCaptureWithOutcome(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetIgnoreErrors([]string{"ignored"})
+
+	tests := []struct {
+		packet   *Packet
+		expected CaptureOutcome
+	}{
+		{NewPacket("sent"), CaptureQueued},
+		{NewPacket("ignored"), CaptureFiltered},
+		{nil, CaptureDropped},
+	}
+	for _, test := range tests {
+		eventID, outcome := client.CaptureWithOutcome(test.packet, nil)
+		if outcome != test.expected {
+			t.Errorf("incorrect outcome: got %s, want %s", outcome, test.expected)
+		}
+		if (eventID != "") != (outcome == CaptureQueued) {
+			t.Errorf("incorrect event ID for %s event: %q", outcome, eventID)
+		}
+	}
+
+	client.SetSampleRate(0)
+	if _, outcome := client.CaptureWithOutcome(NewPacket("sampled"), nil); outcome != CaptureSampledOut {
+		t.Errorf("incorrect outcome: got %s, want %s", outcome, CaptureSampledOut)
+	}
+
+	client.Wait()
+	if len(transport.packets) != 1 {
+		t.Errorf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+}
@@ -0,0 +1,165 @@
+package raven
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Ownership tags events with the owner of the code that raised them, so that
+// Sentry alert and routing rules can match on the "owner" tag without any
+// server-side ownership configuration.
+//
+// Owners are assigned by rules in a CODEOWNERS-like syntax: one rule per
+// line, a package path pattern followed by one or more owners, with the last
+// matching rule winning.
+//
+//	# Default owner
+//	*                                  @acme/platform
+//	github.com/acme/app/billing        @acme/billing
+//	github.com/acme/app/*/export       data@acme.com
+//
+// A pattern matches a package and its subpackages, and may contain the
+// wildcards supported by path.Match. The package of the top in-app frame of
+// the event is matched, and the first owner of the matching rule is used.
+//
+// Example:
+//
+//	owners, err := raven.LoadOwnership("OWNERS")
+//	if err != nil {
+//		log.Fatal(err)
+//	}
+//	client.AddEventProcessor(owners.Process)
+type Ownership struct {
+	rules []ownershipRule
+}
+
+type ownershipRule struct {
+	pattern string
+	owners  []string
+}
+
+// LoadOwnership reads ownership rules from the file at path.
+func LoadOwnership(path string) (*Ownership, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseOwnership(f)
+}
+
+// ParseOwnership reads ownership rules from r.
+func ParseOwnership(r io.Reader) (*Ownership, error) {
+	o := &Ownership{}
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) == 1 {
+			return nil, fmt.Errorf("raven: ownership rule on line %d has no owner", lineno)
+		}
+		pattern := strings.Trim(fields[0], "/")
+		if pattern == "" {
+			pattern = "*"
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("raven: invalid ownership pattern %q on line %d", fields[0], lineno)
+		}
+		o.rules = append(o.rules, ownershipRule{pattern, fields[1:]})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// Owner returns the first owner of the last rule matching the package path
+// pkg, or "" if none does.
+func (o *Ownership) Owner(pkg string) string {
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		if o.rules[i].matches(pkg) {
+			return o.rules[i].owners[0]
+		}
+	}
+	return ""
+}
+
+// Process is an EventProcessor tagging packet with its owner. Packets that
+// already have an owner tag, or no in-app frame, are left unchanged.
+func (o *Ownership) Process(packet *Packet, err error) bool {
+	for _, tag := range packet.Tags {
+		if tag.Key == "owner" {
+			return true
+		}
+	}
+	frame := topInAppFrame(packet)
+	if frame == nil {
+		return true
+	}
+	if owner := o.Owner(frame.Module); owner != "" {
+		packet.Tags = append(packet.Tags, Tag{"owner", owner})
+	}
+	return true
+}
+
+// matches reports whether pkg or one of its parent packages matches the
+// pattern of the rule.
+func (r ownershipRule) matches(pkg string) bool {
+	if r.pattern == "*" {
+		return true
+	}
+	for {
+		if ok, _ := path.Match(r.pattern, pkg); ok {
+			return true
+		}
+		i := strings.LastIndexByte(pkg, '/')
+		if i < 0 {
+			return false
+		}
+		pkg = pkg[:i]
+	}
+}
+
+// topInAppFrame returns the most recent in-app frame of the stack trace of
+// packet, preferring the outermost exception.
+func topInAppFrame(packet *Packet) *StacktraceFrame {
+	var stacktraces []*Stacktrace
+	for _, inter := range packet.Interfaces {
+		switch i := inter.(type) {
+		case *Exception:
+			stacktraces = append(stacktraces, i.Stacktrace)
+		case *Exceptions:
+			for j := len(i.Values) - 1; j >= 0; j-- {
+				stacktraces = append(stacktraces, i.Values[j].Stacktrace)
+			}
+		case Exceptions:
+			for j := len(i.Values) - 1; j >= 0; j-- {
+				stacktraces = append(stacktraces, i.Values[j].Stacktrace)
+			}
+		case *Stacktrace:
+			stacktraces = append(stacktraces, i)
+		}
+	}
+	for _, s := range stacktraces {
+		if s == nil {
+			continue
+		}
+		s.resolve()
+		for i := len(s.Frames) - 1; i >= 0; i-- {
+			if frame := s.Frames[i]; frame != nil && frame.InApp {
+				return frame
+			}
+		}
+	}
+	return nil
+}
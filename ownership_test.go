@@ -0,0 +1,81 @@
+package raven
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testOwnershipRules = `
+# Default owner
+*                                 @acme/platform
+github.com/acme/app/billing       @acme/billing billing@acme.com
+/github.com/acme/app/*/export/    data@acme.com  # trailing comment
+`
+
+func TestOwnershipOwner(t *testing.T) {
+	owners, err := ParseOwnership(strings.NewReader(testOwnershipRules))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		pkg   string
+		owner string
+	}{
+		{"github.com/acme/app", "@acme/platform"},
+		{"github.com/acme/app/billing", "@acme/billing"},
+		{"github.com/acme/app/billing/invoices", "@acme/billing"},
+		{"github.com/acme/app/billingv2", "@acme/platform"},
+		{"github.com/acme/app/users/export", "data@acme.com"},
+		{"github.com/acme/app/billing/export/csv", "data@acme.com"},
+	}
+	for _, test := range cases {
+		if owner := owners.Owner(test.pkg); owner != test.owner {
+			t.Errorf("incorrect owner of %s: got %q, want %q", test.pkg, owner, test.owner)
+		}
+	}
+
+	none, _ := ParseOwnership(strings.NewReader("github.com/acme/app/billing @acme/billing"))
+	if owner := none.Owner("github.com/acme/app"); owner != "" {
+		t.Errorf("incorrect owner: got %q, want none", owner)
+	}
+}
+
+func TestParseOwnershipErrors(t *testing.T) {
+	for _, rules := range []string{
+		"github.com/acme/app\n",
+		"* @acme/platform\ngithub.com/[acme @acme/billing\n",
+	} {
+		if _, err := ParseOwnership(strings.NewReader(rules)); err == nil {
+			t.Errorf("expected an error parsing %q", rules)
+		}
+	}
+}
+
+func TestOwnershipProcess(t *testing.T) {
+	owners, _ := ParseOwnership(strings.NewReader(testOwnershipRules))
+	stacktrace := &Stacktrace{Frames: []*StacktraceFrame{
+		{Module: "github.com/acme/app/billing", Function: "Charge", InApp: true},
+		{Module: "github.com/acme/app/users/export", Function: "Write", InApp: true},
+		{Module: "encoding/csv", Function: "Write"},
+	}}
+
+	packet := NewPacket("failed", &Exception{Type: "*errors.errorString", Stacktrace: stacktrace})
+	owners.Process(packet, nil)
+	if expected := (Tags{{"owner", "data@acme.com"}}); !reflect.DeepEqual(packet.Tags, expected) {
+		t.Errorf("incorrect tags: got %v, want %v", packet.Tags, expected)
+	}
+
+	tagged := NewPacket("failed", stacktrace)
+	tagged.Tags = Tags{{"owner", "@acme/billing"}}
+	owners.Process(tagged, nil)
+	if len(tagged.Tags) != 1 || tagged.Tags[0].Value != "@acme/billing" {
+		t.Errorf("owner tag overwritten: %v", tagged.Tags)
+	}
+
+	message := NewPacket("failed")
+	owners.Process(message, nil)
+	if len(message.Tags) != 0 {
+		t.Errorf("unexpected tags without stack trace: %v", message.Tags)
+	}
+}
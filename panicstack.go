@@ -0,0 +1,126 @@
+package raven
+
+import (
+	"bytes"
+	"reflect"
+	"runtime"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The maximum number of panic stacks SnapshotPanic keeps waiting to be
+// captured. Past it, an arbitrary one is forgotten.
+const maxPanicSnapshots = 256
+
+// How long a panic stack is kept waiting to be captured. Panics recovered
+// without being captured would otherwise leave theirs behind, to be reported
+// for a later panic of the same goroutine with the same value.
+var panicSnapshotTTL = 10 * time.Second
+
+type panicSnapshot struct {
+	value interface{}
+	pcs   []uintptr
+	taken time.Time
+}
+
+var (
+	panicSnapshotsMu sync.Mutex
+	panicSnapshots   = make(map[uint64]panicSnapshot)
+)
+
+// SnapshotPanic records the stack of a panic going through it, then lets the
+// panic go on. Deferred at the top of a function whose panics are recovered
+// further up by code that doesn't report them to Sentry, e.g. a third-party
+// middleware, it lets the recovery handlers of this package, and CaptureError
+// called with the panic value on the same goroutine, report the frames that
+// panicked rather than those of the recovery site.
+//
+// Example:
+//
+//	func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+//		defer raven.SnapshotPanic()
+//		...
+//	}
+func SnapshotPanic() {
+	rval := recover()
+	if rval == nil {
+		return
+	}
+
+	pcs := make([]uintptr, 64)
+	for {
+		n := runtime.Callers(2, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
+			break
+		}
+		pcs = make([]uintptr, 2*len(pcs))
+	}
+
+	now := time.Now()
+	panicSnapshotsMu.Lock()
+	if len(panicSnapshots) >= maxPanicSnapshots {
+		for id, snapshot := range panicSnapshots {
+			if now.Sub(snapshot.taken) > panicSnapshotTTL {
+				delete(panicSnapshots, id)
+			}
+		}
+	}
+	if len(panicSnapshots) >= maxPanicSnapshots {
+		for id := range panicSnapshots {
+			delete(panicSnapshots, id)
+			break
+		}
+	}
+	panicSnapshots[goroutineID()] = panicSnapshot{rval, pcs, now}
+	panicSnapshotsMu.Unlock()
+
+	panic(rval)
+}
+
+// recordedStacktrace returns the stack trace SnapshotPanic recorded on the
+// current goroutine for the panic value rval, or nil.
+func recordedStacktrace(rval interface{}, context int, appPackagePrefixes []string) *Stacktrace {
+	if rval == nil {
+		return nil
+	}
+	id := goroutineID()
+
+	panicSnapshotsMu.Lock()
+	snapshot, ok := panicSnapshots[id]
+	if ok && time.Since(snapshot.taken) > panicSnapshotTTL {
+		// Left behind by a panic recovered without being captured.
+		delete(panicSnapshots, id)
+		ok = false
+	}
+	if ok && samePanicValue(snapshot.value, rval) {
+		delete(panicSnapshots, id)
+	} else {
+		ok = false
+	}
+	panicSnapshotsMu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return stacktraceFromPCs(snapshot.pcs, context, appPackagePrefixes)
+}
+
+func samePanicValue(a, b interface{}) bool {
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	return ta == tb && ta != nil && ta.Comparable() && a == b
+}
+
+// goroutineID returns the ID of the current goroutine, as printed in its
+// traceback.
+func goroutineID() uint64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i != -1 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseUint(string(buf), 10, 64)
+	return id
+}
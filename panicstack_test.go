@@ -0,0 +1,105 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// thirdPartyRecover stands for a middleware recovering panics itself.
+func thirdPartyRecover(f func()) (rval interface{}) {
+	defer func() { rval = recover() }()
+	f()
+	return nil
+}
+
+func panickingHandler() {
+	defer SnapshotPanic()
+	panicHere()
+}
+
+func panicHere() { panic(errors.New("boom")) }
+
+func TestSnapshotPanic(t *testing.T) {
+	client, transport := newTestClient()
+
+	rval := thirdPartyRecover(panickingHandler)
+	err, ok := rval.(error)
+	if !ok || err.Error() != "boom" {
+		t.Fatalf("incorrect panic value: %v", rval)
+	}
+
+	client.CaptureError(err, nil)
+	client.Wait()
+
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+	ex := transport.packets[0].Interfaces[len(transport.packets[0].Interfaces)-1].(*Exception)
+	var found bool
+	for _, f := range ex.Stacktrace.Frames {
+		if f.Function == "panicHere" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("panic frames missing from %+v", frameValues(ex.Stacktrace))
+	}
+
+	if st := recordedStacktrace(err, 0, nil); st != nil {
+		t.Error("snapshot not forgotten once captured")
+	}
+}
+
+func TestSnapshotPanicNotPanicking(t *testing.T) {
+	func() {
+		defer SnapshotPanic()
+	}()
+
+	panicSnapshotsMu.Lock()
+	defer panicSnapshotsMu.Unlock()
+	if _, ok := panicSnapshots[goroutineID()]; ok {
+		t.Error("unexpected snapshot without a panic")
+	}
+}
+
+func TestSamePanicValue(t *testing.T) {
+	err := errors.New("boom")
+	tests := []struct {
+		a, b     interface{}
+		expected bool
+	}{
+		{err, err, true},
+		{err, errors.New("boom"), false},
+		{"boom", "boom", true},
+		{[]int{1}, []int{1}, false},
+		{nil, nil, false},
+	}
+
+	for i, test := range tests {
+		if actual := samePanicValue(test.a, test.b); actual != test.expected {
+			t.Errorf("%d: incorrect result: got %v, want %v", i, actual, test.expected)
+		}
+	}
+}
+
+func TestSnapshotPanicExpires(t *testing.T) {
+	defer func(ttl time.Duration) { panicSnapshotTTL = ttl }(panicSnapshotTTL)
+	panicSnapshotTTL = time.Millisecond
+
+	// A panic recovered without being captured leaves its snapshot behind.
+	rval := thirdPartyRecover(func() {
+		defer SnapshotPanic()
+		panic("boom")
+	})
+	time.Sleep(10 * time.Millisecond)
+
+	if st := recordedStacktrace(rval, 0, nil); st != nil {
+		t.Error("expected the snapshot to have expired")
+	}
+	panicSnapshotsMu.Lock()
+	defer panicSnapshotsMu.Unlock()
+	if _, ok := panicSnapshots[goroutineID()]; ok {
+		t.Error("expected the expired snapshot to be forgotten")
+	}
+}
@@ -0,0 +1,248 @@
+package raven
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ErrPacketPersisted is sent on the channel returned by Capture for packets
+// that were still queued when the client was closed and were saved to its
+// queue file instead of being delivered.
+var ErrPacketPersisted = errors.New("raven: packet saved for delivery after restart")
+
+// SetQueueFile makes the client save the packets still waiting in its queue to
+// path when it is closed, and re-enqueues any packets saved there by a previous
+// process. Deploy-time restarts then don't lose the errors that prompted the
+// deploy. It should be called after the DSN is set, since reloaded packets are
+// delivered right away.
+func (client *Client) SetQueueFile(path string) error {
+	client.mu.Lock()
+	client.queueFile = path
+	client.mu.Unlock()
+
+	if path == "" {
+		return nil
+	}
+	return client.loadQueue(path)
+}
+
+// SetQueueFile sets the queue file of the default *Client
+func SetQueueFile(path string) error { return DefaultClientInstance().SetQueueFile(path) }
+
+// ErrQueueFileCorrupted is returned when the queue file can't be decrypted
+// with the key set with SetQueueFileKey.
+var ErrQueueFileCorrupted = errors.New("raven: queue file can't be decrypted")
+
+// SetQueueFileKey makes the client encrypt its queue file with AES-GCM, so
+// that the personal data of undelivered events isn't written to disk in
+// plaintext. key must be 16, 24 or 32 bytes long; a nil key disables
+// encryption. It must be called before SetQueueFile to decrypt the packets
+// saved by a previous process.
+func (client *Client) SetQueueFileKey(key []byte) error {
+	var aead cipher.AEAD
+	if key != nil {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return err
+		}
+		if aead, err = cipher.NewGCM(block); err != nil {
+			return err
+		}
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.queueFileCipher = aead
+	return nil
+}
+
+// SetQueueFileKey sets the queue file key of the default *Client
+func SetQueueFileKey(key []byte) error { return DefaultClientInstance().SetQueueFileKey(key) }
+
+// loadQueue re-enqueues the packets saved to path. Packets are sent where
+// they were to be sent when saved, or to the current DSN if saved without a
+// target. Those that don't fit in the queue are saved back to path, which is
+// removed otherwise, and records that can't be read are skipped.
+func (client *Client) loadQueue(path string) error {
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	client.mu.RLock()
+	aead := client.queueFileCipher
+	codec := client.queueCodec
+	room := cap(client.queue) - len(client.queue)
+	if client.syncTimeout > 0 {
+		room = -1
+	}
+	client.mu.RUnlock()
+	if codec == nil {
+		codec = CanonicalCodec
+	}
+
+	if aead != nil {
+		if buf, err = openQueueFile(aead, buf); err != nil {
+			return err
+		}
+	}
+
+	pending, err := decodeRecords(codec, buf)
+	if err != nil {
+		debugf("raven: skipped unreadable records of queue file %s: %v", path, err)
+	}
+
+	var remaining []*outgoingPacket
+	if room >= 0 && len(pending) > room {
+		pending, remaining = pending[:room], pending[room:]
+	}
+	for _, p := range pending {
+		client.restoreTarget(p)
+		client.wg.Add(1)
+		client.enqueue(p.packet, p.url, p.authHeader, p.publicKey, make(chan error, 1))
+	}
+	if len(remaining) > 0 {
+		buf, _ := encodeRecords(codec, remaining)
+		return writeQueueFile(path, aead, buf)
+	}
+	return os.Remove(path)
+}
+
+// restoreTarget sets the auth header of p, read back from a queue file, which
+// is saved without it so that secret keys aren't written to disk. The client's
+// DSN and routes still sending to the URL of p with the same key provide it,
+// so that their secret key is sent if SetSendSecretKey asks to. Packets saved
+// without a target are sent to the current DSN.
+func (client *Client) restoreTarget(p *outgoingPacket) {
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+	if p.url == "" {
+		p.url, p.authHeader = client.url, client.authHeader
+		if client.dsn != nil {
+			p.publicKey = client.dsn.PublicKey
+		}
+		return
+	}
+	if client.dsn != nil && p.url == client.url && p.publicKey == client.dsn.PublicKey {
+		p.authHeader = client.authHeader
+		return
+	}
+	for _, route := range client.dsnRoutes {
+		if p.url == route.url && p.publicKey == route.dsn.PublicKey {
+			p.authHeader = route.authHeader
+			return
+		}
+	}
+	for _, route := range client.tenantRoutes {
+		if p.url == route.url && p.publicKey == route.dsn.PublicKey {
+			p.authHeader = route.authHeader
+			return
+		}
+	}
+	if p.publicKey != "" {
+		p.authHeader = client.authHeaderFor(&DSN{PublicKey: p.publicKey})
+	}
+}
+
+// persistQueue saves the packets waiting in the queue to path. It is
+// called on Close, before the queue is closed.
+func (client *Client) persistQueue(path string) error {
+	var pending []*outgoingPacket
+	for done := false; !done; {
+		select {
+		case p := <-client.queue:
+			pending = append(pending, p)
+		default:
+			done = true
+		}
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	client.mu.RLock()
+	aead := client.queueFileCipher
+	codec := client.queueCodec
+	client.mu.RUnlock()
+	if codec == nil {
+		codec = CanonicalCodec
+	}
+
+	for _, p := range pending {
+		p.packet.resolveStacktraces()
+	}
+	buf, errs := encodeRecords(codec, pending)
+	err := writeQueueFile(path, aead, buf)
+
+	for i, p := range pending {
+		switch {
+		case err != nil:
+			client.resolve(p.packet, p.ch, err)
+		case errs[i] != nil:
+			client.resolve(p.packet, p.ch, errs[i])
+		default:
+			client.resolve(p.packet, p.ch, ErrPacketPersisted)
+		}
+		client.wg.Done()
+	}
+	return err
+}
+
+// writeQueueFile writes buf to path, encrypted with aead if not nil. It writes
+// to a temporary file first so that a crash can't leave a truncated queue file
+// behind.
+func writeQueueFile(path string, aead cipher.AEAD, buf []byte) error {
+	var err error
+	if aead != nil {
+		buf, err = sealQueueFile(aead, buf)
+	}
+	var tmp *os.File
+	if err == nil {
+		tmp, err = ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	}
+	if err == nil {
+		_, err = tmp.Write(buf)
+		if cerr := tmp.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), path)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		}
+	}
+	return err
+}
+
+// sealQueueFile encrypts the contents of a queue file, prefixing them with a
+// random nonce.
+func sealQueueFile(aead cipher.AEAD, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// openQueueFile decrypts the contents of a queue file sealed by sealQueueFile.
+func openQueueFile(aead cipher.AEAD, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, ErrQueueFileCorrupted
+	}
+	nonce, ciphertext := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrQueueFileCorrupted
+	}
+	return plaintext, nil
+}
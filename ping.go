@@ -0,0 +1,71 @@
+package raven
+
+import (
+	"bytes"
+	gocontext "context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// Ping checks that the client can reach Sentry and that its DSN is accepted,
+// so that misconfiguration surfaces when a service boots rather than when the
+// first error is lost. It sends an envelope with no items, which Sentry
+// authenticates but doesn't store.
+func (client *Client) Ping(ctx gocontext.Context) error {
+	res, err := client.postEnvelope(ctx, []byte("{}\n"))
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode == http.StatusUnauthorized || res.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("raven: dsn rejected by %s: http status %d - x-sentry-error: %s", res.Request.URL.Host, res.StatusCode, res.Header.Get("X-Sentry-Error"))
+	default:
+		return fmt.Errorf("raven: got http status %d - x-sentry-error: %s", res.StatusCode, res.Header.Get("X-Sentry-Error"))
+	}
+}
+
+// postEnvelope sends an envelope to the envelope endpoint of the client's
+// project, with the HTTP client of its transport if it has one. The body of
+// the response is discarded.
+func (client *Client) postEnvelope(ctx gocontext.Context, envelope []byte) (*http.Response, error) {
+	client.mu.RLock()
+	storeURL, authHeader := client.url, client.authHeader
+	sdk := client.sdk
+	transport := client.Transport
+	client.mu.RUnlock()
+
+	if storeURL == "" {
+		return nil, ErrNotConfigured
+	}
+	envelopeURL := strings.TrimSuffix(storeURL, "store/") + "envelope/"
+
+	req, err := http.NewRequest("POST", envelopeURL, bytes.NewReader(envelope))
+	if err != nil {
+		return nil, fmt.Errorf("can't create new request: %v", err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("X-Sentry-Auth", authHeader)
+	req.Header.Set("User-Agent", sdk.UserAgent())
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+
+	httpClient := http.DefaultClient
+	if t, ok := transport.(*HTTPTransport); ok && t.Client != nil {
+		httpClient = t.Client
+	}
+	res, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	res.Body.Close()
+	return res, nil
+}
+
+// Ping checks that the default *Client can reach Sentry
+func Ping(ctx gocontext.Context) error { return DefaultClientInstance().Ping(ctx) }
@@ -0,0 +1,66 @@
+package raven
+
+import (
+	gocontext "context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/1/envelope/" {
+			t.Errorf("incorrect path: %s", r.URL.Path)
+		}
+		if !strings.Contains(r.Header.Get("X-Sentry-Auth"), "sentry_key=good") {
+			w.Header().Set("X-Sentry-Error", "invalid api key")
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client := newClient(nil)
+	client.url = ""
+	if err := client.Ping(gocontext.Background()); err != ErrNotConfigured {
+		t.Errorf("incorrect error: got %v, want %v", err, ErrNotConfigured)
+	}
+
+	client.SetDSN(strings.Replace(server.URL, "://", "://good@", 1) + "/1")
+	if err := client.Ping(gocontext.Background()); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	client.SetDSN(strings.Replace(server.URL, "://", "://bad@", 1) + "/1")
+	if err := client.Ping(gocontext.Background()); err == nil || !strings.Contains(err.Error(), "invalid api key") {
+		t.Errorf("expected the DSN to be rejected, got %v", err)
+	}
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	if err := client.Ping(ctx); err == nil {
+		t.Error("expected a canceled context to fail")
+	}
+}
+
+func TestPingWhileSettingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := newClient(nil)
+	client.SetDSN(strings.Replace(server.URL, "://", "://u@", 1) + "/1")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			client.SetTransport(NewHTTPTransport(TransportOptions{}))
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		if err := client.Ping(gocontext.Background()); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	<-done
+}
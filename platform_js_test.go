@@ -0,0 +1,25 @@
+package raven
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestJSEnvironment(t *testing.T) {
+	if name := detectHostname(); name != "" {
+		t.Errorf("incorrect hostname: got %q, want none", name)
+	}
+	if _, ok := defaultSourceCodeLoader().(noSourceCodeLoader); !ok {
+		t.Errorf("incorrect source code loader: %T", defaultSourceCodeLoader())
+	}
+
+	tr := NewHTTPTransport(TransportOptions{}).Client.Transport.(*http.Transport)
+	if tr.TLSClientConfig != nil {
+		t.Errorf("expected the browser to verify certificates, got %+v", tr.TLSClientConfig)
+	}
+
+	frames := NewStacktrace(0, 3, nil).Frames
+	if top := frames[len(frames)-1]; top.Function != "TestJSEnvironment" || top.ContextLine != "" {
+		t.Errorf("incorrect top frame: %+v", top)
+	}
+}
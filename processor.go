@@ -0,0 +1,32 @@
+package raven
+
+// An EventProcessor inspects a packet, along with the error it was built from
+// if any, right before it is queued for delivery. It may modify the packet in
+// place; returning false drops it.
+type EventProcessor func(packet *Packet, err error) bool
+
+// AddEventProcessor appends p to the processors run on every packet captured
+// by the client. Processors run in the order they were added.
+func (client *Client) AddEventProcessor(p EventProcessor) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.processors = append(client.processors, p)
+}
+
+// AddEventProcessor appends p to the processors of the default *Client
+func AddEventProcessor(p EventProcessor) { DefaultClientInstance().AddEventProcessor(p) }
+
+// processPacket runs the event processors on packet, reporting whether it
+// should still be sent.
+func (client *Client) processPacket(packet *Packet) bool {
+	client.mu.RLock()
+	processors := client.processors
+	client.mu.RUnlock()
+
+	for _, p := range processors {
+		if !p(packet, packet.err) {
+			return false
+		}
+	}
+	return true
+}
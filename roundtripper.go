@@ -0,0 +1,81 @@
+package raven
+
+import (
+	gocontext "context"
+	"fmt"
+	"net/http"
+)
+
+// RoundTripper wraps the transport of an HTTP client to report outbound
+// requests that fail, time out or panic, along with the scrubbed request.
+// Responses are reported according to the class of their status code.
+//
+// Example:
+//
+//	httpClient := &http.Client{Transport: &raven.RoundTripper{}}
+type RoundTripper struct {
+	// The transport making the requests, http.DefaultTransport if nil.
+	Base http.RoundTripper
+
+	// The client reporting failures, DefaultClient if nil.
+	Client *Client
+
+	// The classes of status codes reported, e.g. 4 for 4xx. Only 5xx
+	// responses are reported if nil.
+	StatusClasses []int
+
+	// Tags added to every event.
+	Tags map[string]string
+}
+
+func (t *RoundTripper) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	client := t.Client
+	if client == nil {
+		client = DefaultClientInstance()
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	defer func() {
+		if rval := recover(); rval != nil {
+			panicErr := &PanicError{Value: rval}
+			cause, ok := rval.(error)
+			if !ok {
+				cause = fmt.Errorf("%v", rval)
+			}
+			panicErr.EventID = client.CaptureHTTPError(nil, cause, t.Tags, newOutgoingHttp(req))
+			resp, err = nil, panicErr
+		}
+	}()
+
+	resp, err = base.RoundTrip(req)
+	if err != nil {
+		// The caller gave up on the request, that's not a failure.
+		if req.Context().Err() != gocontext.Canceled {
+			client.CaptureHTTPError(nil, err, t.Tags, newOutgoingHttp(req))
+		}
+		return resp, err
+	}
+
+	if t.reportStatus(resp.StatusCode) {
+		if resp.Request == nil {
+			resp.Request = req
+		}
+		client.CaptureHTTPError(resp, nil, t.Tags)
+	}
+	return resp, nil
+}
+
+func (t *RoundTripper) reportStatus(code int) bool {
+	if t.StatusClasses == nil {
+		return code >= 500 && code < 600
+	}
+	for _, class := range t.StatusClasses {
+		if code/100 == class {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,95 @@
+package raven
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/unavailable":
+			w.WriteHeader(503)
+			w.Write([]byte("try later"))
+		case "/missing":
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path          string
+		statusClasses []int
+		reported      bool
+	}{
+		{"/ok", nil, false},
+		{"/unavailable", nil, true},
+		{"/missing", nil, false},
+		{"/missing", []int{4, 5}, true},
+	}
+
+	for _, test := range tests {
+		client, transport := newTestClient()
+		httpClient := &http.Client{Transport: &RoundTripper{Client: client, StatusClasses: test.statusClasses}}
+
+		resp, err := httpClient.Get(server.URL + test.path + "?token=secret")
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		client.Wait()
+
+		if reported := len(transport.packets) == 1; reported != test.reported {
+			t.Errorf("%s: incorrect decision: got %v, want %v", test.path, reported, test.reported)
+		}
+		if test.path == "/unavailable" && string(body) != "try later" {
+			t.Errorf("incorrect body: got %q", body)
+		}
+	}
+}
+
+func TestRoundTripperFailures(t *testing.T) {
+	client, transport := newTestClient()
+	failure := errors.New("connection refused")
+	httpClient := &http.Client{Transport: &RoundTripper{
+		Client: client,
+		Base: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if req.URL.Path == "/panic" {
+				panic("boom")
+			}
+			return nil, failure
+		}),
+	}}
+
+	if _, err := httpClient.Get("http://api.example.com/fail?password=secret"); err == nil {
+		t.Error("expected the request to fail")
+	}
+	if _, err := httpClient.Get("http://api.example.com/panic"); err == nil {
+		t.Error("expected the panic to be returned as an error")
+	}
+	client.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.packets))
+	}
+	var req *Http
+	for _, inter := range transport.packets[0].Interfaces {
+		if h, ok := inter.(*Http); ok {
+			req = h
+		}
+	}
+	if req == nil || req.URL != "http://api.example.com/fail" || req.Query != "password=%2A%2A%2A%2A%2A%2A%2A%2A" {
+		t.Errorf("incorrect request: %+v", req)
+	}
+	if transport.packets[1].Message != "boom" {
+		t.Errorf("incorrect Message: got %s, want boom", transport.packets[1].Message)
+	}
+}
@@ -0,0 +1,97 @@
+package raven
+
+import (
+	gocontext "context"
+	"net/http"
+	"reflect"
+)
+
+// A RouteResolver returns the pattern of the route matching r, e.g.
+// "/users/{id}", or "" if it can't tell. The HTTP middleware uses it to name
+// events after routes rather than raw URLs, so that requests for different
+// resources group together.
+type RouteResolver func(r *http.Request) string
+
+type routePatterner interface {
+	RoutePattern() string
+}
+
+type pathTemplater interface {
+	GetPathTemplate() (string, error)
+}
+
+// ChiRoutes returns a RouteResolver for the chi router, given chi.RouteContext.
+// The middleware must be installed with Router.Use for the route to be known.
+//
+//	raven.SetRouteResolver(raven.ChiRoutes(chi.RouteContext))
+func ChiRoutes(routeContext interface{}) RouteResolver {
+	return routeAccessor("ChiRoutes", routeContext)
+}
+
+// GorillaRoutes returns a RouteResolver for gorilla/mux, given
+// mux.CurrentRoute. The middleware must be installed with Router.Use for the
+// route to be known.
+//
+//	raven.SetRouteResolver(raven.GorillaRoutes(mux.CurrentRoute))
+func GorillaRoutes(currentRoute interface{}) RouteResolver {
+	return routeAccessor("GorillaRoutes", currentRoute)
+}
+
+var (
+	requestType = reflect.TypeOf((*http.Request)(nil))
+	contextType = reflect.TypeOf((*gocontext.Context)(nil)).Elem()
+)
+
+// routeAccessor adapts a router function taking a request or its context and
+// returning its route, without depending on the router package.
+func routeAccessor(name string, accessor interface{}) RouteResolver {
+	f := reflect.ValueOf(accessor)
+	if f.Kind() != reflect.Func || f.Type().NumIn() != 1 || f.Type().NumOut() != 1 {
+		panic("raven: " + name + " expects a function taking a request or context and returning a route")
+	}
+	takesRequest := f.Type().In(0) == requestType
+	if !takesRequest && f.Type().In(0) != contextType {
+		panic("raven: " + name + " expects a function taking a request or context and returning a route")
+	}
+
+	return func(r *http.Request) string {
+		arg := reflect.ValueOf(r)
+		if !takesRequest {
+			arg = reflect.ValueOf(r.Context())
+		}
+		route := f.Call([]reflect.Value{arg})[0]
+		if (route.Kind() == reflect.Ptr || route.Kind() == reflect.Interface) && route.IsNil() {
+			return ""
+		}
+		switch route := route.Interface().(type) {
+		case routePatterner:
+			return route.RoutePattern()
+		case pathTemplater:
+			pattern, _ := route.GetPathTemplate()
+			return pattern
+		}
+		return ""
+	}
+}
+
+// SetRouteResolver sets how the HTTP middleware finds the route of a request.
+func (client *Client) SetRouteResolver(resolver RouteResolver) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.routeResolver = resolver
+}
+
+// SetRouteResolver sets the route resolver of the default *Client
+func SetRouteResolver(resolver RouteResolver) { DefaultClientInstance().SetRouteResolver(resolver) }
+
+// route returns the route pattern of r, "" if unknown.
+func (client *Client) route(r *http.Request) string {
+	client.mu.RLock()
+	resolver := client.routeResolver
+	client.mu.RUnlock()
+
+	if resolver == nil {
+		return ""
+	}
+	return resolver(r)
+}
@@ -0,0 +1,80 @@
+package raven
+
+import (
+	gocontext "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type testRouteKey struct{}
+
+// Mimics chi.Context and chi.RouteContext.
+type testChiContext struct{ pattern string }
+
+func (c *testChiContext) RoutePattern() string { return c.pattern }
+
+func testChiRouteContext(ctx gocontext.Context) *testChiContext {
+	rctx, _ := ctx.Value(testRouteKey{}).(*testChiContext)
+	return rctx
+}
+
+// Mimics mux.Route and mux.CurrentRoute.
+type testGorillaRoute struct{ template string }
+
+func (r *testGorillaRoute) GetPathTemplate() (string, error) { return r.template, nil }
+
+func testCurrentRoute(r *http.Request) *testGorillaRoute {
+	route, _ := r.Context().Value(testRouteKey{}).(*testGorillaRoute)
+	return route
+}
+
+func TestRouteResolvers(t *testing.T) {
+	req := httptest.NewRequest("GET", "/users/42", nil)
+
+	chi := ChiRoutes(testChiRouteContext)
+	if route := chi(req); route != "" {
+		t.Errorf("expected no route, got %s", route)
+	}
+	chiReq := req.WithContext(gocontext.WithValue(req.Context(), testRouteKey{}, &testChiContext{"/users/{id}"}))
+	if route := chi(chiReq); route != "/users/{id}" {
+		t.Errorf("incorrect route: got %s, want /users/{id}", route)
+	}
+
+	gorilla := GorillaRoutes(testCurrentRoute)
+	if route := gorilla(req); route != "" {
+		t.Errorf("expected no route, got %s", route)
+	}
+	gorillaReq := req.WithContext(gocontext.WithValue(req.Context(), testRouteKey{}, &testGorillaRoute{"/users/{id:[0-9]+}"}))
+	if route := gorilla(gorillaReq); route != "/users/{id:[0-9]+}" {
+		t.Errorf("incorrect route: got %s, want /users/{id:[0-9]+}", route)
+	}
+}
+
+func TestRouteResolverInvalidAccessor(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic")
+		}
+	}()
+	ChiRoutes(func(s string) string { return s })
+}
+
+func TestStatusRecovererRoute(t *testing.T) {
+	transport := &testTransport{}
+	defer func(t Transport) { DefaultClient.Transport = t }(DefaultClient.Transport)
+	DefaultClient.Transport = transport
+	defer SetRouteResolver(nil)
+	SetRouteResolver(ChiRoutes(testChiRouteContext))
+
+	handler := StatusRecoverer(http.HandlerFunc(failingHandler), func(status int) bool { return status >= 500 })
+	req := httptest.NewRequest("GET", "/users/42", nil)
+	req = req.WithContext(gocontext.WithValue(req.Context(), testRouteKey{}, &testChiContext{"/users/{id}"}))
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	DefaultClient.Wait()
+
+	packet := transport.packets[0]
+	if packet.Message != "GET /users/{id}: 503 Service Unavailable" || packet.Transaction != "/users/{id}" {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+}
@@ -1,4 +1,5 @@
 #!/bin/bash
 go test -race ./...
+go test -tags raven_minimal .
 go test -cover ./...
 go test -v ./...
@@ -0,0 +1,177 @@
+package raven
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The number of fingerprints a FingerprintSampler remembers before it starts
+// evicting expired ones.
+var MaxTrackedFingerprints = 10000
+
+// FingerprintSampler throttles repeat occurrences of the same issue: the first
+// occurrences of a fingerprint within a window are always sent, after which
+// only one in every n is. New issues are therefore never missed while noisy
+// ones are kept in check.
+//
+// Example:
+//
+//	sampler := raven.NewFingerprintSampler(10, 100, time.Hour)
+//	client.AddEventProcessor(sampler.Process)
+type FingerprintSampler struct {
+	first  int
+	every  int
+	window time.Duration
+
+	mu          sync.Mutex
+	seen        map[string]*fingerprintCount
+	coordinator FingerprintCoordinator
+}
+
+// A FingerprintCoordinator is shared by a fleet of processes so that they
+// collectively send at least one event for every new issue, however
+// aggressively each of them samples. It is typically backed by a shared store,
+// e.g. with Redis:
+//
+//	func (c *redisCoordinator) Claim(fingerprint string, ttl time.Duration) (bool, error) {
+//		return c.client.SetNX(ctx, "raven:"+fingerprint, 1, ttl).Result()
+//	}
+type FingerprintCoordinator interface {
+	// Claim reports whether the caller is the first process to see
+	// fingerprint within ttl.
+	Claim(fingerprint string, ttl time.Duration) (bool, error)
+}
+
+// SetCoordinator makes the sampler consult c on the first local occurrence
+// of each fingerprint. An occurrence claimed fleet-wide is always sent, even
+// when the local rates would drop it. Errors from c fall back to the local
+// decision.
+func (s *FingerprintSampler) SetCoordinator(c FingerprintCoordinator) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.coordinator = c
+}
+
+type fingerprintCount struct {
+	count int
+	since time.Time
+}
+
+// NewFingerprintSampler creates a sampler that sends the first occurrences of
+// each fingerprint seen within window, then one in every occurrences.
+func NewFingerprintSampler(first, every int, window time.Duration) *FingerprintSampler {
+	if every < 1 {
+		every = 1
+	}
+	return &FingerprintSampler{
+		first:  first,
+		every:  every,
+		window: window,
+		seen:   make(map[string]*fingerprintCount),
+	}
+}
+
+// Process is an EventProcessor applying the sampler to packet.
+func (s *FingerprintSampler) Process(packet *Packet, err error) bool {
+	key := fingerprintKey(packet)
+	count := s.observe(key, time.Now())
+
+	s.mu.Lock()
+	coordinator := s.coordinator
+	s.mu.Unlock()
+
+	if count == 1 && coordinator != nil {
+		if claimed, err := coordinator.Claim(key, s.window); err == nil && claimed {
+			if packet.Extra == nil {
+				packet.Extra = Extra{}
+			}
+			packet.Extra["sampling.first_occurrence"] = true
+			return true
+		}
+	}
+
+	if count <= s.first {
+		return true
+	}
+	if (count-s.first)%s.every != 0 {
+		return false
+	}
+	if packet.Extra == nil {
+		packet.Extra = Extra{}
+	}
+	packet.Extra["sampling.occurrences"] = count
+	return true
+}
+
+// observe records an occurrence of key, returning how many times it was seen
+// in the current window.
+func (s *FingerprintSampler) observe(key string, now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.seen[key]
+	if !ok || now.Sub(c.since) > s.window {
+		if !ok && len(s.seen) >= MaxTrackedFingerprints {
+			s.evict(now)
+		}
+		c = &fingerprintCount{since: now}
+		s.seen[key] = c
+	}
+	c.count++
+	return c.count
+}
+
+func (s *FingerprintSampler) evict(now time.Time) {
+	for key, c := range s.seen {
+		if now.Sub(c.since) > s.window {
+			delete(s.seen, key)
+		}
+	}
+	// Everything is still live: start over rather than grow without bound.
+	if len(s.seen) >= MaxTrackedFingerprints {
+		s.seen = make(map[string]*fingerprintCount)
+	}
+}
+
+// fingerprintKey identifies the issue a packet belongs to. An explicit
+// fingerprint wins; the default grouping uses the exception types and culprit,
+// falling back to the message.
+func fingerprintKey(packet *Packet) string {
+	var parts []string
+	useDefault := len(packet.Fingerprint) == 0
+	for _, f := range packet.Fingerprint {
+		if f == "{{ default }}" {
+			useDefault = true
+		} else {
+			parts = append(parts, f)
+		}
+	}
+
+	if useDefault {
+		for _, inter := range packet.Interfaces {
+			switch e := inter.(type) {
+			case *Exception:
+				parts = append(parts, e.Type)
+			case *Exceptions:
+				for _, ex := range e.Values {
+					parts = append(parts, ex.Type)
+				}
+			case Exceptions:
+				for _, ex := range e.Values {
+					parts = append(parts, ex.Type)
+				}
+			}
+		}
+		if packet.Culprit != "" {
+			parts = append(parts, packet.Culprit)
+		} else {
+			parts = append(parts, packet.Message)
+		}
+	}
+
+	sum := sha1.Sum([]byte(strings.Join(parts, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
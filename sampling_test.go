@@ -0,0 +1,106 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFingerprintSampler(t *testing.T) {
+	sampler := NewFingerprintSampler(2, 3, time.Hour)
+
+	var sent []int
+	for i := 1; i <= 9; i++ {
+		packet := &Packet{Message: "timeout", Culprit: "main.handler"}
+		if sampler.Process(packet, nil) {
+			sent = append(sent, i)
+		}
+	}
+	expected := []int{1, 2, 5, 8}
+	if len(sent) != len(expected) {
+		t.Fatalf("incorrect occurrences sent: got %v, want %v", sent, expected)
+	}
+	for i := range sent {
+		if sent[i] != expected[i] {
+			t.Fatalf("incorrect occurrences sent: got %v, want %v", sent, expected)
+		}
+	}
+
+	// a new issue is always sent
+	if !sampler.Process(&Packet{Message: "timeout", Culprit: "main.other"}, nil) {
+		t.Error("expected a new fingerprint to be sent")
+	}
+}
+
+func TestFingerprintSamplerWindow(t *testing.T) {
+	sampler := NewFingerprintSampler(1, 100, time.Minute)
+	now := time.Now()
+
+	if sampler.observe("a", now) != 1 || sampler.observe("a", now) != 2 {
+		t.Fatal("expected occurrences to be counted")
+	}
+	if count := sampler.observe("a", now.Add(2*time.Minute)); count != 1 {
+		t.Errorf("expected the count to reset after the window, got %d", count)
+	}
+}
+
+func TestFingerprintKey(t *testing.T) {
+	exception := func(err error) *Packet {
+		return &Packet{Message: err.Error(), Culprit: "main.f", Interfaces: []Interface{NewException(err, nil)}}
+	}
+
+	if fingerprintKey(exception(errors.New("a"))) != fingerprintKey(exception(errors.New("b"))) {
+		t.Error("expected errors of the same type and culprit to share a key")
+	}
+	if fingerprintKey(&Packet{Message: "a"}) == fingerprintKey(&Packet{Message: "b"}) {
+		t.Error("expected messages to have different keys")
+	}
+	if fingerprintKey(&Packet{Message: "a", Fingerprint: []string{"x"}}) != fingerprintKey(&Packet{Message: "b", Fingerprint: []string{"x"}}) {
+		t.Error("expected explicit fingerprints to take precedence")
+	}
+	if fingerprintKey(&Packet{Message: "a", Fingerprint: []string{"{{ default }}", "x"}}) == fingerprintKey(&Packet{Message: "b", Fingerprint: []string{"{{ default }}", "x"}}) {
+		t.Error("expected the default grouping to be included")
+	}
+}
+
+type testCoordinator struct {
+	claimed map[string]bool
+	err     error
+}
+
+func (c *testCoordinator) Claim(fingerprint string, ttl time.Duration) (bool, error) {
+	if c.err != nil {
+		return false, c.err
+	}
+	if c.claimed[fingerprint] {
+		return false, nil
+	}
+	c.claimed[fingerprint] = true
+	return true, nil
+}
+
+func TestFingerprintSamplerCoordinator(t *testing.T) {
+	coordinator := &testCoordinator{claimed: make(map[string]bool)}
+
+	// two processes that never send an occurrence on their own
+	a := NewFingerprintSampler(0, 1000, time.Hour)
+	b := NewFingerprintSampler(0, 1000, time.Hour)
+	a.SetCoordinator(coordinator)
+	b.SetCoordinator(coordinator)
+
+	packet := &Packet{Message: "new issue"}
+	if !a.Process(packet, nil) {
+		t.Error("expected the first occurrence in the fleet to be sent")
+	}
+	if packet.Extra["sampling.first_occurrence"] != true {
+		t.Errorf("expected the packet to be marked, got %+v", packet.Extra)
+	}
+	if b.Process(&Packet{Message: "new issue"}, nil) {
+		t.Error("expected the occurrence already claimed to be dropped")
+	}
+
+	coordinator.err = errors.New("redis is down")
+	if a.Process(&Packet{Message: "another issue"}, nil) {
+		t.Error("expected errors to fall back to the local decision")
+	}
+}
@@ -0,0 +1,93 @@
+package raven
+
+import gocontext "context"
+
+const sdkModule = "github.com/getsentry/raven-go"
+
+// The version of this package, read from the build info of the binary when
+// it was built with modules.
+var sdkVersion = moduleVersion()
+
+// An SDKPackage is a package making up the SDK.
+type SDKPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// SDKInfo identifies the SDK sending an event. Relays route and rate limit
+// events by SDK, and it makes up the User-Agent of HTTP requests.
+// https://develop.sentry.dev/sdk/event-payloads/sdk/
+type SDKInfo struct {
+	Name     string       `json:"name"`
+	Version  string       `json:"version"`
+	Packages []SDKPackage `json:"packages,omitempty"`
+
+	// Appended to the User-Agent, e.g. "billing-service/2.3".
+	app string
+}
+
+func defaultSDK() SDKInfo {
+	return SDKInfo{
+		Name:     "raven-go",
+		Version:  sdkVersion,
+		Packages: []SDKPackage{{"go:" + sdkModule, sdkVersion}},
+	}
+}
+
+// UserAgent returns the User-Agent identifying the SDK in HTTP requests.
+func (s *SDKInfo) UserAgent() string {
+	ua := s.Name + "/" + s.Version
+	if s.app != "" {
+		ua += " " + s.app
+	}
+	return ua
+}
+
+// SetSDK overrides the name and version of the SDK reported with events and
+// in the User-Agent, e.g. for a wrapper around this package.
+func (client *Client) SetSDK(name, version string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.sdk.Name = name
+	client.sdk.Version = version
+	client.updateAuthHeader()
+}
+
+// SetSDK overrides the SDK identification of the default *Client
+func SetSDK(name, version string) { DefaultClientInstance().SetSDK(name, version) }
+
+// SetAppIdentifier appends app, e.g. "billing-service/2.3", to the User-Agent
+// of requests sent to Sentry.
+func (client *Client) SetAppIdentifier(app string) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.sdk.app = app
+}
+
+// SetAppIdentifier sets the application identifier of the default *Client
+func SetAppIdentifier(app string) { DefaultClientInstance().SetAppIdentifier(app) }
+
+// userAgent returns the User-Agent to send packet with.
+func (packet *Packet) userAgent() string {
+	if packet.SDK == nil {
+		return userAgent
+	}
+	return packet.SDK.UserAgent()
+}
+
+// userAgentKey is the context key of the User-Agent to send an envelope with.
+type userAgentKey struct{}
+
+// withUserAgent returns a copy of ctx making SendEnvelope send the envelope
+// with the User-Agent ua, as events are sent with that of their client.
+func withUserAgent(ctx gocontext.Context, ua string) gocontext.Context {
+	return gocontext.WithValue(ctx, userAgentKey{}, ua)
+}
+
+// contextUserAgent returns the User-Agent to send an envelope with in ctx.
+func contextUserAgent(ctx gocontext.Context) string {
+	if ua, ok := ctx.Value(userAgentKey{}).(string); ok {
+		return ua
+	}
+	return userAgent
+}
@@ -0,0 +1,53 @@
+package raven
+
+import (
+	gocontext "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSDKIdentification(t *testing.T) {
+	userAgents := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	client, _ := New("http://public@" + server.Listener.Addr().String() + "/1")
+	client.SetSDK("acme-raven", "2.0.1")
+	client.SetAppIdentifier("billing/3.4")
+
+	packet := NewPacket("failed")
+	_, ch := client.Capture(packet, nil)
+	if err := <-ch; err != nil {
+		t.Fatal(err)
+	}
+
+	if ua := <-userAgents; ua != "acme-raven/2.0.1 billing/3.4" {
+		t.Errorf("incorrect User-Agent: got %s, want acme-raven/2.0.1 billing/3.4", ua)
+	}
+	if packet.SDK == nil || packet.SDK.Name != "acme-raven" || len(packet.SDK.Packages) != 1 {
+		t.Errorf("incorrect SDK: %+v", packet.SDK)
+	}
+}
+
+func TestSDKIdentificationOfEnvelopes(t *testing.T) {
+	userAgents := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userAgents <- r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	client, _ := New("http://public@" + server.Listener.Addr().String() + "/1")
+	client.SetSDK("acme-raven", "2.0.1")
+	client.SetAppIdentifier("billing/3.4")
+
+	if _, err := client.CaptureCheckIn(gocontext.Background(), &CheckIn{MonitorSlug: "nightly", Status: "ok"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if ua := <-userAgents; ua != "acme-raven/2.0.1 billing/3.4" {
+		t.Errorf("incorrect User-Agent: got %s, want acme-raven/2.0.1 billing/3.4", ua)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build go1.12
+// +build go1.12
+
+package raven
+
+import "runtime/debug"
+
+// moduleVersion returns the version of this package from the build info of
+// the binary, or "1.0" if it wasn't built with modules.
+func moduleVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == sdkModule && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			return info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if dep.Path == sdkModule {
+				return dep.Version
+			}
+		}
+	}
+	return "1.0"
+}
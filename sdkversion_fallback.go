@@ -0,0 +1,10 @@
+//go:build !go1.12
+// +build !go1.12
+
+package raven
+
+// moduleVersion returns "1.0": Go releases before 1.12 don't record build
+// info in binaries.
+func moduleVersion() string {
+	return "1.0"
+}
@@ -0,0 +1,213 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/getsentry/raven-go"
+)
+
+type layer struct {
+	// The client capturing the events of the layer, the default one if nil.
+	client *raven.Client
+	scope  *Scope
+}
+
+// Hub manages a stack of scopes bound to a client. Each goroutine handling a
+// request would typically work on its own clone of CurrentHub, carried in
+// its context.
+type Hub struct {
+	mu          sync.RWMutex
+	stack       []*layer
+	lastEventID EventID
+}
+
+var currentHub = NewHub(nil, NewScope())
+
+// NewHub returns a hub with a single scope, bound to client. A nil client
+// stands for the default client of the raven package, including one installed
+// later with raven.SetDefaultClient or Init.
+func NewHub(client *raven.Client, scope *Scope) *Hub {
+	return &Hub{stack: []*layer{{client, scope}}}
+}
+
+// CurrentHub returns the hub used by the package-level functions.
+func CurrentHub() *Hub {
+	return currentHub
+}
+
+func (hub *Hub) top() *layer {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return hub.stack[len(hub.stack)-1]
+}
+
+// Client returns the client the top scope is bound to.
+func (hub *Hub) Client() *raven.Client {
+	if client := hub.top().client; client != nil {
+		return client
+	}
+	return raven.DefaultClientInstance()
+}
+
+// Scope returns the top scope.
+func (hub *Hub) Scope() *Scope {
+	return hub.top().scope
+}
+
+// BindClient binds the top scope to client.
+func (hub *Hub) BindClient(client *raven.Client) {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	top := hub.stack[len(hub.stack)-1]
+	hub.stack[len(hub.stack)-1] = &layer{client, top.scope}
+}
+
+// PushScope pushes a clone of the top scope, and returns it.
+func (hub *Hub) PushScope() *Scope {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	top := hub.stack[len(hub.stack)-1]
+	scope := top.scope.Clone()
+	hub.stack = append(hub.stack, &layer{top.client, scope})
+	return scope
+}
+
+// PopScope removes the top scope, unless it is the only one.
+func (hub *Hub) PopScope() {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	if len(hub.stack) > 1 {
+		hub.stack = hub.stack[:len(hub.stack)-1]
+	}
+}
+
+// WithScope calls f with a temporary clone of the top scope, pushed for the
+// duration of the call.
+func (hub *Hub) WithScope(f func(scope *Scope)) {
+	scope := hub.PushScope()
+	defer hub.PopScope()
+	f(scope)
+}
+
+// ConfigureScope calls f with the top scope.
+func (hub *Hub) ConfigureScope(f func(scope *Scope)) {
+	f(hub.Scope())
+}
+
+// Clone returns a hub with a clone of the top scope, bound to the same client.
+func (hub *Hub) Clone() *Hub {
+	top := hub.top()
+	return NewHub(top.client, top.scope.Clone())
+}
+
+// LastEventID returns the ID of the last event captured through the hub.
+func (hub *Hub) LastEventID() EventID {
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	return hub.lastEventID
+}
+
+// AddBreadcrumb records b on the top scope. The timestamp defaults to now.
+func (hub *Hub) AddBreadcrumb(b *Breadcrumb) {
+	if b == nil {
+		return
+	}
+	if time.Time(b.Timestamp).IsZero() {
+		b.Timestamp = raven.Timestamp(time.Now())
+	}
+	hub.Scope().AddBreadcrumb(b, 0)
+}
+
+// CaptureException captures err along with the top scope, and returns the ID
+// of the event or nil if it was not sent.
+func (hub *Hub) CaptureException(err error) *EventID {
+	return hub.captureException(1, err, "")
+}
+
+// captureException captures err with a stack trace leaving out its caller's
+// skip frames.
+func (hub *Hub) captureException(skip int, err error, level Level) *EventID {
+	if err == nil {
+		return nil
+	}
+	client, scope := hub.Client(), hub.Scope()
+	packet := client.NewErrorPacket(skip+1, err, scope.interfaces()...)
+	packet.Level = level
+	return hub.capture(client, scope, packet)
+}
+
+// CaptureMessage captures message along with the top scope, and returns the
+// ID of the event or nil if it was not sent.
+func (hub *Hub) CaptureMessage(message string) *EventID {
+	client, scope := hub.Client(), hub.Scope()
+	packet := raven.NewPacket(message, append(scope.interfaces(), &raven.Message{Message: message})...)
+	packet.Level = LevelInfo
+	return hub.capture(client, scope, packet)
+}
+
+func (hub *Hub) capture(client *raven.Client, scope *Scope, packet *raven.Packet) *EventID {
+	tags := scope.applyToPacket(packet)
+	eventID, _ := client.Capture(packet, tags)
+	if eventID == "" {
+		return nil
+	}
+
+	id := EventID(eventID)
+	hub.mu.Lock()
+	hub.lastEventID = id
+	hub.mu.Unlock()
+	return &id
+}
+
+// Recover captures the value of a recovered panic at the fatal level, and
+// returns the ID of the event or nil if it was not sent:
+//
+//	defer func() {
+//		if err := recover(); err != nil {
+//			hub.Recover(err)
+//		}
+//	}()
+func (hub *Hub) Recover(err interface{}) *EventID {
+	return hub.recover(1, err)
+}
+
+func (hub *Hub) recover(skip int, err interface{}) *EventID {
+	switch err := err.(type) {
+	case nil:
+		return nil
+	case error:
+		return hub.captureException(skip+1, err, LevelFatal)
+	case string:
+		return hub.captureException(skip+1, errors.New(err), LevelFatal)
+	default:
+		return hub.captureException(skip+1, fmt.Errorf("%v", err), LevelFatal)
+	}
+}
+
+// Flush waits up to timeout for the client to send all events, and reports
+// whether it did in time.
+func (hub *Hub) Flush(timeout time.Duration) bool {
+	return hub.Client().Flush(timeout)
+}
+
+type hubContextKey struct{}
+
+// SetHubOnContext returns a copy of ctx carrying hub.
+func SetHubOnContext(ctx context.Context, hub *Hub) context.Context {
+	return context.WithValue(ctx, hubContextKey{}, hub)
+}
+
+// HubFromContext returns the hub carried by ctx, or nil.
+func HubFromContext(ctx context.Context) *Hub {
+	hub, _ := ctx.Value(hubContextKey{}).(*Hub)
+	return hub
+}
+
+// HasHubOnContext reports whether ctx carries a hub.
+func HasHubOnContext(ctx context.Context) bool {
+	return HubFromContext(ctx) != nil
+}
@@ -0,0 +1,193 @@
+package sentry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/raven-go"
+)
+
+type testTransport struct {
+	mu      sync.Mutex
+	packets []*raven.Packet
+}
+
+func (t *testTransport) Send(url, authHeader string, packet *raven.Packet) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.packets = append(t.packets, packet)
+	return nil
+}
+
+func newTestHub() (*Hub, *testTransport) {
+	transport := &testTransport{}
+	client, _ := raven.New("")
+	client.SetTransport(transport)
+	client.SetIncludePaths([]string{"github.com/getsentry/raven-go/sentry"})
+	return NewHub(client, NewScope()), transport
+}
+
+func tagValue(packet *raven.Packet, key string) string {
+	for _, tag := range packet.Tags {
+		if tag.Key == key {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+func TestHubCaptureException(t *testing.T) {
+	hub, transport := newTestHub()
+	hub.ConfigureScope(func(scope *Scope) {
+		scope.SetTag("region", "eu")
+		scope.SetExtra("attempt", 3)
+		scope.SetLevel(LevelWarning)
+		scope.SetFingerprint([]string{"payments"})
+		scope.SetUser(User{ID: "42"})
+	})
+	hub.AddBreadcrumb(&Breadcrumb{Message: "charging"})
+
+	eventID := hub.CaptureException(errors.New("card declined"))
+	hub.Client().Wait()
+
+	if eventID == nil || *eventID != hub.LastEventID() {
+		t.Fatalf("incorrect event ID: got %v, want %s", eventID, hub.LastEventID())
+	}
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+	packet := transport.packets[0]
+	if expected := "github.com/getsentry/raven-go/sentry.TestHubCaptureException"; packet.Culprit != expected {
+		t.Errorf("incorrect culprit: got %s, want %s", packet.Culprit, expected)
+	}
+	if got := tagValue(packet, "region"); got != "eu" {
+		t.Errorf("incorrect region tag: got %s, want eu", got)
+	}
+	if packet.Extra["attempt"] != 3 {
+		t.Errorf("incorrect extra: got %v, want attempt=3", packet.Extra)
+	}
+	if packet.Level != LevelWarning {
+		t.Errorf("incorrect level: got %s, want %s", packet.Level, LevelWarning)
+	}
+	if len(packet.Fingerprint) != 1 || packet.Fingerprint[0] != "payments" {
+		t.Errorf("incorrect fingerprint: got %v, want [payments]", packet.Fingerprint)
+	}
+
+	var user *User
+	var crumbs *raven.Breadcrumbs
+	for _, inter := range packet.Interfaces {
+		switch inter := inter.(type) {
+		case *User:
+			user = inter
+		case *raven.Breadcrumbs:
+			crumbs = inter
+		}
+	}
+	if user == nil || user.ID != "42" {
+		t.Errorf("incorrect user: got %+v, want ID 42", user)
+	}
+	if crumbs == nil || len(crumbs.Values) != 1 || crumbs.Values[0].Message != "charging" {
+		t.Errorf("incorrect breadcrumbs: got %+v, want [charging]", crumbs)
+	}
+}
+
+func TestHubWithScope(t *testing.T) {
+	hub, transport := newTestHub()
+	hub.Scope().SetTag("region", "eu")
+
+	hub.WithScope(func(scope *Scope) {
+		scope.SetTag("job", "billing")
+		hub.CaptureMessage("inside")
+	})
+	hub.CaptureMessage("outside")
+	hub.Client().Wait()
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("incorrect packet count: got %d, want 2", len(transport.packets))
+	}
+	for i, want := range []string{"billing", ""} {
+		packet := transport.packets[i]
+		if got := tagValue(packet, "job"); got != want {
+			t.Errorf("incorrect job tag of %q: got %q, want %q", packet.Message, got, want)
+		}
+		if got := tagValue(packet, "region"); got != "eu" {
+			t.Errorf("incorrect region tag of %q: got %q, want eu", packet.Message, got)
+		}
+		if packet.Level != LevelInfo {
+			t.Errorf("incorrect level of %q: got %s, want %s", packet.Message, packet.Level, LevelInfo)
+		}
+	}
+}
+
+func TestHubRecover(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		message string
+	}{
+		{errors.New("boom"), "boom"},
+		{"boom", "boom"},
+		{42, "42"},
+	}
+	for _, test := range tests {
+		hub, transport := newTestHub()
+		func() {
+			defer func() {
+				hub.Recover(recover())
+			}()
+			panic(test.value)
+		}()
+		hub.Client().Wait()
+
+		if len(transport.packets) != 1 {
+			t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+		}
+		packet := transport.packets[0]
+		if packet.Message != test.message {
+			t.Errorf("incorrect message: got %s, want %s", packet.Message, test.message)
+		}
+		if packet.Level != LevelFatal {
+			t.Errorf("incorrect level: got %s, want %s", packet.Level, LevelFatal)
+		}
+	}
+
+	hub, _ := newTestHub()
+	if eventID := hub.Recover(nil); eventID != nil {
+		t.Errorf("incorrect event ID: got %s, want nil", *eventID)
+	}
+}
+
+func TestHubContext(t *testing.T) {
+	ctx := context.Background()
+	if HasHubOnContext(ctx) {
+		t.Error("background context carries a hub")
+	}
+
+	hub := CurrentHub().Clone()
+	ctx = SetHubOnContext(ctx, hub)
+	if got := HubFromContext(ctx); got != hub {
+		t.Errorf("incorrect hub: got %p, want %p", got, hub)
+	}
+}
+
+func TestCurrentHubFollowsDefaultClient(t *testing.T) {
+	defer raven.SetDefaultClient(nil)
+
+	transport := &testTransport{}
+	if err := Init(ClientOptions{Transport: transport}); err != nil {
+		t.Fatal(err)
+	}
+	if got := CurrentHub().Client(); got != raven.DefaultClientInstance() {
+		t.Errorf("incorrect client: got %p, want %p", got, raven.DefaultClientInstance())
+	}
+
+	CaptureMessage("hello")
+	if !Flush(time.Second) {
+		t.Fatal("events were not flushed")
+	}
+	if len(transport.packets) != 1 {
+		t.Errorf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+}
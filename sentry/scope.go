@@ -0,0 +1,242 @@
+package sentry
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/getsentry/raven-go"
+)
+
+// Scope holds the data sent with the events captured through a Hub, on top of
+// that of the client.
+type Scope struct {
+	mu          sync.RWMutex
+	breadcrumbs []*Breadcrumb
+	user        User
+	tags        map[string]string
+	contexts    map[string]interface{}
+	extra       map[string]interface{}
+	fingerprint []string
+	level       Level
+	request     *http.Request
+}
+
+// NewScope returns an empty scope.
+func NewScope() *Scope {
+	return &Scope{}
+}
+
+// AddBreadcrumb records b, keeping at most limit breadcrumbs, or
+// raven.MaxBreadcrumbs if limit is 0.
+func (scope *Scope) AddBreadcrumb(b *Breadcrumb, limit int) {
+	if limit == 0 {
+		limit = raven.MaxBreadcrumbs
+	}
+	if limit < 0 {
+		return
+	}
+
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.breadcrumbs = append(scope.breadcrumbs, b)
+	if len(scope.breadcrumbs) > limit {
+		scope.breadcrumbs = scope.breadcrumbs[len(scope.breadcrumbs)-limit:]
+	}
+}
+
+// ClearBreadcrumbs removes the breadcrumbs of the scope.
+func (scope *Scope) ClearBreadcrumbs() {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.breadcrumbs = nil
+}
+
+// SetUser sets the user the events are about.
+func (scope *Scope) SetUser(user User) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.user = user
+}
+
+// SetRequest sets the HTTP request the events happened while serving.
+func (scope *Scope) SetRequest(r *http.Request) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.request = r
+}
+
+// SetTag sets a tag.
+func (scope *Scope) SetTag(key, value string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if scope.tags == nil {
+		scope.tags = make(map[string]string)
+	}
+	scope.tags[key] = value
+}
+
+// SetTags sets several tags.
+func (scope *Scope) SetTags(tags map[string]string) {
+	for k, v := range tags {
+		scope.SetTag(k, v)
+	}
+}
+
+// RemoveTag removes a tag.
+func (scope *Scope) RemoveTag(key string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	delete(scope.tags, key)
+}
+
+// SetContext sets a named context, e.g. "os" or an application specific one.
+func (scope *Scope) SetContext(key string, value interface{}) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if scope.contexts == nil {
+		scope.contexts = make(map[string]interface{})
+	}
+	scope.contexts[key] = value
+}
+
+// RemoveContext removes a named context.
+func (scope *Scope) RemoveContext(key string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	delete(scope.contexts, key)
+}
+
+// SetExtra sets an extra value.
+func (scope *Scope) SetExtra(key string, value interface{}) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	if scope.extra == nil {
+		scope.extra = make(map[string]interface{})
+	}
+	scope.extra[key] = value
+}
+
+// SetExtras sets several extra values.
+func (scope *Scope) SetExtras(extra map[string]interface{}) {
+	for k, v := range extra {
+		scope.SetExtra(k, v)
+	}
+}
+
+// RemoveExtra removes an extra value.
+func (scope *Scope) RemoveExtra(key string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	delete(scope.extra, key)
+}
+
+// SetFingerprint sets the fingerprint the events are grouped by.
+func (scope *Scope) SetFingerprint(fingerprint []string) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.fingerprint = fingerprint
+}
+
+// SetLevel sets the level of the events, overriding the one they were
+// captured with.
+func (scope *Scope) SetLevel(level Level) {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.level = level
+}
+
+// Clone returns a copy of the scope.
+func (scope *Scope) Clone() *Scope {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	clone := &Scope{
+		breadcrumbs: append([]*Breadcrumb(nil), scope.breadcrumbs...),
+		user:        scope.user,
+		fingerprint: append([]string(nil), scope.fingerprint...),
+		level:       scope.level,
+		request:     scope.request,
+	}
+	if scope.tags != nil {
+		clone.tags = make(map[string]string, len(scope.tags))
+		for k, v := range scope.tags {
+			clone.tags[k] = v
+		}
+	}
+	if scope.contexts != nil {
+		clone.contexts = make(map[string]interface{}, len(scope.contexts))
+		for k, v := range scope.contexts {
+			clone.contexts[k] = v
+		}
+	}
+	if scope.extra != nil {
+		clone.extra = make(map[string]interface{}, len(scope.extra))
+		for k, v := range scope.extra {
+			clone.extra[k] = v
+		}
+	}
+	return clone
+}
+
+// Clear resets the scope to an empty one.
+func (scope *Scope) Clear() {
+	scope.mu.Lock()
+	defer scope.mu.Unlock()
+	scope.breadcrumbs = nil
+	scope.user = User{}
+	scope.tags = nil
+	scope.contexts = nil
+	scope.extra = nil
+	scope.fingerprint = nil
+	scope.level = ""
+	scope.request = nil
+}
+
+// interfaces returns the interfaces sent with the events of the scope.
+func (scope *Scope) interfaces() []raven.Interface {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	var interfaces []raven.Interface
+	if scope.user != (User{}) {
+		user := scope.user
+		interfaces = append(interfaces, &user)
+	}
+	if scope.request != nil {
+		interfaces = append(interfaces, raven.NewHttp(scope.request))
+	}
+	if len(scope.breadcrumbs) > 0 {
+		values := append([]*Breadcrumb(nil), scope.breadcrumbs...)
+		interfaces = append(interfaces, &raven.Breadcrumbs{Values: values})
+	}
+	return interfaces
+}
+
+// applyToPacket sets the level, fingerprint, contexts and extra values of the
+// scope on packet, and returns its tags.
+func (scope *Scope) applyToPacket(packet *raven.Packet) map[string]string {
+	scope.mu.RLock()
+	defer scope.mu.RUnlock()
+
+	if scope.level != "" {
+		packet.Level = scope.level
+	}
+	if len(scope.fingerprint) > 0 {
+		packet.Fingerprint = append([]string(nil), scope.fingerprint...)
+	}
+	packet.AddContexts(scope.contexts)
+	for k, v := range scope.extra {
+		if packet.Extra == nil {
+			packet.Extra = raven.Extra{}
+		}
+		if _, ok := packet.Extra[k]; !ok {
+			packet.Extra[k] = v
+		}
+	}
+
+	tags := make(map[string]string, len(scope.tags))
+	for k, v := range scope.tags {
+		tags[k] = v
+	}
+	return tags
+}
@@ -0,0 +1,52 @@
+package sentry
+
+import "testing"
+
+func TestScopeClone(t *testing.T) {
+	scope := NewScope()
+	scope.SetTag("region", "eu")
+	scope.SetExtra("attempt", 1)
+	scope.AddBreadcrumb(&Breadcrumb{Message: "first"}, 0)
+
+	clone := scope.Clone()
+	clone.SetTag("region", "us")
+	clone.SetExtra("attempt", 2)
+	clone.AddBreadcrumb(&Breadcrumb{Message: "second"}, 0)
+
+	if scope.tags["region"] != "eu" {
+		t.Errorf("incorrect region tag: got %s, want eu", scope.tags["region"])
+	}
+	if scope.extra["attempt"] != 1 {
+		t.Errorf("incorrect attempt: got %v, want 1", scope.extra["attempt"])
+	}
+	if len(scope.breadcrumbs) != 1 {
+		t.Errorf("incorrect breadcrumb count: got %d, want 1", len(scope.breadcrumbs))
+	}
+}
+
+func TestScopeBreadcrumbLimit(t *testing.T) {
+	scope := NewScope()
+	for _, message := range []string{"a", "b", "c"} {
+		scope.AddBreadcrumb(&Breadcrumb{Message: message}, 2)
+	}
+	if len(scope.breadcrumbs) != 2 || scope.breadcrumbs[0].Message != "b" {
+		t.Errorf("incorrect breadcrumbs: got %+v, want [b c]", scope.breadcrumbs)
+	}
+
+	scope.AddBreadcrumb(&Breadcrumb{Message: "d"}, -1)
+	if len(scope.breadcrumbs) != 2 {
+		t.Errorf("incorrect breadcrumb count: got %d, want 2", len(scope.breadcrumbs))
+	}
+}
+
+func TestScopeClear(t *testing.T) {
+	scope := NewScope()
+	scope.SetTag("region", "eu")
+	scope.SetUser(User{ID: "42"})
+	scope.SetLevel(LevelFatal)
+	scope.Clear()
+
+	if len(scope.tags) != 0 || scope.user != (User{}) || scope.level != "" {
+		t.Errorf("scope not cleared: %+v", scope)
+	}
+}
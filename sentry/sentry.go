@@ -0,0 +1,106 @@
+// Package sentry exposes the API of the official sentry-go SDK on top of
+// raven-go clients, so that a codebase can move from one SDK to the other a
+// package at a time:
+//
+//	import "github.com/getsentry/raven-go/sentry"
+//
+//	sentry.Init(sentry.ClientOptions{Dsn: dsn})
+//	sentry.ConfigureScope(func(scope *sentry.Scope) {
+//		scope.SetTag("region", "eu")
+//	})
+//	sentry.CaptureException(err)
+//
+// The events go through the raven client, so its filters, processors and
+// transport apply to them as they do to events captured with raven directly.
+// Only the commonly used part of the sentry-go API is covered: there is no
+// tracing, and breadcrumb hints and event hints are not supported.
+package sentry
+
+import (
+	"time"
+
+	"github.com/getsentry/raven-go"
+)
+
+// ClientOptions configures the client built by Init.
+type ClientOptions = raven.ClientOptions
+
+// Integration sets up a client built by Init.
+type Integration = raven.Integration
+
+// User is the user an event is about. Unlike sentry-go's, its IP address
+// field is named IP.
+type User = raven.User
+
+// Breadcrumb records something that happened before an event.
+type Breadcrumb = raven.Breadcrumb
+
+// EventID identifies a captured event.
+type EventID string
+
+// Level is the severity of an event.
+type Level = raven.Severity
+
+const (
+	LevelDebug   = raven.DEBUG
+	LevelInfo    = raven.INFO
+	LevelWarning = raven.WARNING
+	LevelError   = raven.ERROR
+	LevelFatal   = raven.FATAL
+)
+
+// Init builds a client from options and installs it as the default client of
+// the raven package, which CurrentHub captures with.
+func Init(options ClientOptions) error {
+	return raven.Init(options)
+}
+
+// CaptureException captures err on the current hub.
+func CaptureException(err error) *EventID {
+	return CurrentHub().captureException(1, err, "")
+}
+
+// CaptureMessage captures message on the current hub.
+func CaptureMessage(message string) *EventID {
+	return CurrentHub().CaptureMessage(message)
+}
+
+// Recover captures the value of a recovered panic on the current hub.
+func Recover(err interface{}) *EventID {
+	return CurrentHub().recover(1, err)
+}
+
+// AddBreadcrumb records b on the current hub.
+func AddBreadcrumb(b *Breadcrumb) {
+	CurrentHub().AddBreadcrumb(b)
+}
+
+// ConfigureScope calls f with the top scope of the current hub.
+func ConfigureScope(f func(scope *Scope)) {
+	CurrentHub().ConfigureScope(f)
+}
+
+// WithScope calls f with a temporary scope of the current hub.
+func WithScope(f func(scope *Scope)) {
+	CurrentHub().WithScope(f)
+}
+
+// PushScope pushes a scope on the current hub.
+func PushScope() *Scope {
+	return CurrentHub().PushScope()
+}
+
+// PopScope pops a scope off the current hub.
+func PopScope() {
+	CurrentHub().PopScope()
+}
+
+// Flush waits up to timeout for the events of the current hub to be sent.
+func Flush(timeout time.Duration) bool {
+	return CurrentHub().Flush(timeout)
+}
+
+// LastEventID returns the ID of the last event captured on the current hub.
+func LastEventID() EventID {
+	return CurrentHub().LastEventID()
+}
@@ -0,0 +1,18 @@
+package raven
+
+// ServiceProcessor returns an EventProcessor tagging events with what the
+// service manager knows about the process, to help triage daemonized services:
+// the systemd unit, invocation and journald boot ID on Linux, or the service
+// name on Windows. A non-empty name overrides the detected service name; on
+// Windows, where it can't be detected, pass the name given to svc.Run.
+func ServiceProcessor(name string) EventProcessor {
+	tags := serviceTags()
+	if name != "" {
+		tags[serviceNameTag] = name
+	}
+
+	return func(packet *Packet, err error) bool {
+		packet.AddTags(tags)
+		return true
+	}
+}
@@ -0,0 +1,55 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+const serviceNameTag = "systemd.unit"
+
+func serviceTags() map[string]string {
+	tags := make(map[string]string)
+
+	// systemd sets INVOCATION_ID for every unit it starts; without it the
+	// process isn't managed by systemd and the cgroup says nothing useful.
+	invocationID := os.Getenv("INVOCATION_ID")
+	if invocationID == "" {
+		return tags
+	}
+	tags["systemd.invocation_id"] = invocationID
+
+	if cgroup, err := ioutil.ReadFile("/proc/self/cgroup"); err == nil {
+		if unit := cgroupUnit(string(cgroup)); unit != "" {
+			tags[serviceNameTag] = unit
+		}
+	}
+	if bootID, err := ioutil.ReadFile("/proc/sys/kernel/random/boot_id"); err == nil {
+		tags["journald.boot_id"] = strings.Replace(strings.TrimSpace(string(bootID)), "-", "", -1)
+	}
+	return tags
+}
+
+// cgroupUnit extracts the systemd unit from the content of /proc/self/cgroup,
+// preferring the unified hierarchy.
+func cgroupUnit(cgroup string) string {
+	var unit string
+	for _, line := range strings.Split(cgroup, "\n") {
+		// hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 || (parts[0] != "0" && parts[1] != "name=systemd") {
+			continue
+		}
+		elems := strings.Split(parts[2], "/")
+		for i := len(elems) - 1; i >= 0; i-- {
+			if strings.HasSuffix(elems[i], ".service") || strings.HasSuffix(elems[i], ".scope") {
+				unit = elems[i]
+				break
+			}
+		}
+		if unit != "" && parts[0] == "0" {
+			break
+		}
+	}
+	return unit
+}
@@ -0,0 +1,37 @@
+package raven
+
+import "testing"
+
+func TestCgroupUnit(t *testing.T) {
+	tests := []struct {
+		cgroup   string
+		expected string
+	}{
+		{"0::/system.slice/api.service\n", "api.service"},
+		{"12:pids:/system.slice/api.service\n1:name=systemd:/system.slice/worker.service\n", "worker.service"},
+		{"0::/user.slice/user-1000.slice/session-2.scope\n", "session-2.scope"},
+		{"0::/system.slice/docker-abc.scope/app.service\n", "app.service"},
+		{"0::/\n", ""},
+	}
+
+	for _, test := range tests {
+		if actual := cgroupUnit(test.cgroup); actual != test.expected {
+			t.Errorf("incorrect unit for %q: got %s, want %s", test.cgroup, actual, test.expected)
+		}
+	}
+}
+
+func TestServiceProcessor(t *testing.T) {
+	packet := &Packet{}
+	ServiceProcessor("api")(packet, nil)
+
+	var found bool
+	for _, tag := range packet.Tags {
+		if tag.Key == serviceNameTag && tag.Value == "api" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the service name to be tagged, got %+v", packet.Tags)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !windows
+// +build !linux,!windows
+
+package raven
+
+const serviceNameTag = "service"
+
+func serviceTags() map[string]string {
+	return make(map[string]string)
+}
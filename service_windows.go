@@ -0,0 +1,17 @@
+package raven
+
+import "os"
+
+const serviceNameTag = "windows.service"
+
+// The name of a Windows service can't be determined from inside the process
+// without the service control manager API, so it has to be passed to
+// ServiceProcessor. Applications hosted by IIS, e.g. through the
+// HttpPlatformHandler, are tagged with their application pool.
+func serviceTags() map[string]string {
+	tags := make(map[string]string)
+	if pool := os.Getenv("APP_POOL_ID"); pool != "" {
+		tags["iis.app_pool"] = pool
+	}
+	return tags
+}
@@ -0,0 +1,59 @@
+package raven
+
+import "strings"
+
+// A SeverityMapper translates the level name of a logging framework, e.g.
+// "notice" or "critical", to a Sentry severity. It reports false for names it
+// doesn't know, which then fall back to DefaultSeverityMapper.
+type SeverityMapper func(level string) (Severity, bool)
+
+// DefaultSeverityMapper maps the level names of common logging frameworks and
+// syslog to Sentry severities, case insensitively.
+func DefaultSeverityMapper(level string) (Severity, bool) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "trace", "debug", "verbose":
+		return DEBUG, true
+	case "info", "informational", "notice", "print":
+		return INFO, true
+	case "warn", "warning":
+		return WARNING, true
+	case "error", "err":
+		return ERROR, true
+	case "critical", "crit", "alert", "emergency", "emerg", "fatal", "panic", "dpanic":
+		return FATAL, true
+	}
+	return "", false
+}
+
+// SetSeverityMapper sets the mapper integrations use to translate level names
+// through Client.Severity.
+func (client *Client) SetSeverityMapper(m SeverityMapper) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.severityMapper = m
+}
+
+// SetSeverityMapper sets the severity mapper of the default *Client
+func SetSeverityMapper(m SeverityMapper) { DefaultClientInstance().SetSeverityMapper(m) }
+
+// Severity translates the level name of a logging framework to a Sentry
+// severity, using the client's SeverityMapper then DefaultSeverityMapper.
+// Unknown levels map to ERROR.
+func (client *Client) Severity(level string) Severity {
+	client.mu.RLock()
+	m := client.severityMapper
+	client.mu.RUnlock()
+
+	if m != nil {
+		if severity, ok := m(level); ok {
+			return severity
+		}
+	}
+	if severity, ok := DefaultSeverityMapper(level); ok {
+		return severity
+	}
+	return ERROR
+}
+
+// SeverityFor translates a level name using the default *Client
+func SeverityFor(level string) Severity { return DefaultClientInstance().Severity(level) }
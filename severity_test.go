@@ -0,0 +1,32 @@
+package raven
+
+import "testing"
+
+func TestSeverity(t *testing.T) {
+	client := newClient(nil)
+	client.SetSeverityMapper(func(level string) (Severity, bool) {
+		if level == "audit" {
+			return INFO, true
+		}
+		return "", false
+	})
+
+	tests := []struct {
+		level    string
+		expected Severity
+	}{
+		{"TRACE", DEBUG},
+		{"notice", INFO},
+		{"Warn", WARNING},
+		{"err", ERROR},
+		{"critical", FATAL},
+		{"audit", INFO},
+		{"unknown", ERROR},
+	}
+
+	for _, test := range tests {
+		if actual := client.Severity(test.level); actual != test.expected {
+			t.Errorf("incorrect severity for %s: got %s, want %s", test.level, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package raven
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+)
+
+// How long HandleSignals waits for queued events to be sent before letting
+// the process exit.
+var ShutdownTimeout = 2 * time.Second
+
+// Whether HandleSignals captures an INFO event when the process is terminated
+// by a signal, in addition to recording a breadcrumb.
+var ReportTermination = false
+
+// Whether HandleSignals closes the client and re-raises the signal once events
+// are flushed, so that the process exits as it would have otherwise. Leave it
+// unset when the application handles the signals itself, e.g. to shut down
+// gracefully, as it is notified of them regardless.
+var ExitOnSignal = false
+
+// HandleSignals makes sure events are not lost when the process is terminated
+// by one of signals: once one is received, it records a "process terminated"
+// breadcrumb and flushes client for up to ShutdownTimeout. If ExitOnSignal is
+// set, it then closes client so that the events still queued are saved to its
+// queue file, if set, and re-raises the signal. It returns a function undoing
+// the registration.
+//
+// Example:
+//
+//	raven.ExitOnSignal = true
+//	defer raven.HandleSignals(raven.DefaultClientInstance(), syscall.SIGTERM, syscall.SIGINT)()
+func HandleSignals(client *Client, signals ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, signals...)
+
+	go func() {
+		select {
+		case sig := <-ch:
+			signal.Stop(ch)
+			client.onSignal(sig)
+		case <-done:
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
+
+func (client *Client) onSignal(sig os.Signal) {
+	client.handleSignal(sig)
+	if ExitOnSignal {
+		client.Close()
+		reraise(sig)
+	}
+}
+
+func (client *Client) handleSignal(sig os.Signal) {
+	message := fmt.Sprintf("process terminated by %v", sig)
+	client.AddBreadcrumb(&Breadcrumb{
+		Category: "process",
+		Message:  message,
+		Level:    INFO,
+	})
+	if ReportTermination {
+		packet := NewPacket(message, &Message{message, nil})
+		packet.Level = INFO
+		client.Capture(packet, map[string]string{"signal": sig.String()})
+	}
+	client.Flush(ShutdownTimeout)
+}
+
+// FlushOnExit is meant to be deferred at the top of main. It reports a panic
+// ending the program as a FATAL event, flushes the client for up to
+// ShutdownTimeout so that queued events are not lost, and then lets the panic
+// go on.
+//
+// Example:
+//
+//	func main() {
+//		defer raven.FlushOnExit()
+//		...
+//	}
+func (client *Client) FlushOnExit() { client.flushOnExit(recover()) }
+
+// FlushOnExit reports a panic and flushes the default *Client when deferred in main
+func FlushOnExit() { DefaultClientInstance().flushOnExit(recover()) }
+
+func (client *Client) flushOnExit(rval interface{}) {
+	if rval != nil {
+		packet := client.newPanicPacket(2, rval, nil)
+		packet.Level = FATAL
+		client.Capture(packet, nil)
+	}
+
+	client.Flush(ShutdownTimeout)
+
+	if rval != nil {
+		panic(rval)
+	}
+}
+
+// reraise delivers sig again now that HandleSignals stopped relaying it, so
+// that it has its default behaviour unless the application handles it too. It
+// is a variable so that tests can replace it.
+var reraise = func(sig os.Signal) {
+	if p, err := os.FindProcess(os.Getpid()); err == nil && p.Signal(sig) == nil {
+		// Give the runtime a chance to act on the signal.
+		time.Sleep(time.Second)
+	}
+	os.Exit(1)
+}
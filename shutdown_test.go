@@ -0,0 +1,103 @@
+//go:build !windows && !js
+// +build !windows,!js
+
+package raven
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestHandleSignals(t *testing.T) {
+	defer func(f func(os.Signal)) { reraise = f }(reraise)
+	defer func(report bool) { ReportTermination = report }(ReportTermination)
+	defer func(exit bool) { ExitOnSignal = exit }(ExitOnSignal)
+	ReportTermination, ExitOnSignal = true, true
+
+	reraised := make(chan os.Signal, 1)
+	reraise = func(sig os.Signal) { reraised <- sig }
+
+	client, transport := newTestClient()
+	stop := HandleSignals(client, syscall.SIGUSR1)
+	defer stop()
+
+	syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+	select {
+	case sig := <-reraised:
+		if sig != syscall.SIGUSR1 {
+			t.Errorf("incorrect signal: got %v, want %v", sig, syscall.SIGUSR1)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the signal to be re-raised")
+	}
+
+	if len(transport.packets) != 1 {
+		t.Fatalf("expected the termination to be reported, got %d events", len(transport.packets))
+	}
+	if packet := transport.packets[0]; packet.Message != "process terminated by user defined signal 1" || packet.Level != INFO {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+	if _, ch := client.Capture(NewPacket("after"), nil); <-ch != ErrClientClosed {
+		t.Error("expected the client to be closed before the signal was re-raised")
+	}
+}
+
+func TestHandleSignalsWithoutExit(t *testing.T) {
+	defer func(f func(os.Signal)) { reraise = f }(reraise)
+	reraise = func(sig os.Signal) { t.Errorf("unexpected re-raised signal %v", sig) }
+
+	client, transport := newTestClient()
+	client.onSignal(syscall.SIGTERM)
+	if _, ch := client.Capture(NewPacket("after"), nil); <-ch != nil {
+		t.Error("expected the client to stay open")
+	}
+	if len(transport.packets) != 1 {
+		t.Errorf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+
+	stop := HandleSignals(client, syscall.SIGUSR1)
+	stop()
+	stop()
+}
+
+func exitingMain(client *Client, rval interface{}) {
+	defer client.FlushOnExit()
+	if rval != nil {
+		panic(rval)
+	}
+}
+
+func TestFlushOnExit(t *testing.T) {
+	client, transport := newTestClient()
+
+	exitingMain(client, nil)
+	if len(transport.packets) != 0 {
+		t.Errorf("incorrect packet count: got %d, want 0", len(transport.packets))
+	}
+
+	var rval interface{}
+	func() {
+		defer func() { rval = recover() }()
+		exitingMain(client, "out of memory")
+	}()
+
+	if rval != "out of memory" {
+		t.Errorf("panic not re-raised: got %v", rval)
+	}
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+	packet := transport.packets[0]
+	if packet.Message != "out of memory" || packet.Level != FATAL {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+	var found bool
+	for _, f := range packet.Interfaces[0].(*Exception).Stacktrace.Frames {
+		found = found || f.Function == "exitingMain"
+	}
+	if !found {
+		t.Error("panicking frame missing from the stacktrace")
+	}
+}
@@ -0,0 +1,126 @@
+package raven
+
+import (
+	"os"
+	"time"
+)
+
+// SourceContextTimeout bounds the time spent reading the source files of a
+// stack trace, e.g. from a slow network file system. Frames whose files aren't
+// read in time get no context. 0, the default, disables the limit.
+var SourceContextTimeout time.Duration
+
+// SourceContextMaxBytes bounds the size of the source files read from disk for
+// a stack trace, not counting those already cached. Frames whose files would
+// exceed it get no context. 0 disables the limit.
+var SourceContextMaxBytes int64 = 4 << 20
+
+// The number of source files read concurrently for a stack trace.
+const sourceContextWorkers = 8
+
+// sourceFileResult is the source context of the frames in one file.
+type sourceFileResult struct {
+	frames []*StacktraceFrame
+	loaded []StacktraceFrame
+}
+
+// addSourceContext sets the source context of frames, reading their files
+// concurrently within SourceContextTimeout and SourceContextMaxBytes.
+func addSourceContext(frames []*StacktraceFrame, context int) {
+	if context == 0 || len(frames) == 0 {
+		return
+	}
+	loader := sourceCodeLoader
+
+	var files []string
+	byFile := make(map[string][]*StacktraceFrame)
+	for _, frame := range frames {
+		if _, ok := byFile[frame.AbsolutePath]; !ok {
+			files = append(files, frame.AbsolutePath)
+		}
+		byFile[frame.AbsolutePath] = append(byFile[frame.AbsolutePath], frame)
+	}
+
+	if fs, ok := loader.(*fsLoader); ok {
+		if SourceContextMaxBytes > 0 {
+			files = fs.withinBudget(files, SourceContextMaxBytes)
+		}
+		// Cached files take no reading, so they are loaded right away.
+		uncached := files[:0]
+		for _, file := range files {
+			if fs.cached(file) {
+				for _, frame := range byFile[file] {
+					loadFrameContext(loader, frame, context)
+				}
+			} else {
+				uncached = append(uncached, file)
+			}
+		}
+		files = uncached
+	}
+	if len(files) == 0 {
+		return
+	}
+
+	// Frames are loaded into copies, so that files loaded past the timeout
+	// don't modify the stack trace once returned.
+	results := make(chan sourceFileResult, len(files))
+	sem := make(chan struct{}, sourceContextWorkers)
+	for _, file := range files {
+		go func(frames []*StacktraceFrame) {
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			r := sourceFileResult{frames, make([]StacktraceFrame, len(frames))}
+			for i, frame := range frames {
+				r.loaded[i] = StacktraceFrame{AbsolutePath: frame.AbsolutePath, Lineno: frame.Lineno}
+				loadFrameContext(loader, &r.loaded[i], context)
+			}
+			results <- r
+		}(byFile[file])
+	}
+
+	var timeout <-chan time.Time
+	if SourceContextTimeout > 0 {
+		timer := time.NewTimer(SourceContextTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	for range files {
+		select {
+		case r := <-results:
+			for i, frame := range r.frames {
+				frame.PreContext, frame.ContextLine, frame.PostContext = r.loaded[i].PreContext, r.loaded[i].ContextLine, r.loaded[i].PostContext
+			}
+		case <-timeout:
+			debugf("raven: source context loading timed out")
+			return
+		}
+	}
+}
+
+// withinBudget returns the files that can be loaded without reading more than
+// maxBytes of files not cached yet.
+func (fs *fsLoader) withinBudget(files []string, maxBytes int64) []string {
+	var kept []string
+	for _, file := range files {
+		if !fs.cached(file) {
+			info, err := os.Stat(file)
+			if err == nil && info.Size() > maxBytes {
+				continue
+			}
+			if err == nil {
+				maxBytes -= info.Size()
+			}
+		}
+		kept = append(kept, file)
+	}
+	return kept
+}
+
+// cached reports whether file was already read.
+func (fs *fsLoader) cached(file string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	_, ok := fs.cache[file]
+	return ok
+}
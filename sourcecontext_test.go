@@ -0,0 +1,133 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// slowLoader returns the file name as its only line, taking long for slow.go.
+type slowLoader struct{}
+
+func (slowLoader) Load(filename string, line, context int) ([][]byte, int) {
+	if filename == "slow.go" {
+		time.Sleep(time.Second)
+	}
+	return [][]byte{[]byte(filename)}, 0
+}
+
+func TestSourceContextTimeout(t *testing.T) {
+	defer func(loader SourceCodeLoader, timeout time.Duration) {
+		sourceCodeLoader, SourceContextTimeout = loader, timeout
+	}(sourceCodeLoader, SourceContextTimeout)
+	SetSourceCodeLoader(slowLoader{})
+	SourceContextTimeout = 50 * time.Millisecond
+
+	frames := []*StacktraceFrame{
+		{AbsolutePath: "a.go", Lineno: 1},
+		{AbsolutePath: "slow.go", Lineno: 1},
+		{AbsolutePath: "b.go", Lineno: 2},
+		{AbsolutePath: "a.go", Lineno: 3},
+	}
+	start := time.Now()
+	addSourceContext(frames, -1)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("source context loading took %s", elapsed)
+	}
+
+	expected := []string{"a.go", "", "b.go", "a.go"}
+	for i, frame := range frames {
+		if frame.ContextLine != expected[i] {
+			t.Errorf("incorrect context line of frame %d: got %q, want %q", i, frame.ContextLine, expected[i])
+		}
+	}
+}
+
+func TestSourceContextMaxBytes(t *testing.T) {
+	defer func(loader SourceCodeLoader, max int64) {
+		sourceCodeLoader, SourceContextMaxBytes = loader, max
+	}(sourceCodeLoader, SourceContextMaxBytes)
+	SetSourceCodeLoader(&fsLoader{cache: make(map[string][][]byte)})
+	SourceContextMaxBytes = 150
+
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var frames []*StacktraceFrame
+	for _, name := range []string{"small.go", "large.go", "medium.go", "last.go"} {
+		size := map[string]int{"small.go": 50, "large.go": 200, "medium.go": 90, "last.go": 20}[name]
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(strings.Repeat("x", size)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, &StacktraceFrame{AbsolutePath: path, Lineno: 1})
+	}
+	addSourceContext(frames, -1)
+
+	// large.go exceeds the budget, and last.go what small.go and medium.go left
+	expected := []bool{true, false, true, false}
+	for i, frame := range frames {
+		if (frame.ContextLine != "") != expected[i] {
+			t.Errorf("incorrect context line of %s: %q", filepath.Base(frame.AbsolutePath), frame.ContextLine)
+		}
+	}
+}
+
+func TestSourceContextCached(t *testing.T) {
+	defer func(loader SourceCodeLoader, timeout time.Duration) {
+		sourceCodeLoader, SourceContextTimeout = loader, timeout
+	}(sourceCodeLoader, SourceContextTimeout)
+	SetSourceCodeLoader(&fsLoader{cache: map[string][][]byte{"cached.go": {[]byte("cached")}}})
+	// Too short for any file to be read, but cached ones are loaded anyway.
+	SourceContextTimeout = time.Nanosecond
+
+	frames := []*StacktraceFrame{{AbsolutePath: "cached.go", Lineno: 1}}
+	addSourceContext(frames, -1)
+	if frames[0].ContextLine != "cached" {
+		t.Errorf("incorrect context line: got %q, want %q", frames[0].ContextLine, "cached")
+	}
+}
+
+// countingLoader records the lines whose context is loaded.
+type countingLoader struct {
+	mu    sync.Mutex
+	lines []int
+}
+
+func (l *countingLoader) Load(filename string, line, context int) ([][]byte, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, line)
+	return [][]byte{[]byte(filename)}, 0
+}
+
+func TestSourceContextAfterTrim(t *testing.T) {
+	defer func(loader SourceCodeLoader, max int) {
+		sourceCodeLoader, MaxFrames = loader, max
+	}(sourceCodeLoader, MaxFrames)
+	loader := &countingLoader{}
+	SetSourceCodeLoader(loader)
+	MaxFrames = 4
+
+	pcs := make([]uintptr, 64)
+	pcs = pcs[:runtime.Callers(0, pcs)]
+	var deep []uintptr
+	for len(deep) < 5*len(pcs) {
+		deep = append(deep, pcs...)
+	}
+	stacktrace := stacktraceFromPCs(deep, -1, nil)
+	if len(stacktrace.Frames) != 4 {
+		t.Fatalf("incorrect number of frames: got %d, want 4", len(stacktrace.Frames))
+	}
+	if len(loader.lines) != 4 {
+		t.Errorf("incorrect number of frames loaded: got %d, want 4", len(loader.lines))
+	}
+}
@@ -0,0 +1,170 @@
+package raven
+
+import (
+	gocontext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Span measures a single operation within a trace.
+// https://develop.sentry.dev/sdk/event-payloads/span/
+type Span struct {
+	TraceID      string                 `json:"trace_id"`
+	SpanID       string                 `json:"span_id"`
+	ParentSpanID string                 `json:"parent_span_id,omitempty"`
+	Op           string                 `json:"op,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+	Status       string                 `json:"status,omitempty"`
+	Data         map[string]interface{} `json:"data,omitempty"`
+	StartTime    Timestamp              `json:"start_timestamp"`
+	EndTime      Timestamp              `json:"timestamp"`
+
+	// The spans of the trace finished so far, shared by all its spans.
+	recorder *spanRecorder
+}
+
+// The maximum number of finished spans sent with a transaction, as Sentry
+// drops the transactions with more.
+const maxSpans = 1000
+
+// spanRecorder collects the spans of a trace as they finish, so that they are
+// sent with the transaction of its root span.
+type spanRecorder struct {
+	mu    sync.Mutex
+	spans []*Span
+}
+
+func (r *spanRecorder) record(span *Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.spans) < maxSpans {
+		r.spans = append(r.spans, span)
+	}
+}
+
+// finished returns the spans finished so far, but root.
+func (r *spanRecorder) finished(root *Span) []*Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := make([]*Span, 0, len(r.spans))
+	for _, span := range r.spans {
+		if span != root {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+type spanContextKey struct{}
+
+// StartSpan starts a span as a child of the span stored in ctx, if any, and
+// returns a context carrying the new span.
+func StartSpan(ctx gocontext.Context, op, description string) (*Span, gocontext.Context) {
+	span := &Span{
+		SpanID:      spanID(),
+		Op:          op,
+		Description: description,
+		StartTime:   Timestamp(time.Now()),
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.TraceID = parent.TraceID
+		span.ParentSpanID = parent.SpanID
+		span.recorder = parent.recorder
+	} else {
+		span.TraceID, _ = uuid()
+		span.recorder = &spanRecorder{}
+	}
+	return span, gocontext.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext returns the span stored in ctx, or nil.
+func SpanFromContext(ctx gocontext.Context) *Span {
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// SetData sets a key of the span's data, following the conventions in
+// https://develop.sentry.dev/sdk/performance/span-data-conventions/
+func (span *Span) SetData(key string, value interface{}) {
+	if span.Data == nil {
+		span.Data = make(map[string]interface{})
+	}
+	span.Data[key] = value
+}
+
+// Finish records the end time of the span, which is then sent with the
+// transaction of the trace's root span.
+func (span *Span) Finish() {
+	if !time.Time(span.EndTime).IsZero() {
+		return
+	}
+	span.EndTime = Timestamp(time.Now())
+	if span.recorder != nil {
+		span.recorder.record(span)
+	}
+}
+
+// StartDBSpan starts a span for a database query. system identifies the
+// database, e.g. "postgresql" or "redis".
+func StartDBSpan(ctx gocontext.Context, system, query string) (*Span, gocontext.Context) {
+	span, ctx := StartSpan(ctx, "db", query)
+	span.SetData("db.system", system)
+	return span, ctx
+}
+
+// StartHTTPSpan starts a span for an outgoing HTTP request. The query string
+// is kept out of the description so that requests group together.
+func StartHTTPSpan(ctx gocontext.Context, req *http.Request) (*Span, gocontext.Context) {
+	u := *req.URL
+	u.RawQuery, u.Fragment, u.User = "", "", nil
+
+	span, ctx := StartSpan(ctx, "http.client", req.Method+" "+u.String())
+	span.SetData("http.request.method", req.Method)
+	span.SetData("url", u.String())
+	span.SetData("server.address", req.URL.Hostname())
+	if req.URL.RawQuery != "" {
+		span.SetData("http.query", sanitizeQuery(req.URL.Query()).Encode())
+	}
+	return span, ctx
+}
+
+// SetHTTPStatus records the response status code of an HTTP span.
+func (span *Span) SetHTTPStatus(code int) {
+	span.SetData("http.response.status_code", code)
+	switch {
+	case code < 400:
+		span.Status = "ok"
+	case code == http.StatusNotFound:
+		span.Status = "not_found"
+	case code == http.StatusTooManyRequests:
+		span.Status = "resource_exhausted"
+	case code < 500:
+		span.Status = "invalid_argument"
+	default:
+		span.Status = "internal_error"
+	}
+}
+
+// StartCacheSpan starts a span for a cache operation. op should be one of
+// "cache.get", "cache.put", "cache.remove" or "cache.flush".
+func StartCacheSpan(ctx gocontext.Context, op string, keys ...string) (*Span, gocontext.Context) {
+	span, ctx := StartSpan(ctx, op, strings.Join(keys, ", "))
+	span.SetData("cache.key", keys)
+	return span, ctx
+}
+
+// SetCacheHit records whether a cache.get span found the key.
+func (span *Span) SetCacheHit(hit bool) {
+	span.SetData("cache.hit", hit)
+}
+
+func spanID() string {
+	id := make([]byte, 8)
+	io.ReadFull(rand.Reader, id)
+	return hex.EncodeToString(id)
+}
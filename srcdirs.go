@@ -0,0 +1,11 @@
+//go:build !raven_minimal
+// +build !raven_minimal
+
+package raven
+
+import "go/build"
+
+// sourceDirs returns the GOROOT and GOPATH source directories.
+func sourceDirs() []string {
+	return build.Default.SrcDirs()
+}
@@ -0,0 +1,40 @@
+//go:build raven_minimal
+// +build raven_minimal
+
+package raven
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// sourceDirs returns the GOROOT and GOPATH source directories. Minimal builds
+// leave out go/build, which pulls in the Go parser and regexp, and find them
+// from the environment instead.
+func sourceDirs() []string {
+	var dirs []string
+	if root := runtime.GOROOT(); root != "" {
+		dirs = append(dirs, filepath.Join(root, "src"))
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		// Like go/build, default to the go directory of the home directory.
+		env := "HOME"
+		switch runtime.GOOS {
+		case "windows":
+			env = "USERPROFILE"
+		case "plan9":
+			env = "home"
+		}
+		if home := os.Getenv(env); home != "" {
+			gopath = filepath.Join(home, "go")
+		}
+	}
+	for _, dir := range filepath.SplitList(gopath) {
+		if dir != "" {
+			dirs = append(dirs, filepath.Join(dir, "src"))
+		}
+	}
+	return dirs
+}
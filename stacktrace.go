@@ -7,7 +7,6 @@ package raven
 
 import (
 	"bytes"
-	"go/build"
 	"io/ioutil"
 	"net/url"
 	"path/filepath"
@@ -22,6 +21,30 @@ import (
 type Stacktrace struct {
 	// Required
 	Frames []*StacktraceFrame `json:"frames"`
+
+	// Optional
+	FramesOmitted []int `json:"frames_omitted,omitempty"`
+
+	// The return addresses of frames yet to be resolved, see
+	// SetLazyStacktraces.
+	lazy *lazyFrames
+}
+
+// The maximum number of frames kept in a stack trace. Deeper ones, e.g. from
+// runaway recursion, keep their oldest and most recent MaxFrames/2 frames, and
+// record the range of frames left out. Zero or less keeps every frame.
+var MaxFrames = 200
+
+// trim drops the middle frames of a stack trace deeper than MaxFrames.
+func (s *Stacktrace) trim() {
+	max := MaxFrames
+	if max <= 0 || len(s.Frames) <= max {
+		return
+	}
+	head := max / 2
+	tail := len(s.Frames) - (max - head)
+	s.FramesOmitted = []int{head, tail}
+	s.Frames = append(s.Frames[:head:head], s.Frames[tail:]...)
 }
 
 func (s *Stacktrace) Class() string { return "stacktrace" }
@@ -50,6 +73,9 @@ type StacktraceFrame struct {
 	PreContext   []string `json:"pre_context,omitempty"`
 	PostContext  []string `json:"post_context,omitempty"`
 	InApp        bool     `json:"in_app"`
+
+	// Set on frames of calls inlined by the compiler.
+	Inlined bool `json:"inlined,omitempty"`
 }
 
 type StackTracer interface {
@@ -70,28 +96,21 @@ func GetOrNewStacktrace(err, cause error, skip int, context int, appPackagePrefi
 
 	// if either has a trace, we can generate from it
 	if causeHasStacktrace || errHasStacktrace {
-		var frames []*StacktraceFrame
-		for _, f := range stacktracer.StackTrace() {
-			pc := uintptr(f) - 1
-			fn := runtime.FuncForPC(pc)
-			var file string
-			var line int
-			if fn != nil {
-				file, line = fn.FileLine(pc)
-			} else {
-				file = "unknown"
-			}
-			frame := NewStacktraceFrame(pc, file, line, context, appPackagePrefixes)
-			if frame != nil {
-				frames = append([]*StacktraceFrame{frame}, frames...)
-			}
-		}
-		return &Stacktrace{Frames: frames}
+		return stacktraceFromPCs(stackTracerPCs(stacktracer), context, appPackagePrefixes)
 	} else {
 		return NewStacktrace(skip+1, context, appPackagePrefixes)
 	}
 }
 
+func stackTracerPCs(stacktracer StackTracer) []uintptr {
+	trace := stacktracer.StackTrace()
+	pcs := make([]uintptr, len(trace))
+	for i, f := range trace {
+		pcs[i] = uintptr(f)
+	}
+	return pcs
+}
+
 // Intialize and populate a new stacktrace, skipping skip frames.
 //
 // context is the number of surrounding lines that should be included for context.
@@ -101,30 +120,74 @@ func GetOrNewStacktrace(err, cause error, skip int, context int, appPackagePrefi
 // appPackagePrefixes is a list of prefixes used to check whether a package should
 // be considered "in app".
 func NewStacktrace(skip int, context int, appPackagePrefixes []string) *Stacktrace {
-	var frames []*StacktraceFrame
-	for i := 1 + skip; ; i++ {
-		pc, file, line, ok := runtime.Caller(i)
-		if !ok {
+	buf := pcsPool.Get().(*[]uintptr)
+	pcs := *buf
+	for {
+		n := runtime.Callers(2+skip, pcs)
+		if n < len(pcs) {
+			pcs = pcs[:n]
 			break
 		}
-		frame := NewStacktraceFrame(pc, file, line, context, appPackagePrefixes)
-		if frame != nil {
-			frames = append(frames, frame)
-		}
+		pcs = make([]uintptr, 2*len(pcs))
 	}
+
+	stacktrace := stacktraceFromPCs(pcs, context, appPackagePrefixes)
+	*buf = pcs[:cap(pcs)]
+	pcsPool.Put(buf)
+
 	// If there are no frames, the entire stacktrace is nil
-	if len(frames) == 0 {
+	if len(stacktrace.Frames) == 0 {
 		return nil
 	}
-	// Optimize the path where there's only 1 frame
-	if len(frames) == 1 {
-		return &Stacktrace{frames}
+	return stacktrace
+}
+
+// pcsPool holds the buffers NewStacktrace collects return addresses into.
+var pcsPool = sync.Pool{
+	New: func() interface{} {
+		pcs := make([]uintptr, 64)
+		return &pcs
+	},
+}
+
+// stacktraceFromPCs builds a stacktrace from return addresses, as returned by
+// runtime.Callers, most recent call first. Calls inlined by the compiler get
+// frames of their own, marked as inlined.
+func stacktraceFromPCs(pcs []uintptr, context int, appPackagePrefixes []string) *Stacktrace {
+	// Allocate the frames together rather than one by one. Inlined calls
+	// can make for more frames than pcs, which then get a new block.
+	frames := make([]*StacktraceFrame, 0, len(pcs))
+	block := make([]StacktraceFrame, len(pcs))
+	callers := runtime.CallersFrames(pcs)
+	for more := len(pcs) > 0; more; {
+		var f runtime.Frame
+		f, more = callers.Next()
+		module, function := splitFunctionName(f.Function)
+		// Func is only nil for inlined calls and non-Go code, which only has
+		// a name when a cgo symbolizer is registered.
+		goCode := f.Func != nil || strings.HasSuffix(f.File, ".go")
+		if !goCode {
+			module, function = "cgo", f.Function
+		}
+		if len(block) == 0 {
+			block = make([]StacktraceFrame, len(pcs))
+		}
+		frame := &block[0]
+		if initStacktraceFrame(frame, module, function, f.File, f.Line, 0, appPackagePrefixes) {
+			frame.Inlined = f.Func == nil && goCode && f.Function != ""
+			frames = append(frames, frame)
+			block = block[1:]
+		}
 	}
 	// Sentry wants the frames with the oldest first, so reverse them
 	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
 		frames[i], frames[j] = frames[j], frames[i]
 	}
-	return &Stacktrace{frames}
+	stacktrace := &Stacktrace{Frames: frames}
+	// Only load the source of the frames kept.
+	stacktrace.trim()
+	addSourceContext(stacktrace.Frames, context)
+	return stacktrace
 }
 
 // Build a single frame using data returned from runtime.Caller.
@@ -136,27 +199,43 @@ func NewStacktrace(skip int, context int, appPackagePrefixes []string) *Stacktra
 // appPackagePrefixes is a list of prefixes used to check whether a package should
 // be considered "in app".
 func NewStacktraceFrame(pc uintptr, file string, line, context int, appPackagePrefixes []string) *StacktraceFrame {
-	frame := &StacktraceFrame{AbsolutePath: file, Filename: trimPath(file), Lineno: line, InApp: false}
-	frame.Module, frame.Function = functionName(pc)
+	module, function := functionName(pc)
+	return newStacktraceFrame(module, function, file, line, context, appPackagePrefixes)
+}
+
+func newStacktraceFrame(module, function, file string, line, context int, appPackagePrefixes []string) *StacktraceFrame {
+	frame := &StacktraceFrame{}
+	if !initStacktraceFrame(frame, module, function, file, line, context, appPackagePrefixes) {
+		return nil
+	}
+	return frame
+}
 
+// initStacktraceFrame fills in frame, reporting false for frames that should
+// be left out of stacktraces.
+func initStacktraceFrame(frame *StacktraceFrame, module, function, file string, line, context int, appPackagePrefixes []string) bool {
 	// `runtime.goexit` is effectively a placeholder that comes from
 	// runtime/asm_amd64.s and is meaningless.
-	if frame.Module == "runtime" && frame.Function == "goexit" {
-		return nil
+	if module == "runtime" && function == "goexit" {
+		return false
 	}
 
-	if frame.Module == "main" {
-		frame.InApp = true
-	} else {
-		for _, prefix := range appPackagePrefixes {
-			if strings.HasPrefix(frame.Module, prefix) && !strings.Contains(frame.Module, "vendor") && !strings.Contains(frame.Module, "third_party") {
-				frame.InApp = true
-			}
-		}
+	frame.AbsolutePath, frame.Filename, frame.Lineno = file, trimPath(file), line
+	frame.Module, frame.Function = module, function
+
+	if isCgoFunction(frame.Module, frame.Function) {
+		frame.Module = "cgo"
 	}
+	frame.InApp = frame.Module != "cgo" && isInApp(frame.Module, appPackagePrefixes)
 
+	loadFrameContext(sourceCodeLoader, frame, context)
+	return true
+}
+
+// loadFrameContext sets the source context of frame, loaded with loader.
+func loadFrameContext(loader SourceCodeLoader, frame *StacktraceFrame, context int) {
 	if context > 0 {
-		contextLines, lineIdx := sourceCodeLoader.Load(file, line, context)
+		contextLines, lineIdx := loader.Load(frame.AbsolutePath, frame.Lineno, context)
 		if len(contextLines) > 0 {
 			for i, line := range contextLines {
 				switch {
@@ -170,12 +249,33 @@ func NewStacktraceFrame(pc uintptr, file string, line, context int, appPackagePr
 			}
 		}
 	} else if context == -1 {
-		contextLine, _ := sourceCodeLoader.Load(file, line, 0)
+		contextLine, _ := loader.Load(frame.AbsolutePath, frame.Lineno, 0)
 		if len(contextLine) > 0 {
 			frame.ContextLine = string(contextLine[0])
 		}
 	}
-	return frame
+}
+
+// isInApp reports whether module is part of the application.
+func isInApp(module string, appPackagePrefixes []string) bool {
+	if module == "main" {
+		return true
+	}
+	for _, prefix := range appPackagePrefixes {
+		if strings.HasPrefix(module, prefix) && !strings.Contains(module, "vendor") && !strings.Contains(module, "third_party") {
+			return true
+		}
+	}
+	return false
+}
+
+// isCgoFunction reports whether a function is part of a call between Go and C:
+// the runtime functions switching stacks and the wrappers generated by cgo.
+func isCgoFunction(module, function string) bool {
+	if module == "runtime" {
+		return function == "cgocall" || function == "asmcgocall" || strings.HasPrefix(function, "cgocallback")
+	}
+	return strings.HasPrefix(function, "_Cfunc_") || strings.HasPrefix(function, "_cgo_") || strings.HasPrefix(function, "_cgoexp_")
 }
 
 // Retrieve the name of the package and function containing the PC.
@@ -190,6 +290,7 @@ func functionName(pc uintptr) (string, string) {
 
 func splitFunctionName(name string) (string, string) {
 	var pack string
+	name = normalizeFunctionName(name)
 
 	if pos := strings.LastIndex(name, "/"); pos != -1 {
 		pack = name[:pos+1]
@@ -208,11 +309,53 @@ func splitFunctionName(name string) (string, string) {
 	return pack, name
 }
 
+// normalizeFunctionName replaces the type arguments of generic functions and
+// types, e.g. "pkg.Map[go.shape.int,go.shape.string]", with "[...]" as printed
+// by recent Go versions, so that frames group the same whatever the
+// instantiation.
+func normalizeFunctionName(name string) string {
+	if !strings.Contains(name, "[") {
+		return name
+	}
+	var b bytes.Buffer
+	depth := 0
+	for _, r := range name {
+		switch {
+		case r == '[':
+			if depth == 0 {
+				b.WriteString("[...]")
+			}
+			depth++
+		case r == ']' && depth > 0:
+			depth--
+		case depth == 0:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
 type SourceCodeLoader interface {
 	Load(filename string, line, context int) ([][]byte, int)
 }
 
-var sourceCodeLoader SourceCodeLoader = &fsLoader{cache: make(map[string][][]byte)}
+var sourceCodeLoader = defaultSourceCodeLoader()
+
+// defaultSourceCodeLoader returns a loader reading source files, except on js
+// where browsers have no file system to read them from.
+func defaultSourceCodeLoader() SourceCodeLoader {
+	if runtime.GOOS == "js" {
+		return noSourceCodeLoader{}
+	}
+	return &fsLoader{cache: make(map[string][][]byte)}
+}
+
+// noSourceCodeLoader loads no source, leaving frames without context.
+type noSourceCodeLoader struct{}
+
+func (noSourceCodeLoader) Load(filename string, line, context int) ([][]byte, int) {
+	return nil, 0
+}
 
 func SetSourceCodeLoader(loader SourceCodeLoader) {
 	sourceCodeLoader = loader
@@ -224,20 +367,22 @@ type fsLoader struct {
 }
 
 func (fs *fsLoader) Load(filename string, line, context int) ([][]byte, int) {
+	// Read files without holding the lock, so that they can be read
+	// concurrently.
 	fs.mu.Lock()
-	defer fs.mu.Unlock()
 	lines, ok := fs.cache[filename]
+	fs.mu.Unlock()
 	if !ok {
 		data, err := ioutil.ReadFile(filename)
-		if err != nil {
-			// cache errors as nil slice: code below handles it correctly
-			// otherwise when missing the source or running as a different user, we try
-			// reading the file on each error which is unnecessary
-			fs.cache[filename] = nil
-			return nil, 0
+		if err == nil {
+			lines = bytes.Split(data, []byte{'\n'})
 		}
-		lines = bytes.Split(data, []byte{'\n'})
+		// cache errors as nil slice: code below handles it correctly
+		// otherwise when missing the source or running as a different user, we try
+		// reading the file on each error which is unnecessary
+		fs.mu.Lock()
 		fs.cache[filename] = lines
+		fs.mu.Unlock()
 	}
 
 	if lines == nil {
@@ -255,7 +400,7 @@ func (fs *fsLoader) Load(filename string, line, context int) ([][]byte, int) {
 		idx = context
 	}
 	end := line + context + 1
-	if line >= len(lines) {
+	if line < 0 || line >= len(lines) {
 		return nil, 0
 	}
 	if end > len(lines) {
@@ -279,7 +424,7 @@ func trimPath(filename string) string {
 func init() {
 	// Collect all source directories, and make sure they
 	// end in a trailing "separator"
-	for _, prefix := range build.Default.SrcDirs() {
+	for _, prefix := range sourceDirs() {
 		if prefix[len(prefix)-1] != filepath.Separator {
 			prefix += string(filepath.Separator)
 		}
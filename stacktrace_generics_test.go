@@ -0,0 +1,23 @@
+//go:build go1.18
+// +build go1.18
+
+package raven
+
+import "testing"
+
+type genericList[T any] struct{ values []T }
+
+//go:noinline
+func (l *genericList[T]) trace() *Stacktrace { return NewStacktrace(0, 0, []string{thisPackage}) }
+
+func TestStacktraceGenerics(t *testing.T) {
+	for _, st := range []*Stacktrace{(&genericList[int]{}).trace(), (&genericList[map[string]error]{}).trace()} {
+		f := st.Frames[len(st.Frames)-1]
+		if f.Module != thisPackage || f.Function != "(*genericList[...]).trace" {
+			t.Errorf("incorrect frame: got %s.%s, want %s.(*genericList[...]).trace", f.Module, f.Function, thisPackage)
+		}
+		if f.Inlined {
+			t.Errorf("frame wrongly marked as inlined: %+v", f)
+		}
+	}
+}
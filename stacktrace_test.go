@@ -212,3 +212,170 @@ func TestFileContext(t *testing.T) {
 		}
 	}
 }
+
+func TestSplitGenericFunctionName(t *testing.T) {
+	tests := []struct {
+		in         string
+		pack, name string
+	}{
+		{"cmd/main.Map[go.shape.int,go.shape.string]", "cmd/main", "Map[...]"},
+		{"cmd/main.(*List[go.shape.*example.com/x.T]).Push", "cmd/main", "(*List[...]).Push"},
+		{"cmd/main.Keys[map[string]int].func1", "cmd/main", "Keys[...].func1"},
+		{"cmd/main.Keys[...]", "cmd/main", "Keys[...]"},
+	}
+
+	for _, test := range tests {
+		pack, name := splitFunctionName(test.in)
+		if pack != test.pack || name != test.name {
+			t.Errorf("incorrect splitFunctionName(%q): got (%q, %q), want (%q, %q)", test.in, pack, name, test.pack, test.name)
+		}
+	}
+}
+
+func inlinedTrace() *Stacktrace { return NewStacktrace(0, 0, []string{thisPackage}) }
+
+func TestStacktraceInlined(t *testing.T) {
+	st := inlinedTrace()
+	if len(st.Frames) < 2 {
+		t.Fatalf("incorrect frame count: %d", len(st.Frames))
+	}
+
+	f, caller := st.Frames[len(st.Frames)-1], st.Frames[len(st.Frames)-2]
+	if f.Function != "inlinedTrace" || caller.Function != "TestStacktraceInlined" {
+		t.Fatalf("incorrect frames: got %s and %s", f.Function, caller.Function)
+	}
+	if testing.CoverMode() == "" && !f.Inlined {
+		t.Errorf("inlined frame not marked: %+v", f)
+	}
+	if caller.Inlined {
+		t.Errorf("frame wrongly marked as inlined: %+v", caller)
+	}
+}
+
+func TestNewStacktraceFrameCgo(t *testing.T) {
+	tests := []struct {
+		module, function string
+		cgo              bool
+	}{
+		{"runtime", "cgocall", true},
+		{"runtime", "asmcgocall", true},
+		{"runtime", "cgocallbackg1", true},
+		{"example.com/app", "_Cfunc_sqlite3_step", true},
+		{"example.com/app", "_cgoexp_3f1a_goCallback", true},
+		{"", "_cgo_5a2b_Cfunc_sqlite3_step", true},
+		{"runtime", "gopanic", false},
+		{"example.com/app", "Query", false},
+	}
+
+	for _, test := range tests {
+		frame := newStacktraceFrame(test.module, test.function, "file.go", 1, 0, []string{""})
+		if frame.Function != test.function {
+			t.Errorf("incorrect function: got %s, want %s", frame.Function, test.function)
+		}
+		if cgo := frame.Module == "cgo"; cgo != test.cgo {
+			t.Errorf("incorrect module for %s.%s: got %s", test.module, test.function, frame.Module)
+		}
+		if frame.InApp == test.cgo {
+			t.Errorf("incorrect InApp for %s.%s: got %v", test.module, test.function, frame.InApp)
+		}
+	}
+}
+
+func recurse(depth int) *Stacktrace {
+	if depth == 0 {
+		return NewStacktrace(0, 0, []string{thisPackage})
+	}
+	return recurse(depth - 1)
+}
+
+func TestStacktraceMaxFrames(t *testing.T) {
+	defer func(max int) { MaxFrames = max }(MaxFrames)
+
+	MaxFrames = 0
+	full := recurse(50)
+
+	MaxFrames = 11
+	trimmed := recurse(50)
+
+	if len(trimmed.Frames) != 11 {
+		t.Fatalf("incorrect frame count: got %d, want 11", len(trimmed.Frames))
+	}
+	omitted := len(full.Frames) - 11
+	if expected := []int{5, 5 + omitted}; fmt.Sprint(trimmed.FramesOmitted) != fmt.Sprint(expected) {
+		t.Errorf("incorrect omitted frames: got %v, want %v", trimmed.FramesOmitted, expected)
+	}
+	for i, f := range trimmed.Frames {
+		j := i
+		if i >= 5 {
+			j += omitted
+		}
+		if f.Function != full.Frames[j].Function {
+			t.Errorf("incorrect frame %d: got %s, want %s", i, f.Function, full.Frames[j].Function)
+		}
+	}
+	if full.FramesOmitted != nil {
+		t.Errorf("frames omitted from full stacktrace: %v", full.FramesOmitted)
+	}
+}
+
+func TestDeepStacktrace(t *testing.T) {
+	defer func(max int) { MaxFrames = max }(MaxFrames)
+	MaxFrames = 0
+
+	// Twice, to use a pooled buffer grown by the first call
+	for i := 0; i < 2; i++ {
+		var count int
+		for _, f := range recurse(150).Frames {
+			if f.Function == "recurse" {
+				count++
+			}
+		}
+		if count != 151 {
+			t.Errorf("incorrect recurse frame count: got %d, want 151", count)
+		}
+	}
+}
+
+func atDepth(depth int, f func()) {
+	if depth == 0 {
+		f()
+		return
+	}
+	atDepth(depth-1, f)
+}
+
+func BenchmarkNewStacktrace(b *testing.B) {
+	for _, depth := range []int{10, 150} {
+		b.Run(fmt.Sprintf("depth=%d", depth), func(b *testing.B) {
+			b.ReportAllocs()
+			atDepth(depth, func() {
+				for i := 0; i < b.N; i++ {
+					NewStacktrace(0, 0, []string{thisPackage})
+				}
+			})
+		})
+	}
+}
+
+func TestFileContextWithoutLine(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal("failed to create temporary directory:", err)
+	}
+	defer os.RemoveAll(tempdir)
+	path := filepath.Join(tempdir, "ok")
+	if err := ioutil.WriteFile(path, []byte("hello\nworld\n"), 0600); err != nil {
+		t.Fatal("failed writing file:", err)
+	}
+
+	loader := &fsLoader{cache: make(map[string][][]byte)}
+	for _, line := range []int{0, -1, 10} {
+		if lines, index := loader.Load(path, line, 1); lines != nil || index != 0 {
+			t.Errorf("fileContext(%d) = %q, %d; expected no lines", line, lines, index)
+		}
+	}
+
+	if lines, _ := (noSourceCodeLoader{}).Load(path, 1, 1); lines != nil {
+		t.Errorf("unexpected lines from noSourceCodeLoader: %q", lines)
+	}
+}
@@ -0,0 +1,262 @@
+package raven
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitError is returned by HTTPTransport when Sentry rejects an event
+// because the project is over its rate limit.
+type RateLimitError struct {
+	// The time before which Sentry asked not to send more events.
+	Until time.Time
+
+	// The content of the X-Sentry-Error header.
+	Reason string
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("raven: got http status 429 - x-sentry-error: %s", e.Reason)
+}
+
+// The default back-off when a 429 response has no usable Retry-After header.
+const defaultRetryAfter = 60 * time.Second
+
+func parseRetryAfter(header string, now time.Time) time.Time {
+	if seconds, err := strconv.ParseFloat(header, 64); err == nil && seconds >= 0 {
+		return now.Add(time.Duration(seconds * float64(time.Second)))
+	}
+	if date, err := time.Parse(time.RFC1123, header); err == nil {
+		return date
+	}
+	return now.Add(defaultRetryAfter)
+}
+
+// The number of recently captured events kept for diagnostics.
+const maxRecentEvents = 20
+
+// recentEvent describes a captured event and what became of it.
+type recentEvent struct {
+	EventID   string    `json:"event_id"`
+	Timestamp time.Time `json:"timestamp"`
+	Level     Severity  `json:"level"`
+	Message   string    `json:"message"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// clientStats keeps track of what the client did with captured events, to
+// diagnose events that never show up in Sentry.
+type clientStats struct {
+	mu sync.Mutex
+
+	queued  uint64
+	sent    uint64
+	failed  uint64
+	dropped uint64
+
+	lastSuccess         time.Time
+	lastError           error
+	lastErrorTime       time.Time
+	consecutiveFailures int
+	rateLimitedUntil    time.Time
+
+	// Events lost since the last one delivered, reported to Sentry in a
+	// breadcrumb of the next event.
+	loss eventLoss
+
+	recent []recentEvent
+}
+
+// eventLoss counts events the client failed to deliver since a given time.
+type eventLoss struct {
+	dropped     int
+	rateLimited int
+	since       time.Time
+}
+
+func (l eventLoss) empty() bool { return l.dropped == 0 && l.rateLimited == 0 }
+
+func (l *eventLoss) add(dropped, rateLimited int, now time.Time) {
+	if l.empty() {
+		l.since = now
+	}
+	l.dropped += dropped
+	l.rateLimited += rateLimited
+}
+
+// breadcrumb describes the loss, e.g. "3 events dropped in last 60s".
+func (l eventLoss) breadcrumb(now time.Time) *Breadcrumb {
+	var parts []string
+	if l.dropped > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s dropped", l.dropped, plural(l.dropped, "event")))
+	}
+	if l.rateLimited > 0 {
+		parts = append(parts, fmt.Sprintf("%d %s rate-limited", l.rateLimited, plural(l.rateLimited, "event")))
+	}
+	return &Breadcrumb{
+		Timestamp: Timestamp(now),
+		Type:      "default",
+		Category:  "sentry.internal",
+		Level:     WARNING,
+		Message:   fmt.Sprintf("%s in last %ds", strings.Join(parts, ", "), int(now.Sub(l.since)/time.Second)),
+		Data: map[string]interface{}{
+			"dropped":      l.dropped,
+			"rate_limited": l.rateLimited,
+		},
+	}
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+	return noun + "s"
+}
+
+// lossBreadcrumb returns a breadcrumb reporting the events lost so far, and
+// the loss it reports, to be passed to clearLoss once it was delivered. The
+// breadcrumb is nil when nothing was lost.
+func (s *clientStats) lossBreadcrumb() (*Breadcrumb, eventLoss) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loss.empty() {
+		return nil, eventLoss{}
+	}
+	return s.loss.breadcrumb(time.Now()), s.loss
+}
+
+// clearLoss forgets the reported loss, keeping anything lost since.
+func (s *clientStats) clearLoss(reported eventLoss) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loss.dropped -= reported.dropped
+	s.loss.rateLimited -= reported.rateLimited
+	if !s.loss.empty() {
+		s.loss.since = time.Now()
+	}
+}
+
+func (s *clientStats) addRecent(packet *Packet, status string, err error) {
+	event := recentEvent{
+		EventID:   packet.EventID,
+		Timestamp: time.Time(packet.Timestamp),
+		Level:     packet.Level,
+		Message:   packet.Message,
+		Status:    status,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	if len(s.recent) >= maxRecentEvents {
+		s.recent = s.recent[1:]
+	}
+	s.recent = append(s.recent, event)
+}
+
+func (s *clientStats) updateRecent(eventID, status string, err error) {
+	for i := len(s.recent) - 1; i >= 0; i-- {
+		if s.recent[i].EventID == eventID {
+			s.recent[i].Status = status
+			if err != nil {
+				s.recent[i].Error = err.Error()
+			}
+			return
+		}
+	}
+}
+
+func (s *clientStats) recordQueued(packet *Packet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queued++
+	s.addRecent(packet, "queued", nil)
+}
+
+func (s *clientStats) recordDropped(packet *Packet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped++
+	s.loss.add(1, 0, time.Now())
+	s.addRecent(packet, "dropped", ErrPacketDropped)
+}
+
+func (s *clientStats) recordExpired(packet *Packet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped++
+	s.loss.add(1, 0, time.Now())
+	s.updateRecent(packet.EventID, "expired", ErrEventExpired)
+}
+
+func (s *clientStats) recordSend(packet *Packet, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if err == nil {
+		s.sent++
+		s.lastSuccess = now
+		s.consecutiveFailures = 0
+		s.updateRecent(packet.EventID, "sent", nil)
+		return
+	}
+
+	s.failed++
+	s.lastError = err
+	s.lastErrorTime = now
+	s.consecutiveFailures++
+	if rle, ok := err.(*RateLimitError); ok {
+		s.rateLimitedUntil = rle.Until
+		s.loss.add(0, 1, now)
+	}
+	s.updateRecent(packet.EventID, "failed", err)
+}
+
+// HealthReport summarizes the state of a client, so that services can wire it into
+// readiness endpoints or report it periodically.
+type HealthReport struct {
+	// Whether a DSN is set. Without one, events are silently discarded.
+	Configured bool
+
+	// The last time an event was delivered, zero if none was.
+	LastSuccess time.Time
+
+	// The last error returned by the transport, and how many sends in a row
+	// have failed since the last success.
+	LastError           error
+	ConsecutiveFailures int
+
+	// The fraction of the queue in use, from 0 to 1. Events are dropped once
+	// the queue is full.
+	QueueUtilization float64
+
+	// Set while Sentry is rejecting events because of rate limiting.
+	RateLimitedUntil time.Time
+}
+
+// Health returns a snapshot of the client's delivery state.
+func (client *Client) Health() HealthReport {
+	h := HealthReport{Configured: client.URL() != ""}
+	if c := cap(client.queue); c > 0 {
+		h.QueueUtilization = float64(len(client.queue)) / float64(c)
+	}
+
+	s := &client.stats
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	h.LastSuccess = s.lastSuccess
+	h.LastError = s.lastError
+	h.ConsecutiveFailures = s.consecutiveFailures
+	if time.Now().Before(s.rateLimitedUntil) {
+		h.RateLimitedUntil = s.rateLimitedUntil
+	}
+	return h
+}
+
+// Health returns a snapshot of the default *Client's delivery state
+func Health() HealthReport { return DefaultClientInstance().Health() }
@@ -0,0 +1,116 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2000, 01, 01, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		header   string
+		expected time.Time
+	}{
+		{"", now.Add(defaultRetryAfter)},
+		{"garbage", now.Add(defaultRetryAfter)},
+		{"10", now.Add(10 * time.Second)},
+		{"1.5", now.Add(1500 * time.Millisecond)},
+		{"Sat, 01 Jan 2000 00:02:00 GMT", now.Add(2 * time.Minute)},
+	}
+
+	for _, test := range tests {
+		if actual := parseRetryAfter(test.header, now); !actual.Equal(test.expected) {
+			t.Errorf("incorrect time for %q: got %v, want %v", test.header, actual, test.expected)
+		}
+	}
+}
+
+func TestHealth(t *testing.T) {
+	client, _ := newTestClient()
+	client.url = ""
+
+	if h := client.Health(); h.Configured || !h.LastSuccess.IsZero() || h.QueueUtilization != 0 {
+		t.Errorf("incorrect initial health: %+v", h)
+	}
+
+	client.SetDSN("https://u@example.com/1")
+	client.CaptureMessage("ok", nil)
+	client.Wait()
+
+	failure := errors.New("connection refused")
+	client.Transport = &failingTransport{failure}
+	client.CaptureMessage("ko", nil)
+	client.CaptureMessage("ko", nil)
+	client.Wait()
+
+	h := client.Health()
+	if !h.Configured || h.LastSuccess.IsZero() {
+		t.Errorf("incorrect health: %+v", h)
+	}
+	if h.LastError != failure || h.ConsecutiveFailures != 2 {
+		t.Errorf("incorrect failures: %+v", h)
+	}
+	if !h.RateLimitedUntil.IsZero() {
+		t.Errorf("unexpected rate limit: %v", h.RateLimitedUntil)
+	}
+}
+
+func TestEventLossBreadcrumb(t *testing.T) {
+	now := time.Date(2000, 01, 01, 0, 1, 0, 0, time.UTC)
+	tests := []struct {
+		loss     eventLoss
+		expected string
+	}{
+		{eventLoss{dropped: 3, since: now.Add(-time.Minute)}, "3 events dropped in last 60s"},
+		{eventLoss{rateLimited: 1, since: now.Add(-1500 * time.Millisecond)}, "1 event rate-limited in last 1s"},
+		{eventLoss{dropped: 1, rateLimited: 2, since: now}, "1 event dropped, 2 events rate-limited in last 0s"},
+	}
+
+	for _, test := range tests {
+		b := test.loss.breadcrumb(now)
+		if b.Message != test.expected {
+			t.Errorf("incorrect message: got %q, want %q", b.Message, test.expected)
+		}
+		if b.Category != "sentry.internal" || b.Data["dropped"] != test.loss.dropped || b.Data["rate_limited"] != test.loss.rateLimited {
+			t.Errorf("incorrect breadcrumb: %+v", b)
+		}
+	}
+}
+
+func TestLossReportedInNextEvent(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetDSN("https://u@example.com/1")
+
+	client.Transport = &failingTransport{&RateLimitError{Until: time.Now().Add(time.Minute)}}
+	client.CaptureMessage("limited", nil)
+	client.CaptureMessage("limited", nil)
+	client.Wait()
+
+	client.Transport = transport
+	packet := NewPacket("first")
+	client.Capture(packet, nil)
+	client.CaptureMessage("second", nil)
+	client.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("incorrect packet count: got %d, want 2", len(transport.packets))
+	}
+	var crumbs *Breadcrumbs
+	for _, inter := range transport.packets[0].Interfaces {
+		if b, ok := inter.(*Breadcrumbs); ok {
+			crumbs = b
+		}
+	}
+	if crumbs == nil || len(crumbs.Values) != 1 || crumbs.Values[0].Message != "2 events rate-limited in last 0s" {
+		t.Errorf("incorrect breadcrumbs: %+v", crumbs)
+	}
+	if len(packet.Interfaces) != 0 {
+		t.Errorf("captured packet was modified: %+v", packet.Interfaces)
+	}
+	for _, inter := range transport.packets[1].Interfaces {
+		if _, ok := inter.(*Breadcrumbs); ok {
+			t.Errorf("loss reported twice: %+v", inter)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package raven
+
+import "time"
+
+// SetSyncTransport makes the client send events on the capturing goroutine,
+// with no queue nor background worker, giving up after timeout. It suits
+// serverless functions and short-lived commands, which can exit as soon as
+// Capture returns. A timeout of 0 restores the background worker. The timeout
+// is only enforced with transports implementing ContextTransport, such as
+// HTTPTransport.
+func (client *Client) SetSyncTransport(timeout time.Duration) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.syncTimeout = timeout
+}
+
+// SetSyncTransport makes the default *Client send events synchronously
+func SetSyncTransport(timeout time.Duration) { DefaultClientInstance().SetSyncTransport(timeout) }
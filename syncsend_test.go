@@ -0,0 +1,50 @@
+package raven
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyncTransport(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetSyncTransport(time.Second)
+
+	_, ch := client.Capture(NewPacket("message"), nil)
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect packet count once Capture returned: got %d, want 1", len(transport.packets))
+	}
+	select {
+	case err := <-ch:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	default:
+		t.Error("expected the channel to be resolved once Capture returned")
+	}
+}
+
+func TestSyncTransportTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := newClient(nil)
+	client.Transport = &HTTPTransport{Client: http.DefaultClient}
+	client.SetDSN("http://public@" + strings.TrimPrefix(server.URL, "http://") + "/1")
+	client.SetSyncTransport(50 * time.Millisecond)
+
+	start := time.Now()
+	_, ch := client.Capture(NewPacket("message"), nil)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Capture took %s", elapsed)
+	}
+	if err := <-ch; err == nil || !strings.Contains(err.Error(), "deadline exceeded") {
+		t.Errorf("incorrect error: got %v, want a deadline error", err)
+	}
+}
@@ -0,0 +1,72 @@
+package raven
+
+import (
+	gocontext "context"
+	"log"
+	"path"
+	"time"
+)
+
+// An Archiver stores serialized events for long-term retention, e.g. in an S3
+// or GCS bucket:
+//
+//	func (a *s3Archiver) Put(key string, data []byte) error {
+//		_, err := a.client.PutObject(ctx, &s3.PutObjectInput{
+//			Bucket: aws.String(a.bucket),
+//			Key:    aws.String(key),
+//			Body:   bytes.NewReader(data),
+//		})
+//		return err
+//	}
+type Archiver interface {
+	Put(key string, data []byte) error
+}
+
+// TeeTransport sends events through Transport and also archives them, in the
+// canonical JSON form read back by Packet.UnmarshalCanonical, so that they can
+// be kept beyond Sentry's retention window. Events are archived under
+// "<project>/<yyyy>/<mm>/<dd>/<event id>.json".
+type TeeTransport struct {
+	Transport Transport
+	Archiver  Archiver
+
+	// Called when an event can't be archived, instead of logging the error.
+	// Archiving failures don't fail the send.
+	OnArchiveError func(packet *Packet, err error)
+}
+
+func (t *TeeTransport) Send(url, authHeader string, packet *Packet) error {
+	if err := t.archive(packet); err != nil {
+		if t.OnArchiveError != nil {
+			t.OnArchiveError(packet, err)
+		} else {
+			log.Printf("raven: failed to archive event %s: %v", packet.EventID, err)
+		}
+	}
+	return t.Transport.Send(url, authHeader, packet)
+}
+
+// SendEnvelope sends envelope through Transport, if it is an
+// EnvelopeTransport. Envelopes aren't archived.
+func (t *TeeTransport) SendEnvelope(ctx gocontext.Context, url, authHeader string, envelope []byte) error {
+	if et, ok := t.Transport.(EnvelopeTransport); ok {
+		return et.SendEnvelope(ctx, url, authHeader, envelope)
+	}
+	return ErrEnvelopesUnsupported
+}
+
+func (t *TeeTransport) archive(packet *Packet) error {
+	data, err := packet.MarshalCanonical()
+	if err != nil {
+		return err
+	}
+	return t.Archiver.Put(archiveKey(packet), data)
+}
+
+func archiveKey(packet *Packet) string {
+	project := packet.Project
+	if project == "" {
+		project = "unknown"
+	}
+	return path.Join(project, time.Time(packet.Timestamp).UTC().Format("2006/01/02"), packet.EventID+".json")
+}
@@ -0,0 +1,55 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type testArchiver struct {
+	objects map[string][]byte
+	err     error
+}
+
+func (a *testArchiver) Put(key string, data []byte) error {
+	if a.err != nil {
+		return a.err
+	}
+	a.objects[key] = data
+	return nil
+}
+
+func TestTeeTransport(t *testing.T) {
+	sentry := &testTransport{}
+	archiver := &testArchiver{objects: make(map[string][]byte)}
+	transport := &TeeTransport{Transport: sentry, Archiver: archiver}
+
+	packet := NewPacket("failed")
+	packet.Init("1")
+	packet.Timestamp = Timestamp(time.Date(2024, 3, 9, 12, 0, 0, 0, time.UTC))
+	if err := transport.Send("", "", packet); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sentry.packets) != 1 {
+		t.Errorf("expected the event to be sent, got %d", len(sentry.packets))
+	}
+	data, ok := archiver.objects["1/2024/03/09/"+packet.EventID+".json"]
+	if !ok {
+		t.Fatalf("expected the event to be archived, got %v", archiver.objects)
+	}
+	var archived Packet
+	if err := archived.UnmarshalCanonical(data); err != nil || archived.EventID != packet.EventID {
+		t.Errorf("incorrect archived event: %s", data)
+	}
+
+	archiver.err = errors.New("bucket not found")
+	var archiveErr error
+	transport.OnArchiveError = func(packet *Packet, err error) { archiveErr = err }
+	if err := transport.Send("", "", packet); err != nil {
+		t.Errorf("expected archiving failures not to fail the send, got %v", err)
+	}
+	if archiveErr != archiver.err {
+		t.Errorf("incorrect archive error: got %v, want %v", archiveErr, archiver.err)
+	}
+}
@@ -0,0 +1,125 @@
+package raven
+
+import (
+	gocontext "context"
+	"strconv"
+)
+
+// WorkflowInfo identifies a Temporal or Cadence workflow execution, and the
+// activity being run when reporting an activity.
+type WorkflowInfo struct {
+	WorkflowID   string
+	RunID        string
+	WorkflowType string
+	TaskQueue    string
+
+	ActivityType string
+	Attempt      int
+}
+
+func (i *WorkflowInfo) tags() map[string]string {
+	tags := map[string]string{
+		"workflow.id":         i.WorkflowID,
+		"workflow.run_id":     i.RunID,
+		"workflow.type":       i.WorkflowType,
+		"workflow.task_queue": i.TaskQueue,
+	}
+	if i.ActivityType != "" {
+		tags["workflow.activity"] = i.ActivityType
+	}
+	if i.Attempt > 0 {
+		tags["workflow.attempt"] = strconv.Itoa(i.Attempt)
+	}
+	return tags
+}
+
+// WorkflowInterceptor reports failures and panics of Temporal or Cadence
+// activities and workflows, tagged with the workflow execution. It doesn't
+// depend on either SDK: call its methods from the SDK's interceptors, e.g. for
+// Temporal:
+//
+//	func (a *activityInbound) ExecuteActivity(ctx context.Context, in *interceptor.ExecuteActivityInput) (interface{}, error) {
+//		info := activity.GetInfo(ctx)
+//		return a.raven.ExecuteActivity(ctx, raven.WorkflowInfo{
+//			WorkflowID:   info.WorkflowExecution.ID,
+//			RunID:        info.WorkflowExecution.RunID,
+//			WorkflowType: info.WorkflowType.Name,
+//			TaskQueue:    info.TaskQueue,
+//			ActivityType: info.ActivityType.Name,
+//			Attempt:      int(info.Attempt),
+//		}, func(ctx context.Context) (interface{}, error) {
+//			return a.Next.ExecuteActivity(ctx, in)
+//		})
+//	}
+type WorkflowInterceptor struct {
+	// The client reporting failures, DefaultClient if nil.
+	Client *Client
+
+	// Whether to report workflow failures. Reporting from workflow code
+	// is a side effect, so it is off by default and never done while the
+	// workflow is replaying; activity failures usually tell the story.
+	CaptureWorkflows bool
+}
+
+func (w *WorkflowInterceptor) client() *Client {
+	if w.Client == nil {
+		return DefaultClientInstance()
+	}
+	return w.Client
+}
+
+// ExecuteActivity runs an activity through next, reporting its error or
+// panic. Panics are re-raised so that the SDK handles them as usual.
+// Cancellations are not reported.
+func (w *WorkflowInterceptor) ExecuteActivity(ctx gocontext.Context, info WorkflowInfo, next func(gocontext.Context) (interface{}, error)) (interface{}, error) {
+	client := w.client()
+	defer func() {
+		if rval := recover(); rval != nil {
+			w.capturePanic(client, &info, rval)
+		}
+	}()
+
+	result, err := next(ctx)
+	if err != nil && ctx.Err() == nil {
+		w.captureError(client, &info, err)
+	}
+	return result, err
+}
+
+// ExecuteWorkflow runs a workflow through next, reporting its error or panic
+// when CaptureWorkflows is set and replaying, which should be
+// workflow.IsReplaying, returns false.
+func (w *WorkflowInterceptor) ExecuteWorkflow(info WorkflowInfo, replaying func() bool, next func() (interface{}, error)) (interface{}, error) {
+	if !w.CaptureWorkflows {
+		return next()
+	}
+
+	client := w.client()
+	defer func() {
+		if rval := recover(); rval != nil {
+			if replaying() {
+				panic(rval)
+			}
+			w.capturePanic(client, &info, rval)
+		}
+	}()
+
+	result, err := next()
+	if err != nil && !replaying() {
+		w.captureError(client, &info, err)
+	}
+	return result, err
+}
+
+func (w *WorkflowInterceptor) captureError(client *Client, info *WorkflowInfo, err error) {
+	packet := client.newErrorPacket(2, err, nil)
+	client.Capture(packet, info.tags())
+}
+
+// capturePanic reports a recovered panic and re-raises it.
+func (w *WorkflowInterceptor) capturePanic(client *Client, info *WorkflowInfo, rval interface{}) {
+	packet := client.newPanicPacket(3, rval, nil)
+	packet.Level = FATAL
+	client.Capture(packet, info.tags())
+	panic(rval)
+}
@@ -0,0 +1,76 @@
+package raven
+
+import (
+	gocontext "context"
+	"errors"
+	"testing"
+)
+
+func TestWorkflowInterceptorActivity(t *testing.T) {
+	client, transport := newTestClient()
+	interceptor := &WorkflowInterceptor{Client: client}
+	info := WorkflowInfo{WorkflowID: "order-7", RunID: "run-1", TaskQueue: "orders", ActivityType: "Charge", Attempt: 3}
+
+	_, err := interceptor.ExecuteActivity(gocontext.Background(), info, func(gocontext.Context) (interface{}, error) {
+		return nil, errors.New("card declined")
+	})
+	if err == nil || err.Error() != "card declined" {
+		t.Errorf("incorrect error: %v", err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("expected the panic to be re-raised")
+			}
+		}()
+		interceptor.ExecuteActivity(gocontext.Background(), info, func(gocontext.Context) (interface{}, error) {
+			panic("boom")
+		})
+	}()
+
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
+	cancel()
+	interceptor.ExecuteActivity(ctx, info, func(ctx gocontext.Context) (interface{}, error) {
+		return nil, ctx.Err()
+	})
+	client.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(transport.packets))
+	}
+	if packet := transport.packets[1]; packet.Level != FATAL || packet.Message != "boom" {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+	tags := make(map[string]string)
+	for _, tag := range transport.packets[0].Tags {
+		tags[tag.Key] = tag.Value
+	}
+	if tags["workflow.id"] != "order-7" || tags["workflow.run_id"] != "run-1" || tags["workflow.task_queue"] != "orders" || tags["workflow.attempt"] != "3" {
+		t.Errorf("incorrect tags: %+v", transport.packets[0].Tags)
+	}
+}
+
+func TestWorkflowInterceptorWorkflow(t *testing.T) {
+	failure := func() (interface{}, error) { return nil, errors.New("failed") }
+	tests := []struct {
+		capture   bool
+		replaying bool
+		reported  bool
+	}{
+		{false, false, false},
+		{true, true, false},
+		{true, false, true},
+	}
+
+	for i, test := range tests {
+		client, transport := newTestClient()
+		interceptor := &WorkflowInterceptor{Client: client, CaptureWorkflows: test.capture}
+		interceptor.ExecuteWorkflow(WorkflowInfo{WorkflowID: "order-7"}, func() bool { return test.replaying }, failure)
+		client.Wait()
+
+		if reported := len(transport.packets) == 1; reported != test.reported {
+			t.Errorf("%d: incorrect decision: got %v, want %v", i, reported, test.reported)
+		}
+	}
+}
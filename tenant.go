@@ -0,0 +1,185 @@
+package raven
+
+import (
+	"sync"
+	"time"
+)
+
+// The number of tenants a client remembers. Tenants past their quota window
+// are forgotten first.
+var MaxTenants = 10000
+
+// A Tenant captures the events of one tenant of a multi-tenant service through
+// its client. Its events are tagged with "tenant", held to the per-tenant
+// quota set with SetTenantQuota, and sent to the DSN set with SetTenantDSN if
+// any.
+//
+// Example:
+//
+//	client.SetTenantQuota(100, time.Minute)
+//	client.SetTenantDSN("acme", acmeDSN)
+//	client.ForTenant(tenantID).CaptureError(err, nil)
+type Tenant struct {
+	client *Client
+	id     string
+
+	mu          sync.Mutex
+	windowStart time.Time
+	captured    int
+}
+
+// ForTenant returns the Tenant capturing the events of tenant id. The same
+// Tenant, and so the same quota, is returned for every call with the same id,
+// unless more than MaxTenants tenants were asked for since.
+func (client *Client) ForTenant(id string) *Tenant {
+	client.mu.RLock()
+	t := client.tenants[id]
+	client.mu.RUnlock()
+	if t != nil {
+		return t
+	}
+
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	if t = client.tenants[id]; t == nil {
+		if client.tenants == nil {
+			client.tenants = make(map[string]*Tenant)
+		}
+		if len(client.tenants) >= MaxTenants {
+			client.forgetTenants(time.Now())
+		}
+		t = &Tenant{client: client, id: id}
+		client.tenants[id] = t
+	}
+	return t
+}
+
+// ForTenant returns the Tenant capturing the events of tenant id with the default *Client
+func ForTenant(id string) *Tenant { return DefaultClientInstance().ForTenant(id) }
+
+// forgetTenants makes room for a tenant, forgetting those past their quota
+// window, or all of them if none is. client.mu must be held.
+func (client *Client) forgetTenants(now time.Time) {
+	window := client.tenantQuotaWindow
+	for id, t := range client.tenants {
+		t.mu.Lock()
+		idle := now.Sub(t.windowStart) >= window
+		t.mu.Unlock()
+		if idle {
+			delete(client.tenants, id)
+		}
+	}
+	if len(client.tenants) >= MaxTenants {
+		client.tenants = make(map[string]*Tenant)
+	}
+}
+
+// SetTenantQuota limits every tenant to capturing events per window. Only the
+// events left once sampling, filters and event processors have run count
+// against the quota. Those past it are dropped, and Capture resolves their
+// channel with ErrTenantQuotaExceeded. An events of 0, the default, doesn't
+// limit tenants.
+func (client *Client) SetTenantQuota(events int, window time.Duration) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.tenantQuota, client.tenantQuotaWindow = events, window
+}
+
+// SetTenantQuota sets the per-tenant quota of the default *Client
+func SetTenantQuota(events int, window time.Duration) {
+	DefaultClientInstance().SetTenantQuota(events, window)
+}
+
+// SetTenantDSN sends the events of tenant id to dsn rather than to the
+// client's DSN, replacing any DSN set for the tenant before. An empty dsn
+// sends them to the client's DSN again. Tenant DSNs take precedence over the
+// routes added with AddDSNRoute.
+func (client *Client) SetTenantDSN(id, dsn string) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if dsn == "" {
+		delete(client.tenantRoutes, id)
+		return nil
+	}
+	d, err := ParseDSN(dsn, client.strictDSN)
+	if err != nil {
+		return err
+	}
+	if client.tenantRoutes == nil {
+		client.tenantRoutes = make(map[string]*dsnRoute)
+	}
+	client.tenantRoutes[id] = &dsnRoute{
+		dsn:        d,
+		url:        d.StoreURL(),
+		authHeader: client.authHeaderFor(d),
+	}
+	return nil
+}
+
+// SetTenantDSN sends the events of tenant id of the default *Client to dsn
+func SetTenantDSN(id, dsn string) error { return DefaultClientInstance().SetTenantDSN(id, dsn) }
+
+// ID returns the ID of the tenant.
+func (t *Tenant) ID() string { return t.id }
+
+// Capture is like Client.Capture, for the tenant.
+func (t *Tenant) Capture(packet *Packet, captureTags map[string]string) (eventID string, ch chan error) {
+	if packet != nil {
+		packet.tenant = t
+	}
+	return t.client.Capture(packet, t.tags(captureTags))
+}
+
+// CaptureError is like Client.CaptureError, for the tenant.
+func (t *Tenant) CaptureError(err error, tags map[string]string, interfaces ...Interface) string {
+	return t.client.captureError(1, err, t.tags(tags), t.interfaces(interfaces))
+}
+
+// CaptureMessage is like Client.CaptureMessage, for the tenant.
+func (t *Tenant) CaptureMessage(message string, tags map[string]string, interfaces ...Interface) string {
+	return t.client.CaptureMessage(message, t.tags(tags), t.interfaces(interfaces)...)
+}
+
+// tenantOption marks the event it is passed with as captured for a tenant. It
+// is passed along with the interfaces of the event but never sent as one.
+type tenantOption struct{ tenant *Tenant }
+
+func (tenantOption) Class() string { return "tenant" }
+
+// interfaces returns interfaces with the option marking the tenant's events.
+func (t *Tenant) interfaces(interfaces []Interface) []Interface {
+	return append(append(make([]Interface, 0, len(interfaces)+1), interfaces...), tenantOption{t})
+}
+
+// tags returns tags with the tenant tag added.
+func (t *Tenant) tags(tags map[string]string) map[string]string {
+	withTenant := make(map[string]string, len(tags)+1)
+	for k, v := range tags {
+		withTenant[k] = v
+	}
+	withTenant["tenant"] = t.id
+	return withTenant
+}
+
+// allow reports whether the tenant may capture an event at now, counting it
+// against its quota.
+func (t *Tenant) allow(now time.Time) bool {
+	t.client.mu.RLock()
+	quota, window := t.client.tenantQuota, t.client.tenantQuotaWindow
+	t.client.mu.RUnlock()
+	if quota <= 0 {
+		return true
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.windowStart) >= window {
+		t.windowStart, t.captured = now, 0
+	}
+	if t.captured >= quota {
+		return false
+	}
+	t.captured++
+	return true
+}
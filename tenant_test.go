@@ -0,0 +1,181 @@
+package raven
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestForTenant(t *testing.T) {
+	client := newClient(nil)
+	release := make(chan struct{})
+	close(release)
+	transport := &urlTransport{blockingTransport: blockingTransport{started: make(chan struct{}, 10), release: release}}
+	client.Transport = transport
+	client.SetDSN("https://platform@example.com/1")
+	client.SetSDK("raven-go", "1.2.0")
+	if err := client.SetTenantDSN("acme", "https://acme@example.com/2"); err != nil {
+		t.Fatal(err)
+	}
+
+	acme := client.ForTenant("acme")
+	if client.ForTenant("acme") != acme {
+		t.Error("ForTenant returned another Tenant for the same ID")
+	}
+	_, ch := acme.Capture(NewPacket("acme"), map[string]string{"component": "billing"})
+	<-ch
+	_, ch = client.ForTenant("globex").Capture(NewPacket("globex"), nil)
+	<-ch
+
+	expected := []string{
+		"https://example.com/api/2/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=acme 2",
+		"https://example.com/api/1/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=platform 1",
+	}
+	if !reflect.DeepEqual(transport.urls, expected) {
+		t.Errorf("incorrect destinations:\ngot  %q\nwant %q", transport.urls, expected)
+	}
+}
+
+func TestTenantTags(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetIncludePaths([]string{"github.com/getsentry/raven-go"})
+	client.Capture(NewPacket("before"), nil)
+	client.ForTenant("acme").CaptureError(errors.New("failed"), map[string]string{"component": "billing"})
+	client.ForTenant("acme").CaptureMessage("hello", nil)
+	client.Wait()
+
+	if len(transport.packets) != 3 {
+		t.Fatalf("incorrect number of packets sent: got %d, want 3", len(transport.packets))
+	}
+	for _, packet := range transport.packets[1:] {
+		tags := make(map[string]string)
+		for _, tag := range packet.Tags {
+			tags[tag.Key] = tag.Value
+		}
+		if tags["tenant"] != "acme" {
+			t.Errorf("%s: incorrect tenant tag: got %q, want %q", packet.Message, tags["tenant"], "acme")
+		}
+	}
+	if expected := "github.com/getsentry/raven-go.TestTenantTags"; transport.packets[1].Culprit != expected {
+		t.Errorf("incorrect culprit: got %s, want %s", transport.packets[1].Culprit, expected)
+	}
+}
+
+func TestTenantQuota(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetTenantQuota(2, time.Hour)
+
+	acme, globex := client.ForTenant("acme"), client.ForTenant("globex")
+	for i := 0; i < 3; i++ {
+		_, ch := acme.Capture(NewPacket("acme"), nil)
+		err := <-ch
+		if i < 2 && err != nil {
+			t.Errorf("%d: unexpected error: %v", i, err)
+		} else if i == 2 && err != ErrTenantQuotaExceeded {
+			t.Errorf("incorrect error past the quota: got %v, want %v", err, ErrTenantQuotaExceeded)
+		}
+	}
+	if eventID := globex.CaptureMessage("globex", nil); eventID == "" {
+		t.Error("tenant limited by the quota of another")
+	}
+	client.Wait()
+	if len(transport.packets) != 3 {
+		t.Errorf("incorrect number of packets sent: got %d, want 3", len(transport.packets))
+	}
+
+	start := time.Now()
+	if !acme.allow(start.Add(time.Hour)) {
+		t.Error("quota not reset in the next window")
+	}
+}
+
+func TestSetTenantDSNReplaces(t *testing.T) {
+	client := newClient(nil)
+	release := make(chan struct{})
+	close(release)
+	transport := &urlTransport{blockingTransport: blockingTransport{started: make(chan struct{}, 10), release: release}}
+	client.Transport = transport
+	client.SetDSN("https://platform@example.com/1")
+	client.SetSDK("raven-go", "1.2.0")
+
+	acme := client.ForTenant("acme")
+	for _, dsn := range []string{"https://acme@example.com/2", "https://acme@example.com/3", ""} {
+		if err := client.SetTenantDSN("acme", dsn); err != nil {
+			t.Fatal(err)
+		}
+		_, ch := acme.Capture(NewPacket("acme"), nil)
+		<-ch
+	}
+
+	expected := []string{
+		"https://example.com/api/2/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=acme 2",
+		"https://example.com/api/3/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=acme 3",
+		"https://example.com/api/1/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=platform 1",
+	}
+	if !reflect.DeepEqual(transport.urls, expected) {
+		t.Errorf("incorrect destinations:\ngot  %q\nwant %q", transport.urls, expected)
+	}
+}
+
+func TestTenantDSNIgnoresTenantTag(t *testing.T) {
+	client := newClient(map[string]string{"tenant": "acme"})
+	release := make(chan struct{})
+	close(release)
+	transport := &urlTransport{blockingTransport: blockingTransport{started: make(chan struct{}, 10), release: release}}
+	client.Transport = transport
+	client.SetDSN("https://platform@example.com/1")
+	client.SetSDK("raven-go", "1.2.0")
+	client.SetTenantDSN("acme", "https://acme@example.com/2")
+
+	// Tagged by the capture and by the client, but not captured for acme
+	_, ch := client.Capture(NewPacket("tagged"), map[string]string{"tenant": "acme"})
+	<-ch
+	_, ch = client.Capture(NewPacket("tagged by the client"), nil)
+	<-ch
+	_, ch = client.ForTenant("acme").Capture(NewPacket("for acme"), nil)
+	<-ch
+
+	expected := []string{
+		"https://example.com/api/1/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=platform 1",
+		"https://example.com/api/1/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=platform 1",
+		"https://example.com/api/2/store/ Sentry sentry_version=7, sentry_client=raven-go/1.2.0, sentry_key=acme 2",
+	}
+	if !reflect.DeepEqual(transport.urls, expected) {
+		t.Errorf("incorrect destinations:\ngot  %q\nwant %q", transport.urls, expected)
+	}
+}
+
+func TestTenantQuotaAfterFiltering(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetTenantQuota(2, time.Hour)
+	client.AddEventProcessor(func(packet *Packet, err error) bool {
+		return packet.Message != "filtered"
+	})
+
+	acme := client.ForTenant("acme")
+	for _, message := range []string{"filtered", "filtered", "first", "second", "third"} {
+		acme.CaptureMessage(message, nil)
+	}
+	client.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Errorf("incorrect number of packets sent: got %d, want 2", len(transport.packets))
+	}
+}
+
+func TestMaxTenants(t *testing.T) {
+	defer func(max int) { MaxTenants = max }(MaxTenants)
+	MaxTenants = 2
+
+	client := newClient(nil)
+	client.SetTenantQuota(1, time.Hour)
+	acme := client.ForTenant("acme")
+	acme.allow(time.Now())
+	client.ForTenant("globex")
+	client.ForTenant("initech")
+
+	if len(client.tenants) != 2 || client.tenants["acme"] != acme {
+		t.Errorf("expected the idle tenant to be forgotten, got %v", client.tenants)
+	}
+}
@@ -0,0 +1,77 @@
+package raven
+
+import (
+	"sync"
+	"time"
+)
+
+// The number of keys CaptureErrorThrottled remembers. Keys past their interval
+// are forgotten first.
+var MaxThrottledKeys = 10000
+
+type throttle struct {
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+// allow reports whether key may be sent at now, and if so holds it back until
+// minInterval has passed.
+func (t *throttle) allow(key string, minInterval time.Duration, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Before(t.until[key]) {
+		return false
+	}
+	if t.until == nil {
+		t.until = make(map[string]time.Time)
+	}
+	if _, ok := t.until[key]; !ok && len(t.until) >= MaxThrottledKeys {
+		for k, until := range t.until {
+			if !now.Before(until) {
+				delete(t.until, k)
+			}
+		}
+		if len(t.until) >= MaxThrottledKeys {
+			t.until = make(map[string]time.Time)
+		}
+	}
+	t.until[key] = now.Add(minInterval)
+	return true
+}
+
+// CaptureErrorThrottled is like CaptureError, but silently skips the error
+// when another one with the same key was sent less than minInterval ago. It is
+// meant for hot loops that would otherwise report the same error over and
+// over. An empty key uses the error message.
+func (client *Client) CaptureErrorThrottled(err error, key string, minInterval time.Duration, tags map[string]string, interfaces ...Interface) string {
+	if client == nil {
+		return ""
+	}
+
+	if err == nil {
+		return ""
+	}
+
+	if client.shouldExcludeErr(err.Error()) {
+		return ""
+	}
+
+	if key == "" {
+		key = err.Error()
+	}
+	if !client.throttle.allow(key, minInterval, time.Now()) {
+		return ""
+	}
+
+	packet := client.newErrorPacket(1, err, interfaces)
+	eventID, _ := client.Capture(packet, tags)
+
+	return eventID
+}
+
+// CaptureErrorThrottled is like CaptureError with the default *Client, skipping
+// errors with the same key sent less than minInterval ago
+func CaptureErrorThrottled(err error, key string, minInterval time.Duration, tags map[string]string, interfaces ...Interface) string {
+	return DefaultClientInstance().CaptureErrorThrottled(err, key, minInterval, tags, interfaces...)
+}
@@ -0,0 +1,43 @@
+package raven
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestThrottleAllow(t *testing.T) {
+	var throttle throttle
+	now := time.Now()
+
+	tests := []struct {
+		key     string
+		offset  time.Duration
+		allowed bool
+	}{
+		{"a", 0, true},
+		{"a", 30 * time.Second, false},
+		{"b", 30 * time.Second, true},
+		{"a", time.Minute, true},
+		{"a", 90 * time.Second, false},
+	}
+
+	for i, test := range tests {
+		if allowed := throttle.allow(test.key, time.Minute, now.Add(test.offset)); allowed != test.allowed {
+			t.Errorf("%d: incorrect decision for %s: got %v, want %v", i, test.key, allowed, test.allowed)
+		}
+	}
+}
+
+func TestCaptureErrorThrottled(t *testing.T) {
+	client, transport := newTestClient()
+	for i := 0; i < 3; i++ {
+		client.CaptureErrorThrottled(errors.New("connection refused"), "", time.Hour, nil)
+	}
+	client.CaptureErrorThrottled(errors.New("connection refused"), "other", time.Hour, nil)
+	client.Wait()
+
+	if len(transport.packets) != 2 {
+		t.Errorf("expected 2 events to be sent, got %d", len(transport.packets))
+	}
+}
@@ -0,0 +1,282 @@
+package raven
+
+import (
+	"bytes"
+	mrand "math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// The prefix used by Sentry for its members of the W3C baggage header.
+const baggagePrefix = "sentry-"
+
+// DynamicSamplingContext holds the trace metadata that is propagated to
+// downstream services through the baggage header so that Sentry can apply
+// server-side dynamic sampling consistently across a whole trace.
+// https://develop.sentry.dev/sdk/performance/dynamic-sampling-context/
+type DynamicSamplingContext struct {
+	TraceID     string
+	PublicKey   string
+	Release     string
+	Environment string
+	Transaction string
+	SampleRate  string
+	Sampled     string
+
+	// Baggage members that don't belong to Sentry, kept so that they survive
+	// being re-serialized.
+	thirdParty []string
+}
+
+// DynamicSamplingContext builds the sampling context for a new trace started
+// by this client. An empty traceID generates a new one.
+func (client *Client) DynamicSamplingContext(traceID, transaction string) *DynamicSamplingContext {
+	if traceID == "" {
+		traceID, _ = uuid()
+	}
+
+	client.mu.RLock()
+	defer client.mu.RUnlock()
+
+	return &DynamicSamplingContext{
+		TraceID:     traceID,
+		PublicKey:   client.publicKey,
+		Release:     client.release,
+		Environment: client.environment,
+		Transaction: transaction,
+		SampleRate:  strconv.FormatFloat(float64(client.tracesSampleRate), 'f', -1, 32),
+	}
+}
+
+// SamplingContext is passed to a TracesSampler to decide whether a new
+// transaction should be traced.
+type SamplingContext struct {
+	TransactionName string
+
+	// The incoming request that started the transaction, if any.
+	Request *http.Request
+
+	// The decision made by an upstream service, nil at the head of the trace.
+	ParentSampled *bool
+
+	// The sampling context propagated by an upstream service, as returned by
+	// ParseBaggage, nil at the head of the trace.
+	Parent *DynamicSamplingContext
+}
+
+// A TracesSampler returns the probability, between 0 and 1, that the
+// transaction described by ctx is traced.
+type TracesSampler func(ctx SamplingContext) float64
+
+// SetTracesSampleRate sets the probability that a transaction is traced when
+// no TracesSampler is set. Tracing is disabled by default.
+func (client *Client) SetTracesSampleRate(rate float32) error {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+
+	if rate < 0 || rate > 1 {
+		return ErrInvalidSampleRate
+	}
+	client.tracesSampleRate = rate
+	return nil
+}
+
+// SetTracesSampler sets a callback that decides the sample rate of each
+// transaction, taking precedence over the flat traces sample rate.
+func (client *Client) SetTracesSampler(sampler TracesSampler) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.tracesSampler = sampler
+}
+
+// SetTracesSampleRate sets the traces sample rate on the default *Client
+func SetTracesSampleRate(rate float32) error {
+	return DefaultClientInstance().SetTracesSampleRate(rate)
+}
+
+// SetTracesSampler sets the traces sampler on the default *Client
+func SetTracesSampler(sampler TracesSampler) { DefaultClientInstance().SetTracesSampler(sampler) }
+
+// SampleTrace makes the head-based sampling decision for a new transaction
+// and returns the sampling context to propagate downstream. A parent sampling
+// context holding a decision is frozen by the head of the trace, so it is
+// returned unchanged for the whole trace to be sampled consistently.
+// Otherwise, without a TracesSampler the upstream decision is honoured,
+// falling back to the traces sample rate at the head of the trace.
+func (client *Client) SampleTrace(traceID string, ctx SamplingContext) *DynamicSamplingContext {
+	if ctx.Parent != nil && ctx.Parent.Sampled != "" {
+		parent := *ctx.Parent
+		parent.thirdParty = append([]string(nil), ctx.Parent.thirdParty...)
+		return &parent
+	}
+	dsc := client.DynamicSamplingContext(traceID, ctx.TransactionName)
+
+	client.mu.RLock()
+	sampler := client.tracesSampler
+	rate := float64(client.tracesSampleRate)
+	client.mu.RUnlock()
+
+	var sampled bool
+	switch {
+	case sampler != nil:
+		rate = sampler(ctx)
+		sampled = rate > 0 && mrand.Float64() < rate
+	case ctx.ParentSampled != nil:
+		sampled = *ctx.ParentSampled
+	default:
+		sampled = rate > 0 && mrand.Float64() < rate
+	}
+
+	dsc.SampleRate = strconv.FormatFloat(rate, 'f', -1, 64)
+	dsc.Sampled = strconv.FormatBool(sampled)
+	return dsc
+}
+
+// SampleTrace makes the head-based sampling decision on the default *Client
+func SampleTrace(traceID string, ctx SamplingContext) *DynamicSamplingContext {
+	return DefaultClientInstance().SampleTrace(traceID, ctx)
+}
+
+// IsSampled reports whether the trace was sampled by the head service.
+func (dsc *DynamicSamplingContext) IsSampled() bool {
+	return dsc.Sampled == "true"
+}
+
+// Baggage serializes the sampling context as the value of a baggage header.
+// Empty fields are omitted.
+func (dsc *DynamicSamplingContext) Baggage() string {
+	members := append([]string{}, dsc.thirdParty...)
+	for _, kv := range dsc.fields() {
+		if kv[1] != "" {
+			members = append(members, baggagePrefix+kv[0]+"="+percentEncode(kv[1]))
+		}
+	}
+	return strings.Join(members, ",")
+}
+
+// percentEncode encodes s as the value of a baggage member, percent-encoding
+// every byte but unreserved URL characters. Unlike url.QueryEscape, it encodes
+// spaces as %20, as the W3C baggage format requires.
+func percentEncode(s string) string {
+	const hex = "0123456789ABCDEF"
+	var b bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		}
+	}
+	return b.String()
+}
+
+// traceHeader returns the sampling context as the trace header of a transaction
+// envelope. Empty fields are omitted.
+func (dsc *DynamicSamplingContext) traceHeader() map[string]string {
+	header := make(map[string]string)
+	for _, kv := range dsc.fields() {
+		if kv[1] != "" {
+			header[kv[0]] = kv[1]
+		}
+	}
+	return header
+}
+
+// fields returns the keys and values of the sampling context, in the order
+// they are serialized in.
+func (dsc *DynamicSamplingContext) fields() [][2]string {
+	return [][2]string{
+		{"trace_id", dsc.TraceID},
+		{"public_key", dsc.PublicKey},
+		{"release", dsc.Release},
+		{"environment", dsc.Environment},
+		{"transaction", dsc.Transaction},
+		{"sample_rate", dsc.SampleRate},
+		{"sampled", dsc.Sampled},
+	}
+}
+
+// ParseBaggage extracts the sampling context from an incoming baggage header.
+// It returns nil when the header carries no Sentry members, in which case the
+// receiving service is the head of the trace and should build its own.
+func ParseBaggage(header string) *DynamicSamplingContext {
+	dsc := &DynamicSamplingContext{}
+	found := false
+
+	for _, member := range strings.Split(header, ",") {
+		member = strings.TrimSpace(member)
+		if member == "" {
+			continue
+		}
+		if !strings.HasPrefix(member, baggagePrefix) {
+			dsc.thirdParty = append(dsc.thirdParty, member)
+			continue
+		}
+
+		kv := strings.SplitN(strings.TrimPrefix(member, baggagePrefix), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		// Drop any ;-separated properties, Sentry doesn't use them.
+		value := kv[1]
+		if idx := strings.Index(value, ";"); idx != -1 {
+			value = value[:idx]
+		}
+		// Unlike in query strings, + stands for itself.
+		value, err := url.QueryUnescape(strings.Replace(value, "+", "%2B", -1))
+		if err != nil {
+			continue
+		}
+
+		found = true
+		switch kv[0] {
+		case "trace_id":
+			dsc.TraceID = value
+		case "public_key":
+			dsc.PublicKey = value
+		case "release":
+			dsc.Release = value
+		case "environment":
+			dsc.Environment = value
+		case "transaction":
+			dsc.Transaction = value
+		case "sample_rate":
+			dsc.SampleRate = value
+		case "sampled":
+			dsc.Sampled = value
+		}
+	}
+
+	if !found {
+		return nil
+	}
+	return dsc
+}
+
+// InjectBaggage sets the baggage header of an outgoing request so the
+// sampling context propagates downstream. Non-Sentry members already present
+// on the request are preserved.
+func InjectBaggage(header http.Header, dsc *DynamicSamplingContext) {
+	if dsc == nil {
+		return
+	}
+
+	var members []string
+	for _, member := range strings.Split(strings.Join(header["Baggage"], ","), ",") {
+		member = strings.TrimSpace(member)
+		if member != "" && !strings.HasPrefix(member, baggagePrefix) {
+			members = append(members, member)
+		}
+	}
+
+	out := *dsc
+	out.thirdParty = members
+	header.Set("Baggage", out.Baggage())
+}
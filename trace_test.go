@@ -0,0 +1,94 @@
+package raven
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestDynamicSamplingContext(t *testing.T) {
+	client := &Client{tracesSampleRate: 0.5}
+	client.SetDSN("https://public@example.com/sentry/1")
+	client.SetRelease("1.0")
+	client.SetEnvironment("production")
+
+	dsc := client.DynamicSamplingContext("abc", "GET /checkout")
+	expected := "sentry-trace_id=abc,sentry-public_key=public,sentry-release=1.0,sentry-environment=production,sentry-transaction=GET%20%2Fcheckout,sentry-sample_rate=0.5"
+	if actual := dsc.Baggage(); actual != expected {
+		t.Errorf("incorrect baggage: got %s, want %s", actual, expected)
+	}
+
+	if len(client.DynamicSamplingContext("", "").TraceID) != 32 {
+		t.Error("expected a generated trace id")
+	}
+}
+
+func TestSampleTrace(t *testing.T) {
+	client := &Client{}
+	yes, no := true, false
+
+	if dsc := client.SampleTrace("", SamplingContext{}); dsc.IsSampled() {
+		t.Error("tracing should be disabled by default")
+	}
+	if dsc := client.SampleTrace("", SamplingContext{ParentSampled: &yes}); !dsc.IsSampled() {
+		t.Error("expected the parent decision to be honoured")
+	}
+
+	client.SetTracesSampleRate(1)
+	if dsc := client.SampleTrace("", SamplingContext{ParentSampled: &no}); dsc.IsSampled() {
+		t.Error("expected the parent decision to be honoured")
+	}
+
+	client.SetTracesSampler(func(ctx SamplingContext) float64 {
+		if ctx.TransactionName == "/healthz" {
+			return 0
+		}
+		return 1
+	})
+	if dsc := client.SampleTrace("", SamplingContext{TransactionName: "/healthz"}); dsc.IsSampled() || dsc.SampleRate != "0" {
+		t.Errorf("expected /healthz not to be sampled, got %+v", dsc)
+	}
+	if dsc := client.SampleTrace("", SamplingContext{TransactionName: "/checkout", ParentSampled: &no}); !dsc.IsSampled() || dsc.SampleRate != "1" {
+		t.Errorf("expected /checkout to be sampled, got %+v", dsc)
+	}
+	// The sampling context of the head of the trace is passed on unchanged.
+	parent := ParseBaggage("sentry-trace_id=abc,sentry-public_key=upstream,sentry-transaction=GET%20%2F,sentry-sample_rate=0.25,sentry-sampled=false")
+	dsc := client.SampleTrace("", SamplingContext{TransactionName: "/checkout", Parent: parent})
+	if !reflect.DeepEqual(dsc, parent) || dsc == parent {
+		t.Errorf("incorrect sampling context: got %+v, want a copy of %+v", dsc, parent)
+	}
+}
+
+func TestParseBaggage(t *testing.T) {
+	dsc := ParseBaggage("other=1, sentry-trace_id=abc,sentry-transaction=GET%20%2Fcheckout;prop=1,sentry-sample_rate=0.25,sentry-release=1.0+beta")
+	if dsc == nil {
+		t.Fatal("expected a sampling context")
+	}
+
+	expected := &DynamicSamplingContext{
+		TraceID:     "abc",
+		Release:     "1.0+beta",
+		Transaction: "GET /checkout",
+		SampleRate:  "0.25",
+		thirdParty:  []string{"other=1"},
+	}
+	if !reflect.DeepEqual(dsc, expected) {
+		t.Errorf("incorrect sampling context: got %+v, want %+v", dsc, expected)
+	}
+
+	if dsc := ParseBaggage("other=1,foo=bar"); dsc != nil {
+		t.Errorf("expected nil sampling context, got %+v", dsc)
+	}
+}
+
+func TestInjectBaggage(t *testing.T) {
+	header := http.Header{}
+	header.Add("Baggage", "other=1,sentry-trace_id=old")
+
+	InjectBaggage(header, &DynamicSamplingContext{TraceID: "new", Release: "2.0"})
+
+	expected := "other=1,sentry-trace_id=new,sentry-release=2.0"
+	if actual := header.Get("Baggage"); actual != expected {
+		t.Errorf("incorrect baggage header: got %s, want %s", actual, expected)
+	}
+}
@@ -0,0 +1,95 @@
+package raven
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// ParseRuntimeStack converts a traceback as printed by runtime.Stack,
+// debug.Stack or a crashing program into a stack trace, e.g. to report panics
+// caught by a supervisor or goroutine dumps read from a file. Only the first
+// goroutine is converted, and frames are marked as in-app according to the
+// default *Client's include paths. It returns nil if no frame was found.
+func ParseRuntimeStack(stack []byte) *Stacktrace {
+	appPackagePrefixes := DefaultClientInstance().IncludePaths()
+
+	var frames []*StacktraceFrame
+	var function string
+	scanner := bufio.NewScanner(bytes.NewReader(stack))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "\t"):
+			if function == "" {
+				continue
+			}
+			file, lineno := parseTracebackLocation(line[1:])
+			module, name := splitFunctionName(function)
+			if frame := newStacktraceFrame(module, name, file, lineno, 0, appPackagePrefixes); frame != nil {
+				frames = append(frames, frame)
+			}
+			function = ""
+		case strings.HasPrefix(line, "goroutine ") && strings.HasSuffix(line, ":"), line == "":
+			if len(frames) > 0 {
+				return reversedStacktrace(frames)
+			}
+			function = ""
+		default:
+			function = parseTracebackFunction(line)
+		}
+	}
+	if len(frames) == 0 {
+		return nil
+	}
+	return reversedStacktrace(frames)
+}
+
+// parseTracebackFunction extracts the function name from a traceback line,
+// e.g. "main.(*T).run(0xc000010000, {0x4b2f60, 0x3})" or "created by
+// main.main in goroutine 1".
+func parseTracebackFunction(line string) string {
+	if strings.HasPrefix(line, "created by ") {
+		line = strings.TrimPrefix(line, "created by ")
+		if i := strings.Index(line, " in goroutine "); i != -1 {
+			line = line[:i]
+		}
+		return line
+	}
+	if !strings.HasSuffix(line, ")") {
+		return ""
+	}
+	if i := strings.LastIndex(line, "("); i > 0 {
+		return line[:i]
+	}
+	return ""
+}
+
+// parseTracebackLocation extracts the file and line from a traceback line
+// like "/src/main.go:12 +0x1d".
+func parseTracebackLocation(line string) (string, int) {
+	if i := strings.LastIndex(line, " +0x"); i != -1 {
+		line = line[:i]
+	}
+	i := strings.LastIndex(line, ":")
+	if i == -1 {
+		return line, 0
+	}
+	lineno, err := strconv.Atoi(line[i+1:])
+	if err != nil {
+		return line, 0
+	}
+	return line[:i], lineno
+}
+
+// reversedStacktrace builds a stack trace from frames listed newest first, as
+// Sentry wants the oldest first, trimmed to MaxFrames.
+func reversedStacktrace(frames []*StacktraceFrame) *Stacktrace {
+	for i, j := 0, len(frames)-1; i < j; i, j = i+1, j-1 {
+		frames[i], frames[j] = frames[j], frames[i]
+	}
+	stacktrace := &Stacktrace{Frames: frames}
+	stacktrace.trim()
+	return stacktrace
+}
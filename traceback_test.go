@@ -0,0 +1,99 @@
+package raven
+
+import (
+	"reflect"
+	"runtime"
+	"testing"
+)
+
+const crashLog = `panic: runtime error: index out of range [3] with length 3
+
+goroutine 7 [running]:
+example.com/app/worker.(*Pool).run(0xc000010000, {0x4b2f60, 0x3})
+	/src/app/worker/pool.go:42 +0x1d
+example.com/app/worker.process[...](...)
+	/src/app/worker/generic.go:10
+created by example.com/app/worker.Start in goroutine 1
+	/src/app/worker/pool.go:20 +0x85
+
+goroutine 1 [chan receive]:
+main.main()
+	/src/app/main.go:12 +0x25
+`
+
+func TestParseRuntimeStack(t *testing.T) {
+	defer DefaultClient.SetIncludePaths(DefaultClient.IncludePaths())
+	DefaultClient.SetIncludePaths([]string{"example.com/app"})
+
+	expected := &Stacktrace{Frames: []*StacktraceFrame{
+		{Filename: "/src/app/worker/pool.go", AbsolutePath: "/src/app/worker/pool.go", Lineno: 20, Module: "example.com/app/worker", Function: "Start", InApp: true},
+		{Filename: "/src/app/worker/generic.go", AbsolutePath: "/src/app/worker/generic.go", Lineno: 10, Module: "example.com/app/worker", Function: "process[...]", InApp: true},
+		{Filename: "/src/app/worker/pool.go", AbsolutePath: "/src/app/worker/pool.go", Lineno: 42, Module: "example.com/app/worker", Function: "(*Pool).run", InApp: true},
+	}}
+
+	actual := ParseRuntimeStack([]byte(crashLog))
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("incorrect stacktrace:\ngot  %+v\nwant %+v", frameValues(actual), frameValues(expected))
+	}
+
+	if actual := ParseRuntimeStack([]byte("not a traceback")); actual != nil {
+		t.Errorf("incorrect stacktrace: got %+v, want nil", actual)
+	}
+}
+
+func TestParseRuntimeStackCurrent(t *testing.T) {
+	buf := make([]byte, 1<<16)
+	actual := ParseRuntimeStack(buf[:runtime.Stack(buf, false)])
+	if actual == nil || len(actual.Frames) == 0 {
+		t.Fatalf("no frames parsed")
+	}
+
+	top := actual.Frames[len(actual.Frames)-1]
+	if top.Module != "github.com/getsentry/raven-go" || top.Function != "TestParseRuntimeStackCurrent" {
+		t.Errorf("incorrect top frame: %+v", top)
+	}
+	if top.Lineno == 0 || top.AbsolutePath == "" {
+		t.Errorf("incorrect location: %+v", top)
+	}
+}
+
+func frameValues(s *Stacktrace) []StacktraceFrame {
+	if s == nil {
+		return nil
+	}
+	var frames []StacktraceFrame
+	for _, f := range s.Frames {
+		frames = append(frames, *f)
+	}
+	return frames
+}
+
+const cgoCrashLog = `goroutine 1 [syscall]:
+runtime.cgocall(0x4a1b20, 0xc000051f40)
+	/usr/local/go/src/runtime/cgocall.go:157 +0x4b
+main._Cfunc_crash()
+	_cgo_gotypes.go:39 +0x45
+main.main()
+	/src/app/main.go:9 +0x17
+`
+
+func TestParseRuntimeStackCgo(t *testing.T) {
+	actual := ParseRuntimeStack([]byte(cgoCrashLog))
+	if actual == nil || len(actual.Frames) != 3 {
+		t.Fatalf("incorrect stacktrace: %+v", frameValues(actual))
+	}
+
+	expected := []struct {
+		module, function string
+		inApp            bool
+	}{
+		{"main", "main", true},
+		{"cgo", "_Cfunc_crash", false},
+		{"cgo", "cgocall", false},
+	}
+	for i, f := range actual.Frames {
+		if f.Module != expected[i].module || f.Function != expected[i].function || f.InApp != expected[i].inApp {
+			t.Errorf("incorrect frame %d: got %+v, want %+v", i, *f, expected[i])
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package raven
+
+import (
+	gocontext "context"
+	"encoding/json"
+	"strings"
+)
+
+// A transaction is the event a trace's root span is sent to Sentry as.
+// https://develop.sentry.dev/sdk/event-payloads/transaction/
+type transaction struct {
+	Type        string                 `json:"type"`
+	EventID     string                 `json:"event_id"`
+	Transaction string                 `json:"transaction"`
+	Platform    string                 `json:"platform"`
+	Release     string                 `json:"release,omitempty"`
+	Environment string                 `json:"environment,omitempty"`
+	StartTime   Timestamp              `json:"start_timestamp"`
+	EndTime     Timestamp              `json:"timestamp"`
+	Contexts    map[string]interface{} `json:"contexts"`
+	Spans       []*Span                `json:"spans"`
+}
+
+// newTransaction returns the transaction named name that root is sent as,
+// along with the spans of its trace finished so far.
+func newTransaction(root *Span, name, release, environment string) (*transaction, error) {
+	eventID, err := uuid()
+	if err != nil {
+		return nil, err
+	}
+	trace := map[string]interface{}{
+		"trace_id": root.TraceID,
+		"span_id":  root.SpanID,
+	}
+	for key, value := range map[string]string{
+		"parent_span_id": root.ParentSpanID,
+		"op":             root.Op,
+		"description":    root.Description,
+		"status":         root.Status,
+	} {
+		if value != "" {
+			trace[key] = value
+		}
+	}
+	if len(root.Data) > 0 {
+		trace["data"] = root.Data
+	}
+	spans := []*Span{}
+	if root.recorder != nil {
+		spans = root.recorder.finished(root)
+	}
+	return &transaction{
+		Type:        "transaction",
+		EventID:     eventID,
+		Transaction: name,
+		Platform:    "go",
+		Release:     release,
+		Environment: environment,
+		StartTime:   root.StartTime,
+		EndTime:     root.EndTime,
+		Contexts:    map[string]interface{}{"trace": trace},
+		Spans:       spans,
+	}, nil
+}
+
+// CaptureTransaction sends root, the span starting a trace, to Sentry as the
+// transaction named name with the client's transport, which must be an
+// EnvelopeTransport, and waits for it to be accepted. dsc is the sampling
+// context of the trace, as returned by SampleTrace or ParseBaggage, and is sent
+// in the envelope's trace header so that Sentry samples the trace consistently.
+// A nil dsc samples the trace with SampleTrace. Traces that aren't sampled are
+// not sent, and ErrSampledOut is returned. The spans of the trace finished by
+// then are sent along, and root is finished if it isn't yet. It returns the ID
+// of the transaction.
+func (client *Client) CaptureTransaction(ctx gocontext.Context, root *Span, name string, dsc *DynamicSamplingContext) (string, error) {
+	if dsc == nil {
+		dsc = client.SampleTrace(root.TraceID, SamplingContext{TransactionName: name})
+	}
+	if !dsc.IsSampled() {
+		return "", ErrSampledOut
+	}
+	root.Finish()
+
+	client.mu.RLock()
+	release, environment := client.release, client.environment
+	storeURL, authHeader := client.url, client.authHeader
+	transport := client.Transport
+	ua := client.sdk.UserAgent()
+	client.mu.RUnlock()
+	if storeURL == "" {
+		return "", ErrNotConfigured
+	}
+	t, ok := transport.(EnvelopeTransport)
+	if !ok {
+		return "", ErrEnvelopesUnsupported
+	}
+
+	tx, err := newTransaction(root, name, release, environment)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(tx)
+	if err != nil {
+		return "", err
+	}
+	envelope, err := newEnvelopeWithHeader(envelopeHeader{EventID: tx.EventID, Trace: dsc.traceHeader()}, "transaction", payload)
+	if err != nil {
+		return "", err
+	}
+	envelopeURL := strings.TrimSuffix(storeURL, "store/") + "envelope/"
+	if err := t.SendEnvelope(withUserAgent(ctx, ua), envelopeURL, authHeader, envelope); err != nil {
+		return "", err
+	}
+	return tx.EventID, nil
+}
+
+// CaptureTransaction sends a trace's root span to Sentry with the default *Client
+func CaptureTransaction(ctx gocontext.Context, root *Span, name string, dsc *DynamicSamplingContext) (string, error) {
+	return DefaultClientInstance().CaptureTransaction(ctx, root, name, dsc)
+}
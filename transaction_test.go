@@ -0,0 +1,158 @@
+package raven
+
+import (
+	"bytes"
+	gocontext "context"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// envelopeTransport records the envelopes it is asked to send.
+type envelopeTransport struct {
+	testTransport
+	urls      []string
+	envelopes [][]byte
+}
+
+func (t *envelopeTransport) SendEnvelope(ctx gocontext.Context, url, authHeader string, envelope []byte) error {
+	t.urls = append(t.urls, url)
+	t.envelopes = append(t.envelopes, envelope)
+	return nil
+}
+
+// decodeEnvelope decodes the header of envelope and the payload of its only
+// item into header and payload.
+func decodeEnvelope(t *testing.T, envelope []byte, header, payload interface{}) {
+	lines := bytes.Split(bytes.TrimSpace(envelope), []byte("\n"))
+	if len(lines) != 3 {
+		t.Fatalf("incorrect envelope: %s", envelope)
+	}
+	var item envelopeItemHeader
+	if err := json.Unmarshal(lines[1], &item); err != nil || item.Type != "transaction" || item.Length != len(lines[2]) {
+		t.Fatalf("incorrect item header: %s", lines[1])
+	}
+	if err := json.Unmarshal(lines[0], header); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(lines[2], payload); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCaptureTransaction(t *testing.T) {
+	transport := &envelopeTransport{}
+	client := newClient(nil)
+	client.SetTransport(transport)
+	client.SetDSN("https://public@example.com/1")
+	client.SetRelease("1.0")
+
+	root, _ := StartSpan(gocontext.Background(), "http.server", "GET /checkout")
+	root.Finish()
+	yes := true
+	dsc := client.SampleTrace(root.TraceID, SamplingContext{TransactionName: "GET /checkout", ParentSampled: &yes})
+	id, err := client.CaptureTransaction(gocontext.Background(), root, "GET /checkout", dsc)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(transport.envelopes) != 1 || transport.urls[0] != "https://example.com/api/1/envelope/" {
+		t.Fatalf("incorrect envelopes: %q", transport.urls)
+	}
+	var header envelopeHeader
+	var payload struct {
+		Type        string                            `json:"type"`
+		EventID     string                            `json:"event_id"`
+		Transaction string                            `json:"transaction"`
+		Release     string                            `json:"release"`
+		Contexts    map[string]map[string]interface{} `json:"contexts"`
+	}
+	decodeEnvelope(t, transport.envelopes[0], &header, &payload)
+
+	expected := map[string]string{
+		"trace_id":    root.TraceID,
+		"public_key":  "public",
+		"release":     "1.0",
+		"transaction": "GET /checkout",
+		"sample_rate": "0",
+		"sampled":     "true",
+	}
+	if header.EventID != id || !reflect.DeepEqual(header.Trace, expected) {
+		t.Errorf("incorrect envelope header: got %+v, want trace %v", header, expected)
+	}
+	if payload.Type != "transaction" || payload.EventID != id || payload.Transaction != "GET /checkout" || payload.Release != "1.0" {
+		t.Errorf("incorrect transaction: %+v", payload)
+	}
+	if trace := payload.Contexts["trace"]; trace["trace_id"] != root.TraceID || trace["span_id"] != root.SpanID || trace["op"] != "http.server" {
+		t.Errorf("incorrect trace context: %v", trace)
+	}
+}
+
+func TestCaptureTransactionSampledOut(t *testing.T) {
+	transport := &envelopeTransport{}
+	client := newClient(nil)
+	client.SetTransport(transport)
+	client.SetDSN("https://public@example.com/1")
+
+	// Tracing is disabled by default.
+	root, _ := StartSpan(gocontext.Background(), "http.server", "GET /")
+	if _, err := client.CaptureTransaction(gocontext.Background(), root, "GET /", nil); err != ErrSampledOut {
+		t.Errorf("incorrect error: got %v, want %v", err, ErrSampledOut)
+	}
+	if len(transport.envelopes) != 0 {
+		t.Errorf("expected no envelope, got %d", len(transport.envelopes))
+	}
+}
+
+func TestTransactionSpans(t *testing.T) {
+	root, ctx := StartSpan(gocontext.Background(), "http.server", "GET /users")
+	query, queryCtx := StartDBSpan(ctx, "postgresql", "SELECT * FROM users")
+	cache, _ := StartCacheSpan(queryCtx, "cache.get", "users")
+	cache.SetCacheHit(false)
+	cache.Finish()
+	query.Finish()
+	query.Finish()
+	unfinished, _ := StartSpan(ctx, "http.client", "GET https://example.com")
+	root.Finish()
+	other, _ := StartSpan(gocontext.Background(), "task", "cleanup")
+	other.Finish()
+
+	tx, err := newTransaction(root, "GET /users", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var payload struct {
+		Spans []map[string]interface{} `json:"spans"`
+	}
+	if err := json.Unmarshal(data, &payload); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(payload.Spans) != 2 {
+		t.Fatalf("incorrect spans: %s", data)
+	}
+	expected := []map[string]interface{}{
+		{"span_id": cache.SpanID, "parent_span_id": query.SpanID, "op": "cache.get", "description": "users"},
+		{"span_id": query.SpanID, "parent_span_id": root.SpanID, "op": "db", "description": "SELECT * FROM users"},
+	}
+	for i, span := range payload.Spans {
+		for key, value := range expected[i] {
+			if span[key] != value {
+				t.Errorf("incorrect %s of span %d: got %v, want %v", key, i, span[key], value)
+			}
+		}
+		if span["trace_id"] != root.TraceID || span["start_timestamp"] == nil || span["timestamp"] == nil {
+			t.Errorf("incorrect span %d: %v", i, span)
+		}
+		if span["span_id"] == unfinished.SpanID || span["span_id"] == other.SpanID {
+			t.Errorf("unexpected span %d: %v", i, span)
+		}
+	}
+	if data := payload.Spans[0]["data"].(map[string]interface{}); data["cache.hit"] != false {
+		t.Errorf("incorrect data of the cache span: %v", data)
+	}
+}
@@ -0,0 +1,374 @@
+package raven
+
+import (
+	"bytes"
+	gocontext "context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HTTPTransport is the default transport, delivering packets to Sentry via the
+// HTTP API.
+type HTTPTransport struct {
+	*http.Client
+
+	// Headers added to every request, e.g. for a proxy or relay that requires
+	// its own authentication.
+	Headers map[string]string
+
+	// AuthProvider, if set, is called before every request for additional
+	// headers, e.g. a bearer token that it refreshes as needed.
+	AuthProvider AuthProvider
+
+	// Signer, if set, is called with every request once its headers are set
+	// and with the body it is about to send, e.g. to add a signature expected
+	// by an authenticating gateway in front of Sentry.
+	Signer RequestSigner
+
+	// OnSendPayload, if set, is called with the body of every request and the
+	// URL it is sent to, once the event is serialized and compressed, e.g. to
+	// audit events or record their size. The body it returns is sent instead,
+	// and signed if a Signer is set, e.g. to add an encryption layer.
+	OnSendPayload PayloadHook
+
+	probeInterval time.Duration
+	mu            sync.Mutex
+	lastURL       string
+	stop          chan struct{}
+}
+
+// An AuthProvider returns headers to attach to a request to Sentry. A non-nil
+// error fails the send.
+type AuthProvider func() (map[string]string, error)
+
+// A RequestSigner signs a request to Sentry, typically by setting a header
+// derived from body. A non-nil error fails the send.
+type RequestSigner func(req *http.Request, body []byte) error
+
+// A PayloadHook inspects, and may replace, the body of a request to Sentry
+// right before it is sent to url. A non-nil error fails the send.
+type PayloadHook func(url string, body []byte) ([]byte, error)
+
+// HMACSigner returns a RequestSigner setting header to the hex-encoded
+// HMAC-SHA256 of the request body under key.
+func HMACSigner(header string, key []byte) RequestSigner {
+	return func(req *http.Request, body []byte) error {
+		mac := hmac.New(sha256.New, key)
+		mac.Write(body)
+		req.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+		return nil
+	}
+}
+
+// ErrInvalidRootCAs is logged by NewHTTPTransport when TransportOptions.RootCAs
+// holds no PEM-encoded certificate.
+var ErrInvalidRootCAs = errors.New("raven: no certificate found in root CAs")
+
+// TransportOptions configures an HTTPTransport built by NewHTTPTransport.
+type TransportOptions struct {
+	Headers      map[string]string
+	AuthProvider AuthProvider
+	Signer       RequestSigner
+
+	// OnSendPayload sets HTTPTransport.OnSendPayload.
+	OnSendPayload PayloadHook
+
+	// Connection tuning for clients sending thousands of events per minute.
+	// Zero values keep the net/http defaults. ForceAttemptHTTP2 and
+	// MaxConnsPerHost are ignored when built with Go releases before 1.13.
+	ForceAttemptHTTP2   bool
+	MaxConnsPerHost     int
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	// ConnectionProbeInterval, if set, makes the transport check its
+	// connection to Sentry at that interval once it has sent an event. Idle
+	// connections are dropped when a check fails, so that the next event
+	// dials a fresh one instead of failing on a dead connection.
+	ConnectionProbeInterval time.Duration
+
+	// RootCAs, if set, are the PEM-encoded root certificates the transport
+	// trusts instead of the default ones, e.g. for distroless images that
+	// ship without a CA bundle or to trust a private CA.
+	RootCAs []byte
+
+	// RoundTripper, if set, sends the requests instead of an http.Transport,
+	// which ignores the connection and certificate options above. On js/wasm
+	// the default http.Transport uses the browser's Fetch API; a RoundTripper
+	// can be injected for runtimes that lack it.
+	RoundTripper http.RoundTripper
+}
+
+// NewHTTPTransport builds an HTTPTransport honouring proxy environment
+// variables and trusting opts.RootCAs if set, or else the certifi root
+// certificates, or the system ones when built with the raven_nocertifi tag.
+func NewHTTPTransport(opts TransportOptions) *HTTPTransport {
+	if opts.RoundTripper != nil {
+		return &HTTPTransport{
+			Client:        &http.Client{Transport: opts.RoundTripper},
+			Headers:       opts.Headers,
+			AuthProvider:  opts.AuthProvider,
+			Signer:        opts.Signer,
+			OnSendPayload: opts.OnSendPayload,
+			probeInterval: opts.ConnectionProbeInterval,
+		}
+	}
+
+	transport := &http.Transport{
+		Proxy:               http.ProxyFromEnvironment,
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:     opts.IdleConnTimeout,
+	}
+	tuneTransport(transport, opts)
+	rootCAs, err := rootCAs(opts.RootCAs)
+	if err != nil {
+		log.Println("raven: failed to load root TLS certificates:", err)
+	} else if rootCAs != nil {
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+	}
+
+	return &HTTPTransport{
+		Client:        &http.Client{Transport: transport},
+		Headers:       opts.Headers,
+		AuthProvider:  opts.AuthProvider,
+		Signer:        opts.Signer,
+		OnSendPayload: opts.OnSendPayload,
+		probeInterval: opts.ConnectionProbeInterval,
+	}
+}
+
+// rootCAs returns the pool of the certificates in pem if any, or else the
+// default root certificates. A nil pool stands for the system roots.
+func rootCAs(pem []byte) (*x509.CertPool, error) {
+	if len(pem) == 0 {
+		return defaultRootCAs()
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, ErrInvalidRootCAs
+	}
+	return pool, nil
+}
+
+func newTransport() Transport {
+	return NewHTTPTransport(TransportOptions{})
+}
+
+// Close stops the connection probe, if any, and closes idle connections.
+func (t *HTTPTransport) Close() {
+	t.mu.Lock()
+	if t.stop != nil {
+		close(t.stop)
+		t.stop = nil
+	}
+	t.mu.Unlock()
+
+	if t.Client != nil {
+		t.CloseIdleConnections()
+	}
+}
+
+// startProbe remembers the URL to probe and starts the probe the first time
+// an event is sent.
+func (t *HTTPTransport) startProbe(url string) {
+	if t.probeInterval <= 0 {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.lastURL == "" {
+		t.stop = make(chan struct{})
+		go t.probe(t.stop)
+	}
+	t.lastURL = url
+}
+
+func (t *HTTPTransport) probe(stop chan struct{}) {
+	ticker := time.NewTicker(t.probeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		t.mu.Lock()
+		url := t.lastURL
+		t.mu.Unlock()
+
+		req, err := http.NewRequest("HEAD", url, nil)
+		if err != nil {
+			continue
+		}
+		ctx, cancel := gocontext.WithTimeout(gocontext.Background(), t.probeInterval)
+		res, err := t.Do(req.WithContext(ctx))
+		cancel()
+		if err != nil {
+			t.CloseIdleConnections()
+			continue
+		}
+		io.Copy(ioutil.Discard, res.Body)
+		res.Body.Close()
+	}
+}
+
+func (t *HTTPTransport) Send(url, authHeader string, packet *Packet) error {
+	return t.SendContext(gocontext.Background(), url, authHeader, packet)
+}
+
+// SendContext is like Send, but gives up when ctx is done.
+func (t *HTTPTransport) SendContext(ctx gocontext.Context, url, authHeader string, packet *Packet) error {
+	if url == "" {
+		return nil
+	}
+	t.startProbe(url)
+
+	err := t.send(ctx, url, authHeader, packet)
+	if se, ok := err.(*statusError); ok && se.code == http.StatusRequestEntityTooLarge {
+		truncated, stripped := truncatePacket(packet)
+		if len(stripped) > 0 {
+			debugf("raven: event %s is too large, retrying without %s", packet.EventID, strings.Join(stripped, ", "))
+			err = t.send(ctx, url, authHeader, truncated)
+		}
+	}
+	return err
+}
+
+func (t *HTTPTransport) send(ctx gocontext.Context, url, authHeader string, packet *Packet) error {
+	body, contentType, err := serializedPacket(packet)
+	if err != nil {
+		return fmt.Errorf("error serializing packet: %v", err)
+	}
+	return t.post(ctx, url, authHeader, packet.userAgent(), packet.requestHeaders(), body, contentType)
+}
+
+// SendEnvelope sends envelope to the envelope endpoint at url, with the
+// headers, hooks and signer of the transport applied as they are to events.
+func (t *HTTPTransport) SendEnvelope(ctx gocontext.Context, url, authHeader string, envelope []byte) error {
+	return t.post(ctx, url, authHeader, contextUserAgent(ctx), nil, bytes.NewReader(envelope), "application/x-sentry-envelope")
+}
+
+// post sends body to url along with headers, and returns the error the server
+// answered with, if any.
+func (t *HTTPTransport) post(ctx gocontext.Context, url, authHeader, userAgent string, headers http.Header, body io.Reader, contentType string) error {
+	var payload []byte
+	var err error
+	if t.Signer != nil || t.OnSendPayload != nil {
+		if payload, err = ioutil.ReadAll(body); err != nil {
+			return fmt.Errorf("error serializing packet: %v", err)
+		}
+		if t.OnSendPayload != nil {
+			if payload, err = t.OnSendPayload(url, payload); err != nil {
+				return fmt.Errorf("raven: payload hook failed: %v", err)
+			}
+		}
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return fmt.Errorf("can't create new request: %v", err)
+	}
+	req = req.WithContext(ctx)
+	for k, v := range t.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range headers {
+		req.Header[k] = v
+	}
+	if t.AuthProvider != nil {
+		headers, err := t.AuthProvider()
+		if err != nil {
+			return fmt.Errorf("raven: auth provider failed: %v", err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+	}
+	req.Header.Set("X-Sentry-Auth", authHeader)
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Content-Type", contentType)
+	if t.Signer != nil {
+		if err := t.Signer(req, payload); err != nil {
+			return fmt.Errorf("raven: request signer failed: %v", err)
+		}
+	}
+	res, err := t.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusMultiStatus {
+		return parsePartialSuccess(res.Body)
+	}
+	io.Copy(ioutil.Discard, res.Body)
+	if res.StatusCode == http.StatusTooManyRequests {
+		return &RateLimitError{
+			Until:  parseRetryAfter(res.Header.Get("Retry-After"), time.Now()),
+			Reason: res.Header.Get("X-Sentry-Error"),
+		}
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return &statusError{fmt.Sprintf("raven: got http status %d - x-sentry-error: %s", res.StatusCode, res.Header.Get("X-Sentry-Error")), res.StatusCode}
+	}
+	return nil
+}
+
+func serializedPacket(packet *Packet) (io.Reader, string, error) {
+	packetJSON, err := packet.JSON()
+	if err != nil {
+		return nil, "", fmt.Errorf("error marshaling packet %+v to JSON: %v", packet, err)
+	}
+
+	body, contentType := encodeBody(packetJSON)
+	return body, contentType, nil
+}
+
+// PartialSuccessError is returned by HTTPTransport when a relay answers 207
+// Multi-Status, accepting the request but reporting errors for some of its
+// items.
+type PartialSuccessError struct {
+	Errors []string
+}
+
+func (e *PartialSuccessError) Error() string {
+	return "raven: partially accepted: " + strings.Join(e.Errors, "; ")
+}
+
+// parsePartialSuccess reads the body of a 207 response, of the form
+// {"errors": [...]} where errors are strings or objects.
+func parsePartialSuccess(body io.Reader) error {
+	var res struct {
+		Errors []json.RawMessage `json:"errors"`
+	}
+	data, _ := ioutil.ReadAll(io.LimitReader(body, int64(MaxResponseBodySize)))
+	if json.Unmarshal(data, &res) != nil || len(res.Errors) == 0 {
+		return nil
+	}
+
+	errs := make([]string, len(res.Errors))
+	for i, raw := range res.Errors {
+		var s string
+		if json.Unmarshal(raw, &s) == nil {
+			errs[i] = s
+		} else {
+			errs[i] = string(raw)
+		}
+	}
+	return &PartialSuccessError{errs}
+}
@@ -0,0 +1,10 @@
+//go:build !go1.13
+// +build !go1.13
+
+package raven
+
+import "net/http"
+
+// tuneTransport ignores ForceAttemptHTTP2 and MaxConnsPerHost: net/http lacks
+// them before Go 1.13.
+func tuneTransport(transport *http.Transport, opts TransportOptions) {}
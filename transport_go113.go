@@ -0,0 +1,13 @@
+//go:build go1.13
+// +build go1.13
+
+package raven
+
+import "net/http"
+
+// tuneTransport applies the connection options of opts that net/http only
+// has since Go 1.13.
+func tuneTransport(transport *http.Transport, opts TransportOptions) {
+	transport.ForceAttemptHTTP2 = opts.ForceAttemptHTTP2
+	transport.MaxConnsPerHost = opts.MaxConnsPerHost
+}
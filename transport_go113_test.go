@@ -0,0 +1,21 @@
+//go:build go1.13
+// +build go1.13
+
+package raven
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestNewHTTPTransportHTTP2Tuning(t *testing.T) {
+	transport := NewHTTPTransport(TransportOptions{
+		ForceAttemptHTTP2: true,
+		MaxConnsPerHost:   4,
+	})
+
+	tr := transport.Client.Transport.(*http.Transport)
+	if !tr.ForceAttemptHTTP2 || tr.MaxConnsPerHost != 4 {
+		t.Errorf("options not applied: %+v", tr)
+	}
+}
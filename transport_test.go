@@ -0,0 +1,283 @@
+package raven
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHTTPTransportHeaders(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer server.Close()
+
+	token := "first"
+	transport := NewHTTPTransport(TransportOptions{
+		Headers: map[string]string{"X-Relay": "edge", "X-Sentry-Auth": "overridden"},
+		AuthProvider: func() (map[string]string, error) {
+			return map[string]string{"Authorization": "Bearer " + token}, nil
+		},
+	})
+
+	if err := transport.Send(server.URL, "Sentry sentry_key=u", NewPacket("test")); err != nil {
+		t.Fatal(err)
+	}
+	if received.Get("X-Relay") != "edge" || received.Get("Authorization") != "Bearer first" {
+		t.Errorf("missing custom headers: %+v", received)
+	}
+	if received.Get("X-Sentry-Auth") != "Sentry sentry_key=u" {
+		t.Errorf("custom headers should not override X-Sentry-Auth: %+v", received)
+	}
+
+	token = "refreshed"
+	transport.Send(server.URL, "", NewPacket("test"))
+	if received.Get("Authorization") != "Bearer refreshed" {
+		t.Errorf("expected the token to be refreshed: %+v", received)
+	}
+}
+
+func TestHTTPTransportAuthProviderError(t *testing.T) {
+	transport := NewHTTPTransport(TransportOptions{
+		AuthProvider: func() (map[string]string, error) {
+			return nil, errors.New("token expired")
+		},
+	})
+
+	if err := transport.Send("http://127.0.0.1:1/", "", NewPacket("test")); err == nil || err.Error() != "raven: auth provider failed: token expired" {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestHTTPTransportSigner(t *testing.T) {
+	key := []byte("gateway-key")
+	var signature, expected string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, key)
+		mac.Write(body)
+		signature, expected = r.Header.Get("X-Signature"), hex.EncodeToString(mac.Sum(nil))
+	}))
+	defer server.Close()
+
+	transport := NewHTTPTransport(TransportOptions{Signer: HMACSigner("X-Signature", key)})
+	for _, message := range []string{"short", strings.Repeat("long ", 500)} {
+		if err := transport.Send(server.URL, "", NewPacket(message)); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if signature == "" || signature != expected {
+			t.Errorf("incorrect signature: got %q, want %q", signature, expected)
+		}
+	}
+
+	transport.Signer = func(*http.Request, []byte) error { return errors.New("no key") }
+	if err := transport.Send(server.URL, "", NewPacket("test")); err == nil || err.Error() != "raven: request signer failed: no key" {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestHTTPTransportOnSendPayload(t *testing.T) {
+	key := []byte("gateway-key")
+	var received []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received, _ = ioutil.ReadAll(r.Body)
+		signature = r.Header.Get("X-Signature")
+	}))
+	defer server.Close()
+
+	var hookedURL string
+	var hooked []byte
+	transport := NewHTTPTransport(TransportOptions{
+		Signer: HMACSigner("X-Signature", key),
+		OnSendPayload: func(url string, body []byte) ([]byte, error) {
+			hookedURL, hooked = url, body
+			return append([]byte("sealed:"), body...), nil
+		},
+	})
+	if err := transport.Send(server.URL, "", NewPacket("test")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if hookedURL != server.URL {
+		t.Errorf("incorrect URL: got %s, want %s", hookedURL, server.URL)
+	}
+	if !bytes.Contains(hooked, []byte(`"message":"test"`)) {
+		t.Errorf("incorrect payload: got %s", hooked)
+	}
+	if want := "sealed:" + string(hooked); string(received) != want {
+		t.Errorf("incorrect body: got %q, want %q", received, want)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(received)
+	if expected := hex.EncodeToString(mac.Sum(nil)); signature != expected {
+		t.Errorf("incorrect signature: got %q, want %q", signature, expected)
+	}
+
+	transport.OnSendPayload = func(string, []byte) ([]byte, error) { return nil, errors.New("no key") }
+	if err := transport.Send(server.URL, "", NewPacket("test")); err == nil || err.Error() != "raven: payload hook failed: no key" {
+		t.Errorf("incorrect error: %v", err)
+	}
+}
+
+func TestNewHTTPTransportTuning(t *testing.T) {
+	transport := NewHTTPTransport(TransportOptions{
+		MaxIdleConnsPerHost: 4,
+		IdleConnTimeout:     time.Minute,
+	})
+
+	tr := transport.Client.Transport.(*http.Transport)
+	if tr.MaxIdleConnsPerHost != 4 || tr.IdleConnTimeout != time.Minute {
+		t.Errorf("options not applied: %+v", tr)
+	}
+	if roots, _ := defaultRootCAs(); roots != nil && (tr.TLSClientConfig == nil || tr.TLSClientConfig.RootCAs == nil) {
+		t.Error("expected the certifi root certificates to be trusted")
+	}
+}
+
+func TestNewHTTPTransportRootCAs(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	transport := NewHTTPTransport(TransportOptions{RootCAs: certificate})
+	if err := transport.Send(server.URL, "", NewPacket("test")); err != nil {
+		t.Errorf("unexpected error trusting the server certificate: %v", err)
+	}
+
+	if err := NewHTTPTransport(TransportOptions{}).Send(server.URL, "", NewPacket("test")); err == nil {
+		t.Error("expected an error without trusting the server certificate")
+	}
+
+	if _, err := rootCAs([]byte("not a certificate")); err != ErrInvalidRootCAs {
+		t.Errorf("incorrect error: got %v, want %v", err, ErrInvalidRootCAs)
+	}
+}
+
+func TestNewHTTPTransportRoundTripper(t *testing.T) {
+	var sent *http.Request
+	transport := NewHTTPTransport(TransportOptions{
+		Headers: map[string]string{"X-Relay": "edge"},
+		RoundTripper: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			sent = req
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		}),
+	})
+
+	if err := transport.Send("https://example.com/api/1/store/", "Sentry sentry_key=u", NewPacket("test")); err != nil {
+		t.Fatal(err)
+	}
+	if sent == nil || sent.Header.Get("X-Relay") != "edge" || sent.Header.Get("X-Sentry-Auth") != "Sentry sentry_key=u" {
+		t.Errorf("incorrect request sent: %+v", sent)
+	}
+}
+
+func TestHTTPTransportConnectionProbe(t *testing.T) {
+	probes := make(chan string, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "HEAD" {
+			probes <- r.URL.Path
+		}
+	}))
+	defer server.Close()
+
+	transport := NewHTTPTransport(TransportOptions{ConnectionProbeInterval: 10 * time.Millisecond})
+	defer transport.Close()
+
+	select {
+	case <-probes:
+		t.Fatal("the probe should not start before an event is sent")
+	case <-time.After(30 * time.Millisecond):
+	}
+
+	transport.Send(server.URL+"/api/1/store/", "", NewPacket("test"))
+	select {
+	case path := <-probes:
+		if path != "/api/1/store/" {
+			t.Errorf("incorrect probe path: %s", path)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the connection to be probed")
+	}
+}
+
+func TestHTTPTransportStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		body   string
+		err    string
+	}{
+		{200, `{"id":"1"}`, ""},
+		{202, "", ""},
+		{204, "", ""},
+		{207, `{"errors":[]}`, ""},
+		{207, `{"errors":["attachment too large",{"item":"session"}]}`, `raven: partially accepted: attachment too large; {"item":"session"}`},
+		{302, "", "raven: got http status 302 - x-sentry-error: "},
+		{500, "", "raven: got http status 500 - x-sentry-error: "},
+	}
+
+	for _, test := range tests {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(test.status)
+			w.Write([]byte(test.body))
+		}))
+
+		transport := NewHTTPTransport(TransportOptions{})
+		transport.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+		err := transport.Send(server.URL, "", NewPacket("test"))
+		server.Close()
+
+		var actual string
+		if err != nil {
+			actual = err.Error()
+		}
+		if actual != test.err {
+			t.Errorf("%d: incorrect error: got %q, want %q", test.status, actual, test.err)
+		}
+	}
+}
+
+func TestHTTPTransportPayloadTooLarge(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+		}
+	}))
+	defer server.Close()
+
+	var logged bytes.Buffer
+	SetDebugLogger(log.New(&logged, "", 0))
+	defer SetDebugLogger(nil)
+
+	packet := NewPacket("test", &Breadcrumbs{Values: []*Breadcrumb{{Message: "crumb"}}})
+	packet.EventID = "abc"
+	packet.Extra = Extra{"small": "value", "blob": strings.Repeat("x", 4096)}
+
+	transport := NewHTTPTransport(TransportOptions{})
+	if err := transport.Send(server.URL, "", packet); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if requests != 2 {
+		t.Errorf("incorrect request count: got %d, want 2", requests)
+	}
+	expected := "raven: event abc is too large, retrying without breadcrumbs, extra blob\n"
+	if logged.String() != expected {
+		t.Errorf("incorrect debug log: got %q, want %q", logged.String(), expected)
+	}
+	if _, ok := packet.Extra["blob"]; !ok {
+		t.Error("original packet was modified")
+	}
+}
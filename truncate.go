@@ -0,0 +1,97 @@
+package raven
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// Extra values serializing to more than this many bytes are dropped when an
+// event is too large for the server.
+const maxTruncatedExtraSize = 1024
+
+// truncatePacket returns a copy of packet stripped of what usually makes an
+// event too large: breadcrumbs, thread stacks, source context and large extra
+// values. It also describes what was stripped.
+func truncatePacket(packet *Packet) (*Packet, []string) {
+	truncated := *packet
+	var stripped []string
+	var sourceContext bool
+
+	truncated.Interfaces = nil
+	for _, inter := range packet.Interfaces {
+		switch inter := inter.(type) {
+		case *Breadcrumbs:
+			stripped = append(stripped, "breadcrumbs")
+			continue
+		case *Threads:
+			stripped = append(stripped, "threads")
+			continue
+		case *Stacktrace:
+			var ok bool
+			if inter, ok = withoutSourceContext(inter); ok {
+				sourceContext = true
+			}
+			truncated.Interfaces = append(truncated.Interfaces, inter)
+			continue
+		case *Exception:
+			ex := *inter
+			var ok bool
+			if ex.Stacktrace, ok = withoutSourceContext(ex.Stacktrace); ok {
+				sourceContext = true
+			}
+			truncated.Interfaces = append(truncated.Interfaces, &ex)
+			continue
+		case *Exceptions:
+			es := &Exceptions{}
+			for _, ex := range inter.Values {
+				ex := *ex
+				var ok bool
+				if ex.Stacktrace, ok = withoutSourceContext(ex.Stacktrace); ok {
+					sourceContext = true
+				}
+				es.Values = append(es.Values, &ex)
+			}
+			truncated.Interfaces = append(truncated.Interfaces, es)
+			continue
+		}
+		truncated.Interfaces = append(truncated.Interfaces, inter)
+	}
+	if sourceContext {
+		stripped = append(stripped, "source context")
+	}
+
+	if len(packet.Extra) > 0 {
+		truncated.Extra = make(Extra, len(packet.Extra))
+		var large []string
+		for k, v := range packet.Extra {
+			if data, err := json.Marshal(v); err != nil || len(data) > maxTruncatedExtraSize {
+				large = append(large, "extra "+k)
+				continue
+			}
+			truncated.Extra[k] = v
+		}
+		sort.Strings(large)
+		stripped = append(stripped, large...)
+	}
+
+	return &truncated, stripped
+}
+
+// withoutSourceContext returns a copy of s without source context, and
+// whether there was any.
+func withoutSourceContext(s *Stacktrace) (*Stacktrace, bool) {
+	if s == nil {
+		return nil, false
+	}
+	var found bool
+	frames := make([]*StacktraceFrame, len(s.Frames))
+	for i, frame := range s.Frames {
+		f := *frame
+		if f.ContextLine != "" || len(f.PreContext) > 0 || len(f.PostContext) > 0 {
+			found = true
+		}
+		f.ContextLine, f.PreContext, f.PostContext = "", nil, nil
+		frames[i] = &f
+	}
+	return &Stacktrace{Frames: frames, FramesOmitted: s.FramesOmitted}, found
+}
@@ -0,0 +1,42 @@
+package raven
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTruncatePacket(t *testing.T) {
+	frame := &StacktraceFrame{Function: "f", ContextLine: "line", PreContext: []string{"pre"}, PostContext: []string{"post"}}
+	packet := NewPacket("test",
+		&Exception{Value: "boom", Stacktrace: &Stacktrace{Frames: []*StacktraceFrame{frame}}},
+		&Breadcrumbs{},
+		&Threads{},
+		&Message{Message: "test"},
+	)
+	packet.Extra = Extra{"small": 1, "large": strings.Repeat("x", 2048)}
+
+	truncated, stripped := truncatePacket(packet)
+
+	expected := []string{"breadcrumbs", "threads", "source context", "extra large"}
+	if !reflect.DeepEqual(stripped, expected) {
+		t.Errorf("incorrect stripped: got %v, want %v", stripped, expected)
+	}
+	if len(truncated.Interfaces) != 2 {
+		t.Fatalf("incorrect interfaces: got %d, want 2", len(truncated.Interfaces))
+	}
+	f := truncated.Interfaces[0].(*Exception).Stacktrace.Frames[0]
+	if f.Function != "f" || f.ContextLine != "" || f.PreContext != nil || f.PostContext != nil {
+		t.Errorf("incorrect frame: got %+v", f)
+	}
+	if frame.ContextLine != "line" {
+		t.Error("original frame was modified")
+	}
+	if !reflect.DeepEqual(truncated.Extra, Extra{"small": 1}) {
+		t.Errorf("incorrect extra: got %v", truncated.Extra)
+	}
+
+	if _, stripped := truncatePacket(NewPacket("test")); len(stripped) != 0 {
+		t.Errorf("incorrect stripped: got %v, want none", stripped)
+	}
+}
@@ -0,0 +1,17 @@
+package raven
+
+import "time"
+
+// SetEventTTL makes the client discard events older than ttl instead of
+// sending them, resolving their channel with ErrEventExpired. It keeps the
+// events queued, saved to the queue file or the durable directory during a
+// long outage from flooding Sentry with stale errors once it is reachable
+// again. A ttl of 0, the default, keeps events however old.
+func (client *Client) SetEventTTL(ttl time.Duration) {
+	client.mu.Lock()
+	defer client.mu.Unlock()
+	client.eventTTL = ttl
+}
+
+// SetEventTTL sets the time to live of the events of the default *Client
+func SetEventTTL(ttl time.Duration) { DefaultClientInstance().SetEventTTL(ttl) }
@@ -0,0 +1,61 @@
+package raven
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEventTTL(t *testing.T) {
+	client, transport := newTestClient()
+	client.SetEventTTL(time.Hour)
+
+	stale := NewPacket("stale")
+	stale.Timestamp = Timestamp(time.Now().Add(-2 * time.Hour))
+	_, ch := client.Capture(stale, nil)
+	if err := <-ch; err != ErrEventExpired {
+		t.Errorf("incorrect error: got %v, want %v", err, ErrEventExpired)
+	}
+
+	_, ch = client.Capture(NewPacket("fresh"), nil)
+	if err := <-ch; err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(transport.packets) != 1 || transport.packets[0].Message != "fresh" {
+		t.Errorf("incorrect packets sent: %+v", transport.packets)
+	}
+}
+
+func TestEventTTLDurableDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "raven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stale := NewPacket("stale")
+	stale.Timestamp = Timestamp(time.Now().Add(-2 * time.Hour))
+	stale.Init("1")
+	client, _ := newTestClient()
+	client.SetDurableDir(dir)
+	if client.journal(&outgoingPacket{packet: stale}) == "" {
+		t.Fatal("unable to persist the event")
+	}
+
+	client, transport := newTestClient()
+	client.SetDSN("https://u@example.com/1")
+	client.SetEventTTL(time.Hour)
+	if err := client.SetDurableDir(dir); err != nil {
+		t.Fatal(err)
+	}
+	client.Wait()
+
+	if len(transport.packets) != 0 {
+		t.Errorf("expected the stale event to be discarded, got %+v", transport.packets)
+	}
+	if names, _ := filepath.Glob(filepath.Join(dir, "*")); len(names) != 0 {
+		t.Errorf("expected the stale event to be removed, got %v", names)
+	}
+}
@@ -0,0 +1,61 @@
+package raven
+
+import (
+	gocontext "context"
+)
+
+// CaptureErrorAndWaitCtx is like CaptureErrorAndWait, but stops waiting when
+// ctx is done, returning ctx.Err() while the event is still sent in the
+// background. Otherwise it returns the error the transport reported, if any,
+// or ErrSampledOut or ErrFiltered if the event was dropped.
+func (client *Client) CaptureErrorAndWaitCtx(ctx gocontext.Context, err error, tags map[string]string, interfaces ...Interface) (string, error) {
+	if client == nil || err == nil {
+		return "", nil
+	}
+
+	if client.shouldExcludeErr(err.Error()) {
+		return "", ErrFiltered
+	}
+
+	packet := client.newErrorPacket(1, err, interfaces)
+	eventID, ch := client.Capture(packet, tags)
+	return eventID, waitDelivery(ctx, ch)
+}
+
+// CaptureErrorAndWaitCtx is like CaptureErrorAndWait with the default *Client, but stops waiting when ctx is done
+func CaptureErrorAndWaitCtx(ctx gocontext.Context, err error, tags map[string]string, interfaces ...Interface) (string, error) {
+	return DefaultClientInstance().CaptureErrorAndWaitCtx(ctx, err, tags, interfaces...)
+}
+
+// CaptureMessageAndWaitCtx is like CaptureMessageAndWait, but stops waiting
+// when ctx is done, returning ctx.Err() while the event is still sent in the
+// background. Otherwise it returns the error the transport reported, if any,
+// or ErrSampledOut or ErrFiltered if the event was dropped.
+func (client *Client) CaptureMessageAndWaitCtx(ctx gocontext.Context, message string, tags map[string]string, interfaces ...Interface) (string, error) {
+	if client == nil {
+		return "", nil
+	}
+
+	if client.shouldExcludeErr(message) {
+		return "", ErrFiltered
+	}
+
+	packet := NewPacket(message, append(append(interfaces, client.contextInterfaces()...), &Message{message, nil})...)
+	eventID, ch := client.Capture(packet, tags)
+	return eventID, waitDelivery(ctx, ch)
+}
+
+// CaptureMessageAndWaitCtx is like CaptureMessageAndWait with the default *Client, but stops waiting when ctx is done
+func CaptureMessageAndWaitCtx(ctx gocontext.Context, message string, tags map[string]string, interfaces ...Interface) (string, error) {
+	return DefaultClientInstance().CaptureMessageAndWaitCtx(ctx, message, tags, interfaces...)
+}
+
+// waitDelivery waits for the outcome of a capture until ctx is done.
+func waitDelivery(ctx gocontext.Context, ch chan error) error {
+	select {
+	case err := <-ch:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,54 @@
+package raven
+
+import (
+	gocontext "context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCaptureErrorAndWaitCtx(t *testing.T) {
+	client, transport := newTestClient()
+	eventID, err := client.CaptureErrorAndWaitCtx(gocontext.Background(), errors.New("failed"), nil)
+	if eventID == "" || err != nil {
+		t.Errorf("incorrect result: got (%q, %v)", eventID, err)
+	}
+	if len(transport.packets) != 1 {
+		t.Errorf("incorrect packet count: got %d, want 1", len(transport.packets))
+	}
+
+	failure := errors.New("connection refused")
+	client.Transport = &failingTransport{failure}
+	if _, err := client.CaptureMessageAndWaitCtx(gocontext.Background(), "failed", nil); err != failure {
+		t.Errorf("incorrect error: got %v, want %v", err, failure)
+	}
+}
+
+func TestCaptureAndWaitCtxDropped(t *testing.T) {
+	client, _ := newTestClient()
+	client.SetIgnoreErrors([]string{"ignored"})
+	if _, err := client.CaptureMessageAndWaitCtx(gocontext.Background(), "ignored", nil); err != ErrFiltered {
+		t.Errorf("incorrect error: got %v, want %v", err, ErrFiltered)
+	}
+
+	client.SetSampleRate(0)
+	if _, err := client.CaptureErrorAndWaitCtx(gocontext.Background(), errors.New("failed"), nil); err != ErrSampledOut {
+		t.Errorf("incorrect error: got %v, want %v", err, ErrSampledOut)
+	}
+}
+
+func TestCaptureAndWaitCtxDeadline(t *testing.T) {
+	client, _ := newTestClient()
+	transport := &blockingTransport{started: make(chan struct{}, 1), release: make(chan struct{})}
+	client.Transport = transport
+
+	ctx, cancel := gocontext.WithTimeout(gocontext.Background(), 20*time.Millisecond)
+	defer cancel()
+	eventID, err := client.CaptureErrorAndWaitCtx(ctx, errors.New("failed"), nil)
+	if eventID == "" || err != gocontext.DeadlineExceeded {
+		t.Errorf("incorrect result: got (%q, %v), want an event ID and %v", eventID, err, gocontext.DeadlineExceeded)
+	}
+
+	close(transport.release)
+	client.Wait()
+}
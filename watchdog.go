@@ -0,0 +1,179 @@
+package raven
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The number of distinct goroutine stacks attached to a goroutine leak event,
+// largest groups first.
+var MaxGoroutineStacks = 10
+
+// Growth between two samples is ignored below this many goroutines, where
+// doubling is normal and harmless.
+const minGoroutinesForGrowth = 100
+
+// https://develop.sentry.dev/sdk/event-payloads/threads/
+type Thread struct {
+	ID         string      `json:"id"`
+	Name       string      `json:"name,omitempty"`
+	Crashed    bool        `json:"crashed"`
+	Current    bool        `json:"current"`
+	Stacktrace *Stacktrace `json:"stacktrace,omitempty"`
+}
+
+type Threads struct {
+	Values []*Thread `json:"values"`
+}
+
+func (t *Threads) Class() string { return "threads" }
+
+// Culprit returns the culprit of the crashed thread, or else of the current
+// one.
+func (t *Threads) Culprit() string {
+	for _, crashed := range []bool{true, false} {
+		for _, thread := range t.Values {
+			if (crashed && thread.Crashed || !crashed && thread.Current) && thread.Stacktrace != nil {
+				return thread.Stacktrace.Culprit()
+			}
+		}
+	}
+	return ""
+}
+
+// WatchGoroutines starts a watchdog sampling the number of goroutines every
+// interval. It sends a WARNING event with the most common goroutine stacks
+// when the count exceeds threshold, or grows by more than growth (e.g. 0.5
+// for 50%) between two samples. Either check is disabled by a zero value. To
+// avoid flooding Sentry with a slow leak, another event is only sent once the
+// count has doubled since the last one. The returned function stops the
+// watchdog.
+func (client *Client) WatchGoroutines(threshold int, growth float64, interval time.Duration) (stop func()) {
+	w := &goroutineWatchdog{threshold: threshold, growth: growth}
+	done := make(chan struct{})
+	var once sync.Once
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			if message := w.check(runtime.NumGoroutine()); message != "" {
+				client.captureGoroutineLeak(message, w)
+			}
+		}
+	}()
+
+	return func() { once.Do(func() { close(done) }) }
+}
+
+// WatchGoroutines starts a goroutine watchdog reporting to the default *Client
+func WatchGoroutines(threshold int, growth float64, interval time.Duration) (stop func()) {
+	return DefaultClientInstance().WatchGoroutines(threshold, growth, interval)
+}
+
+type goroutineWatchdog struct {
+	threshold int
+	growth    float64
+
+	previous int
+	reported int
+}
+
+// check records a sample, returning why it should be reported, if it should.
+func (w *goroutineWatchdog) check(count int) string {
+	previous := w.previous
+	w.previous = count
+
+	if w.reported > 0 && count < 2*w.reported {
+		if w.threshold > 0 && count < w.threshold {
+			w.reported = 0
+		}
+		return ""
+	}
+
+	var message string
+	switch {
+	case w.threshold > 0 && count > w.threshold:
+		message = fmt.Sprintf("goroutine count %d exceeds threshold %d", count, w.threshold)
+	case w.growth > 0 && previous > 0 && count >= minGoroutinesForGrowth &&
+		float64(count-previous) > w.growth*float64(previous):
+		message = fmt.Sprintf("goroutine count grew from %d to %d", previous, count)
+	default:
+		return ""
+	}
+	w.reported = count
+	return message
+}
+
+func (client *Client) captureGoroutineLeak(message string, w *goroutineWatchdog) {
+	packet := NewPacket(message, &Message{message, nil}, goroutineThreads(client.IncludePaths()))
+	packet.Level = WARNING
+	packet.Logger = "raven.watchdog"
+	packet.Fingerprint = []string{"goroutine-leak"}
+	packet.Extra["goroutines.threshold"] = w.threshold
+	packet.Extra["goroutines.count"] = w.reported
+	client.Capture(packet, nil)
+}
+
+// A goroutineGroup is a set of goroutines with the same stack.
+type goroutineGroup struct {
+	stack []uintptr
+	count int
+}
+
+// byGoroutineCount sorts goroutine groups from the largest to the smallest.
+type byGoroutineCount []*goroutineGroup
+
+func (s byGoroutineCount) Len() int           { return len(s) }
+func (s byGoroutineCount) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byGoroutineCount) Less(i, j int) bool { return s[i].count > s[j].count }
+
+// goroutineThreads groups the stacks of all goroutines, returning the most
+// common ones.
+func goroutineThreads(appPackagePrefixes []string) *Threads {
+	var records []runtime.StackRecord
+	n, ok := runtime.GoroutineProfile(nil)
+	for !ok {
+		// Leave room for goroutines started in the meantime.
+		records = make([]runtime.StackRecord, n+10)
+		n, ok = runtime.GoroutineProfile(records)
+	}
+	records = records[:n]
+
+	groups := make(map[[32]uintptr]*goroutineGroup)
+	for _, r := range records {
+		g, ok := groups[r.Stack0]
+		if !ok {
+			g = &goroutineGroup{stack: r.Stack()}
+			groups[r.Stack0] = g
+		}
+		g.count++
+	}
+
+	sorted := make(byGoroutineCount, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Sort(sorted)
+	if len(sorted) > MaxGoroutineStacks {
+		sorted = sorted[:MaxGoroutineStacks]
+	}
+
+	threads := &Threads{}
+	for i, g := range sorted {
+		threads.Values = append(threads.Values, &Thread{
+			ID:         fmt.Sprint(i),
+			Name:       fmt.Sprintf("%d goroutines", g.count),
+			Stacktrace: stacktraceFromPCs(g.stack, 0, appPackagePrefixes),
+		})
+	}
+	return threads
+}
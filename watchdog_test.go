@@ -0,0 +1,62 @@
+package raven
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoroutineWatchdogCheck(t *testing.T) {
+	w := &goroutineWatchdog{threshold: 1000, growth: 0.5}
+	tests := []struct {
+		count    int
+		reported bool
+	}{
+		{50, false},
+		{90, false},   // grew 80% but too few goroutines to matter
+		{200, true},   // grew more than 50%
+		{250, false},  // already reported, not doubled
+		{350, false},  // still not doubled
+		{1100, true},  // above threshold
+		{1500, false}, // not doubled since last report
+		{2300, true},
+	}
+
+	for i, test := range tests {
+		if message := w.check(test.count); (message != "") != test.reported {
+			t.Errorf("%d: incorrect decision for %d goroutines: got %q", i, test.count, message)
+		}
+	}
+}
+
+func TestWatchGoroutines(t *testing.T) {
+	client, transport := newTestClient()
+	block := make(chan struct{})
+	for i := 0; i < 5; i++ {
+		go func() { <-block }()
+	}
+	defer close(block)
+
+	stop := client.WatchGoroutines(1, 0, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	stop()
+	client.Wait()
+
+	if len(transport.packets) == 0 {
+		t.Fatal("expected a goroutine leak event")
+	}
+	packet := transport.packets[0]
+	if packet.Level != WARNING || !strings.HasPrefix(packet.Message, "goroutine count") {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+
+	var threads *Threads
+	for _, inter := range packet.Interfaces {
+		if th, ok := inter.(*Threads); ok {
+			threads = th
+		}
+	}
+	if threads == nil || len(threads.Values) == 0 || len(threads.Values[0].Stacktrace.Frames) == 0 {
+		t.Errorf("expected goroutine stacks, got %+v", threads)
+	}
+}
@@ -0,0 +1,111 @@
+package raven
+
+import "fmt"
+
+// The exit code a service wrapped with ServiceExecute stops with when it
+// panics, so that the service control manager applies its recovery actions.
+var ServicePanicExitCode uint32 = 1
+
+// RunService calls run, typically starting a Windows service with svc.Run of
+// golang.org/x/sys/windows/svc, and reports the error it returns, e.g. when
+// the process isn't started by the service control manager. It waits for the
+// event to be sent, as the process is about to exit.
+//
+//	if err := raven.RunService("myservice", func() error { return svc.Run("myservice", &handler{}) }); err != nil {
+//		log.Fatal(err)
+//	}
+func (client *Client) RunService(name string, run func() error) error {
+	err := run()
+	if err != nil {
+		client.CaptureErrorAndWait(err, map[string]string{"windows.service": name})
+	}
+	return err
+}
+
+// RunService calls run and reports the error it returns with the default *Client
+func RunService(name string, run func() error) error {
+	return DefaultClientInstance().RunService(name, run)
+}
+
+// ServiceExecute runs execute, the body of the Execute method of a
+// golang.org/x/sys/windows/svc Handler, and reports the service stopping with
+// a non-zero exit code or on a panic. Panics are recovered and the service
+// stops with ServicePanicExitCode. Events are tagged with the service name and
+// sent before ServiceExecute returns.
+//
+//	func (h *handler) Execute(args []string, r <-chan svc.ChangeRequest, s chan<- svc.Status) (bool, uint32) {
+//		return raven.ServiceExecute("myservice", func() (bool, uint32) { return h.execute(args, r, s) })
+//	}
+func (client *Client) ServiceExecute(name string, execute func() (svcSpecificEC bool, exitCode uint32)) (svcSpecificEC bool, exitCode uint32) {
+	tags := map[string]string{"windows.service": name}
+	rval, _ := client.CapturePanicAndWait(func() { svcSpecificEC, exitCode = execute() }, tags)
+	if rval != nil {
+		return true, ServicePanicExitCode
+	}
+
+	if exitCode != 0 {
+		message := fmt.Sprintf("service %s stopped with exit code %d", name, exitCode)
+		packet := NewPacketWithExtra(message, Extra{"exit_code": exitCode, "service_specific": svcSpecificEC}, &Message{message, nil})
+		_, ch := client.Capture(packet, tags)
+		<-ch
+	}
+	return svcSpecificEC, exitCode
+}
+
+// ServiceExecute runs execute, reporting the service failing with the default *Client
+func ServiceExecute(name string, execute func() (svcSpecificEC bool, exitCode uint32)) (svcSpecificEC bool, exitCode uint32) {
+	return DefaultClientInstance().ServiceExecute(name, execute)
+}
+
+// An EventLog writes to the Windows event log, like *eventlog.Log and
+// debug.Log of golang.org/x/sys/windows/svc.
+type EventLog interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Error(eid uint32, msg string) error
+}
+
+// EventLogBreadcrumbs wraps log so that every entry written to the event log
+// is also recorded as a breadcrumb, giving events the history the service
+// logged before them.
+//
+//	elog, err := eventlog.Open("myservice")
+//	...
+//	logger := raven.EventLogBreadcrumbs(elog)
+func (client *Client) EventLogBreadcrumbs(log EventLog) EventLog {
+	return &breadcrumbEventLog{client, log}
+}
+
+// EventLogBreadcrumbs wraps log, recording its entries as breadcrumbs of the default *Client
+func EventLogBreadcrumbs(log EventLog) EventLog {
+	return DefaultClientInstance().EventLogBreadcrumbs(log)
+}
+
+type breadcrumbEventLog struct {
+	client *Client
+	log    EventLog
+}
+
+func (l *breadcrumbEventLog) Info(eid uint32, msg string) error {
+	l.record(INFO, eid, msg)
+	return l.log.Info(eid, msg)
+}
+
+func (l *breadcrumbEventLog) Warning(eid uint32, msg string) error {
+	l.record(WARNING, eid, msg)
+	return l.log.Warning(eid, msg)
+}
+
+func (l *breadcrumbEventLog) Error(eid uint32, msg string) error {
+	l.record(ERROR, eid, msg)
+	return l.log.Error(eid, msg)
+}
+
+func (l *breadcrumbEventLog) record(level Severity, eid uint32, msg string) {
+	l.client.AddBreadcrumb(&Breadcrumb{
+		Category: "eventlog",
+		Message:  msg,
+		Level:    level,
+		Data:     map[string]interface{}{"event_id": eid},
+	})
+}
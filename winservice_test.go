@@ -0,0 +1,115 @@
+package raven
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func serviceTag(packet *Packet) string {
+	for _, tag := range packet.Tags {
+		if tag.Key == "windows.service" {
+			return tag.Value
+		}
+	}
+	return ""
+}
+
+func TestRunService(t *testing.T) {
+	client, transport := newTestClient()
+	failed := errors.New("the service process could not connect to the service controller")
+	if err := client.RunService("billing", func() error { return failed }); err != failed {
+		t.Errorf("incorrect error: got %v, want %v", err, failed)
+	}
+	client.RunService("billing", func() error { return nil })
+
+	if len(transport.packets) != 1 {
+		t.Fatalf("incorrect number of packets sent: got %d, want 1", len(transport.packets))
+	}
+	if packet := transport.packets[0]; packet.Message != failed.Error() || serviceTag(packet) != "billing" {
+		t.Errorf("incorrect packet: %+v", packet)
+	}
+}
+
+func TestServiceExecute(t *testing.T) {
+	client, transport := newTestClient()
+
+	if ssec, code := client.ServiceExecute("billing", func() (bool, uint32) { return false, 0 }); ssec || code != 0 {
+		t.Errorf("incorrect exit code: got %t, %d", ssec, code)
+	}
+	if len(transport.packets) != 0 {
+		t.Errorf("unexpected packets for a clean stop: %+v", transport.packets)
+	}
+
+	if ssec, code := client.ServiceExecute("billing", func() (bool, uint32) { return true, 3 }); !ssec || code != 3 {
+		t.Errorf("incorrect exit code: got %t, %d", ssec, code)
+	}
+	if ssec, code := client.ServiceExecute("billing", func() (bool, uint32) { panic("boom") }); !ssec || code != ServicePanicExitCode {
+		t.Errorf("incorrect exit code after a panic: got %t, %d", ssec, code)
+	}
+
+	if len(transport.packets) != 2 {
+		t.Fatalf("incorrect number of packets sent: got %d, want 2", len(transport.packets))
+	}
+	stopped := transport.packets[0]
+	if stopped.Message != "service billing stopped with exit code 3" || stopped.Extra["exit_code"] != uint32(3) || serviceTag(stopped) != "billing" {
+		t.Errorf("incorrect stop packet: %+v", stopped)
+	}
+	if panicked := transport.packets[1]; panicked.Message != "boom" || serviceTag(panicked) != "billing" {
+		t.Errorf("incorrect panic packet: %+v", panicked)
+	}
+}
+
+type eventLogEntry struct {
+	level string
+	eid   uint32
+	msg   string
+}
+
+type testEventLog struct{ entries []eventLogEntry }
+
+func (l *testEventLog) Info(eid uint32, msg string) error {
+	l.entries = append(l.entries, eventLogEntry{"info", eid, msg})
+	return nil
+}
+
+func (l *testEventLog) Warning(eid uint32, msg string) error {
+	l.entries = append(l.entries, eventLogEntry{"warning", eid, msg})
+	return nil
+}
+
+func (l *testEventLog) Error(eid uint32, msg string) error {
+	l.entries = append(l.entries, eventLogEntry{"error", eid, msg})
+	return nil
+}
+
+func TestEventLogBreadcrumbs(t *testing.T) {
+	client, transport := newTestClient()
+	elog := &testEventLog{}
+	log := client.EventLogBreadcrumbs(elog)
+	log.Info(1, "starting")
+	log.Warning(2, "config missing, using defaults")
+	log.Error(3, "listener failed")
+
+	expected := []eventLogEntry{{"info", 1, "starting"}, {"warning", 2, "config missing, using defaults"}, {"error", 3, "listener failed"}}
+	if !reflect.DeepEqual(elog.entries, expected) {
+		t.Errorf("incorrect event log entries: got %+v, want %+v", elog.entries, expected)
+	}
+
+	client.CaptureMessage("failed", nil)
+	client.Wait()
+	var breadcrumbs *Breadcrumbs
+	for _, inter := range transport.packets[0].Interfaces {
+		if b, ok := inter.(*Breadcrumbs); ok {
+			breadcrumbs = b
+		}
+	}
+	if breadcrumbs == nil || len(breadcrumbs.Values) != 3 {
+		t.Fatalf("incorrect breadcrumbs: %+v", breadcrumbs)
+	}
+	for i, b := range breadcrumbs.Values {
+		if b.Category != "eventlog" || string(b.Level) != expected[i].level || b.Message != expected[i].msg || b.Data["event_id"] != expected[i].eid {
+			t.Errorf("incorrect breadcrumb %d: %+v", i, b)
+		}
+	}
+}